@@ -0,0 +1,57 @@
+package apispec
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/fulcrumproject/commons/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	ID        properties.UUID `json:"id"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type testService struct {
+	testBase
+	Name       string          `json:"name"`
+	Size       int             `json:"size,omitempty"`
+	Ratio      float64         `json:"ratio"`
+	Enabled    *bool           `json:"enabled"`
+	Tags       []string        `json:"tags"`
+	Properties properties.JSON `json:"properties"`
+	Parent     *testService    `json:"parent"`
+	internal   string
+	Ignored    string `json:"-"`
+}
+
+func TestGenerator_SchemaOf(t *testing.T) {
+	g := newGenerator()
+
+	ref := g.schemaOf(reflect.TypeOf(&testService{}))
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/testService"}, ref)
+
+	s := g.components["testService"]
+	require.NotNil(t, s)
+	assert.Equal(t, "object", s.Type)
+	assert.ElementsMatch(t, []string{"id", "createdAt", "name", "ratio"}, s.Required)
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid"}, s.Properties["id"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, s.Properties["createdAt"])
+	assert.Equal(t, &Schema{Type: "integer"}, s.Properties["size"])
+	assert.Equal(t, &Schema{Type: "number"}, s.Properties["ratio"])
+	assert.Equal(t, &Schema{Type: "boolean"}, s.Properties["enabled"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, s.Properties["tags"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{}}, s.Properties["properties"])
+	assert.Equal(t, ref, s.Properties["parent"])
+	assert.NotContains(t, s.Properties, "internal")
+	assert.NotContains(t, s.Properties, "Ignored")
+}
+
+func TestComponentName(t *testing.T) {
+	assert.Equal(t, "Page_testService", componentName(reflect.TypeOf(response.Page[testService]{})))
+	assert.Equal(t, "ErrResponse", componentName(reflect.TypeOf(response.ErrResponse{})))
+}
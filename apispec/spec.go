@@ -0,0 +1,187 @@
+package apispec
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+var pathParamRe = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+var methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// Operation describes an API endpoint
+type Operation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Tags        []string
+	// Request is a value of the request body type, nil for operations without body
+	Request any
+	// Response is a value of the response body type, e.g. response.Page[T]{} for listings
+	Response any
+	// Status is the success status code, defaults to 200
+	Status int
+	// Action and Object are the permission required to call the operation, empty for public operations
+	Action auth.Action
+	Object auth.ObjectType
+}
+
+// Permission is the permission required by an operation as exposed in the document
+type Permission struct {
+	Action auth.Action     `json:"action"`
+	Object auth.ObjectType `json:"object"`
+}
+
+// Spec collects operations and generates the OpenAPI document
+type Spec struct {
+	mu      sync.Mutex
+	title   string
+	version string
+	ops     []Operation
+}
+
+// New creates an empty spec
+func New(title, version string) *Spec {
+	return &Spec{title: title, version: version}
+}
+
+// Add registers an operation, it panics on unsupported methods like chi does
+func (s *Spec) Add(op Operation) {
+	if !methods[op.Method] {
+		panic(fmt.Sprintf("apispec: unsupported method %q", op.Method))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = append(s.ops, op)
+}
+
+// Operations returns the registered operations
+func (s *Spec) Operations() []Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Operation(nil), s.ops...)
+}
+
+// Route registers the operation and mounts the handler on the router behind the request validator
+func (s *Spec) Route(r chi.Router, op Operation, h http.Handler, middlewares ...func(http.Handler) http.Handler) {
+	s.Add(op)
+	mws := append([]func(http.Handler) http.Handler{s.Validator(op)}, middlewares...)
+	r.With(mws...).Method(op.Method, op.Path, h)
+}
+
+// Document generates the OpenAPI 3.1 document
+func (s *Spec) Document() map[string]any {
+	g := newGenerator()
+	errSchema := g.schemaOf(reflect.TypeOf(response.ErrResponse{}))
+
+	paths := make(map[string]map[string]any)
+	for _, op := range s.Operations() {
+		path := pathParamRe.ReplaceAllString(op.Path, "{$1}")
+		item, ok := paths[path]
+		if !ok {
+			item = make(map[string]any)
+			paths[path] = item
+		}
+		item[strings.ToLower(op.Method)] = s.operationObject(g, op, errSchema)
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   s.title,
+			"version": s.version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func (s *Spec) operationObject(g *generator, op Operation, errSchema *Schema) map[string]any {
+	obj := map[string]any{}
+	if op.OperationID != "" {
+		obj["operationId"] = op.OperationID
+	}
+	if op.Summary != "" {
+		obj["summary"] = op.Summary
+	}
+	if len(op.Tags) > 0 {
+		obj["tags"] = op.Tags
+	}
+
+	var params []map[string]any
+	for _, m := range pathParamRe.FindAllStringSubmatch(op.Path, -1) {
+		params = append(params, map[string]any{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   &Schema{Type: "string"},
+		})
+	}
+	if params != nil {
+		obj["parameters"] = params
+	}
+
+	if op.Request != nil {
+		obj["requestBody"] = map[string]any{
+			"required": true,
+			"content":  jsonContent(g.schemaOf(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = jsonContent(g.schemaOf(reflect.TypeOf(op.Response)))
+	}
+	obj["responses"] = map[string]any{
+		strconv.Itoa(status): success,
+		"default": map[string]any{
+			"description": "Error",
+			"content":     jsonContent(errSchema),
+		},
+	}
+
+	if op.Action != "" {
+		obj["security"] = []map[string][]string{{"bearerAuth": {}}}
+		obj["x-permission"] = Permission{Action: op.Action, Object: op.Object}
+	}
+	return obj
+}
+
+// Handler serves the OpenAPI document as JSON
+func (s *Spec) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, s.Document())
+	})
+}
+
+func jsonContent(schema *Schema) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
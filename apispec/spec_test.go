@@ -0,0 +1,105 @@
+package apispec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fulcrumproject/commons/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCreateService struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+func TestSpec_Document(t *testing.T) {
+	spec := New("Test API", "1.0.0")
+	spec.Add(Operation{
+		Method:      http.MethodGet,
+		Path:        "/services",
+		OperationID: "listServices",
+		Response:    response.Page[testService]{},
+		Action:      "list",
+		Object:      "service",
+	})
+	spec.Add(Operation{
+		Method:   http.MethodPost,
+		Path:     "/services",
+		Request:  testCreateService{},
+		Response: testService{},
+		Status:   http.StatusCreated,
+		Action:   "create",
+		Object:   "service",
+	})
+	spec.Add(Operation{
+		Method: http.MethodGet,
+		Path:   "/services/{id:[0-9a-f-]+}/health",
+	})
+
+	w := httptest.NewRecorder()
+	spec.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string           `json:"operationId"`
+			Parameters  []map[string]any `json:"parameters"`
+			RequestBody map[string]any   `json:"requestBody"`
+			Responses   map[string]any   `json:"responses"`
+			Security    []map[string]any `json:"security"`
+			Permission  *Permission      `json:"x-permission"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]Schema `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	list := doc.Paths["/services"]["get"]
+	assert.Equal(t, "listServices", list.OperationID)
+	assert.Equal(t, &Permission{Action: "list", Object: "service"}, list.Permission)
+	assert.Len(t, list.Security, 1)
+	assert.Contains(t, list.Responses, "200")
+	assert.Contains(t, list.Responses, "default")
+
+	create := doc.Paths["/services"]["post"]
+	assert.NotNil(t, create.RequestBody)
+	assert.Contains(t, create.Responses, "201")
+
+	health := doc.Paths["/services/{id}/health"]["get"]
+	assert.Nil(t, health.Permission)
+	assert.Nil(t, health.Security)
+	require.Len(t, health.Parameters, 1)
+	assert.Equal(t, "id", health.Parameters[0]["name"])
+
+	for _, name := range []string{"Page_testService", "testService", "testCreateService", "ErrResponse"} {
+		assert.Contains(t, doc.Components.Schemas, name)
+	}
+}
+
+func TestSpec_Route(t *testing.T) {
+	spec := New("Test API", "1.0.0")
+	r := chi.NewRouter()
+	called := false
+	spec.Route(r, Operation{Method: http.MethodPost, Path: "/services", Request: testCreateService{}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+	assert.Len(t, spec.Operations(), 1)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/services", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, called)
+
+	assert.Panics(t, func() { spec.Add(Operation{Method: "FETCH", Path: "/"}) })
+}
@@ -0,0 +1,148 @@
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/commons/response"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// Validator returns a middleware checking the request body against the operation request schema,
+// operations without a request type are passed through
+func (s *Spec) Validator(op Operation) func(http.Handler) http.Handler {
+	if op.Request == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	g := newGenerator()
+	schema := g.schemaOf(reflect.TypeOf(op.Request))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				render.Render(w, r, response.ErrInvalidRequest(err))
+				return
+			}
+
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			var value any
+			if err := dec.Decode(&value); err != nil {
+				render.Render(w, r, response.ErrInvalidRequest(fmt.Errorf("invalid JSON body: %w", err)))
+				return
+			}
+
+			v := validator{components: g.components}
+			v.validate("", schema, value)
+			if len(v.errs) > 0 {
+				render.Render(w, r, response.MultiErrInvalidRequest(v.errs))
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type validator struct {
+	components map[string]*Schema
+	errs       []response.ValidationError
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	if path == "" {
+		path = "$"
+	}
+	v.errs = append(v.errs, response.ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(path string, schema *Schema, value any) {
+	if schema.Ref != "" {
+		schema = v.components[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	if schema.Type == "" || value == nil {
+		// untyped schemas accept anything and null is accepted for optional values
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			v.fail(path, "must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				v.fail(join(path, name), "is required")
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := schema.Properties[k]; ok {
+				v.validate(join(path, k), prop, obj[k])
+			} else if schema.AdditionalProperties != nil {
+				v.validate(join(path, k), schema.AdditionalProperties, obj[k])
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			v.fail(path, "must be an array")
+			return
+		}
+		for i, item := range arr {
+			v.validate(fmt.Sprintf("%s[%d]", path, i), schema.Items, item)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			v.fail(path, "must be a string")
+			return
+		}
+		switch schema.Format {
+		case "uuid":
+			if _, err := uuid.Parse(str); err != nil {
+				v.fail(path, "must be a UUID")
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				v.fail(path, "must be a RFC 3339 date-time")
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "must be a boolean")
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			v.fail(path, "must be an integer")
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			v.fail(path, "must be a number")
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
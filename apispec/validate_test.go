@@ -0,0 +1,95 @@
+package apispec
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fulcrumproject/commons/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedErrors []response.ValidationError
+	}{
+		{
+			name:           "Valid body",
+			body:           `{"id":"0190a5c1-3d3e-7c4e-8f00-000000000001","createdAt":"2024-01-01T00:00:00Z","name":"db","ratio":1.5,"tags":["a"],"parent":null}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Schema violations",
+			body:           `{"id":"nope","createdAt":"yesterday","size":1.5,"ratio":"high","tags":[1],"parent":{"name":3}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []response.ValidationError{
+				{Path: "name", Message: "is required"},
+				{Path: "createdAt", Message: "must be a RFC 3339 date-time"},
+				{Path: "id", Message: "must be a UUID"},
+				{Path: "parent.id", Message: "is required"},
+				{Path: "parent.createdAt", Message: "is required"},
+				{Path: "parent.ratio", Message: "is required"},
+				{Path: "parent.name", Message: "must be a string"},
+				{Path: "ratio", Message: "must be a number"},
+				{Path: "size", Message: "must be an integer"},
+				{Path: "tags[0]", Message: "must be a string"},
+			},
+		},
+		{
+			name:           "Not an object",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []response.ValidationError{{Path: "$", Message: "must be an object"}},
+		},
+	}
+
+	spec := New("Test API", "1.0.0")
+	mw := spec.Validator(Operation{Method: http.MethodPost, Path: "/services", Request: testService{}})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received = string(body)
+			}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/services", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.body, received)
+				return
+			}
+			if tt.expectedErrors != nil {
+				var resp response.ErrResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedErrors, resp.ValidationErrors)
+			}
+		})
+	}
+}
+
+func TestValidator_NoRequest(t *testing.T) {
+	spec := New("Test API", "1.0.0")
+	h := spec.Validator(Operation{Method: http.MethodGet, Path: "/services"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/services", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Hook is a named pair of lifecycle functions, either of which can be nil
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// HealthCheck is a named readiness check
+type HealthCheck struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Job is a named background function running until its context is cancelled
+type Job struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Option configures an App
+type Option func(*App)
+
+// WithAddr sets the HTTP listen address (default ":8080")
+func WithAddr(addr string) Option {
+	return func(a *App) {
+		a.addr = addr
+	}
+}
+
+// WithLogger sets the logger used for lifecycle messages (default slog.Default)
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *App) {
+		a.logger = logger
+	}
+}
+
+// WithShutdownTimeout bounds the graceful shutdown (default 30s)
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.shutdownTimeout = d
+	}
+}
+
+// WithMiddlewares adds middlewares applied to every route
+func WithMiddlewares(middlewares ...func(http.Handler) http.Handler) Option {
+	return func(a *App) {
+		a.middlewares = append(a.middlewares, middlewares...)
+	}
+}
+
+// App composes the HTTP server, background jobs and lifecycle hooks of a service
+//
+// Run starts the hooks in registration order, then serves HTTP and runs the jobs
+// until a termination signal, a job or the server fails, and finally stops the server,
+// cancels the jobs and stops the hooks in reverse registration order
+type App struct {
+	name            string
+	addr            string
+	logger          *slog.Logger
+	shutdownTimeout time.Duration
+	middlewares     []func(http.Handler) http.Handler
+
+	routers      []func(r chi.Router)
+	jobs         []Job
+	hooks        []Hook
+	healthChecks []HealthCheck
+
+	mu       sync.Mutex
+	listener net.Listener
+}
+
+// New creates an app named after the service
+func New(name string, opts ...Option) *App {
+	a := &App{
+		name:            name,
+		addr:            ":8080",
+		logger:          slog.Default(),
+		shutdownTimeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// AddRouter registers routes on the app router
+func (a *App) AddRouter(fn func(r chi.Router)) *App {
+	a.routers = append(a.routers, fn)
+	return a
+}
+
+// AddJob registers a background job
+// A job returning before the app is stopped, with or without error, stops the app
+func (a *App) AddJob(name string, fn func(ctx context.Context) error) *App {
+	a.jobs = append(a.jobs, Job{Name: name, Fn: fn})
+	return a
+}
+
+// AddHook registers a lifecycle hook
+// A failing OnStart aborts the start after stopping the hooks already started
+func (a *App) AddHook(hook Hook) *App {
+	a.hooks = append(a.hooks, hook)
+	return a
+}
+
+// AddHealthCheck registers a readiness check exposed on /readyz
+func (a *App) AddHealthCheck(name string, fn func(ctx context.Context) error) *App {
+	a.healthChecks = append(a.healthChecks, HealthCheck{Name: name, Fn: fn})
+	return a
+}
+
+// Handler builds the HTTP handler with health endpoints and the registered routers
+func (a *App) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Use(a.middlewares...)
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, map[string]string{"status": "ok"})
+	})
+	r.Get("/readyz", a.readiness)
+	for _, fn := range a.routers {
+		fn(r)
+	}
+	return r
+}
+
+// Addr returns the address the server is listening on, empty before Run
+func (a *App) Addr() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.listener == nil {
+		return ""
+	}
+	return a.listener.Addr().String()
+}
+
+// Run runs the app until SIGINT or SIGTERM
+func (a *App) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.RunContext(ctx)
+}
+
+// RunContext runs the app until ctx is cancelled
+func (a *App) RunContext(ctx context.Context) error {
+	a.logger.Info("starting application", "name", a.name)
+
+	started, err := a.startHooks(ctx)
+	if err != nil {
+		return errors.Join(err, a.stopHooks(started))
+	}
+
+	listener, err := net.Listen("tcp", a.addr)
+	if err != nil {
+		return errors.Join(fmt.Errorf("cannot listen on %s: %w", a.addr, err), a.stopHooks(started))
+	}
+	a.mu.Lock()
+	a.listener = listener
+	a.mu.Unlock()
+
+	server := &http.Server{Handler: a.Handler()}
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	failures := make(chan error, len(a.jobs)+1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.logger.Info("http server listening", "addr", listener.Addr().String())
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failures <- fmt.Errorf("http server failed: %w", err)
+		}
+	}()
+	for _, job := range a.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := job.Fn(jobsCtx)
+			if jobsCtx.Err() != nil {
+				return
+			}
+			if err == nil {
+				err = errors.New("exited")
+			}
+			failures <- fmt.Errorf("job %s: %w", job.Name, err)
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("shutting down application", "name", a.name)
+	case runErr = <-failures:
+		a.logger.Error("application failure, shutting down", "error", runErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		runErr = errors.Join(runErr, fmt.Errorf("http server shutdown: %w", err))
+	}
+	cancelJobs()
+	wg.Wait()
+
+	return errors.Join(runErr, a.stopHooks(started))
+}
+
+// startHooks returns the number of hooks started successfully
+func (a *App) startHooks(ctx context.Context) (int, error) {
+	for i, hook := range a.hooks {
+		if hook.OnStart == nil {
+			continue
+		}
+		if err := hook.OnStart(ctx); err != nil {
+			return i, fmt.Errorf("start hook %s: %w", hook.Name, err)
+		}
+	}
+	return len(a.hooks), nil
+}
+
+// stopHooks stops the first started hooks in reverse order
+func (a *App) stopHooks(started int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := started - 1; i >= 0; i-- {
+		hook := a.hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+		if err := hook.OnStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop hook %s: %w", hook.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *App) readiness(w http.ResponseWriter, r *http.Request) {
+	failed := map[string]string{}
+	for _, check := range a.healthChecks {
+		if err := check.Fn(r.Context()); err != nil {
+			failed[check.Name] = err.Error()
+		}
+	}
+	if len(failed) > 0 {
+		render.Status(r, http.StatusServiceUnavailable)
+		render.JSON(w, r, map[string]any{"status": "unavailable", "checks": failed})
+		return
+	}
+	render.JSON(w, r, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApp() *App {
+	return New("test",
+		WithAddr("127.0.0.1:0"),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithShutdownTimeout(time.Second),
+	)
+}
+
+func recordingHook(name string, events *[]string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			*events = append(*events, "start "+name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			*events = append(*events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestApp_Handler(t *testing.T) {
+	ready := errors.New("db not ready")
+	a := newTestApp().
+		AddRouter(func(r chi.Router) {
+			r.Get("/hello", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("hello"))
+			})
+		}).
+		AddHealthCheck("db", func(ctx context.Context) error { return ready })
+
+	h := a.Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, "hello", get("/hello").Body.String())
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+
+	w := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "db not ready")
+
+	ready = nil
+	assert.Equal(t, http.StatusOK, get("/readyz").Code)
+}
+
+func TestApp_RunContext(t *testing.T) {
+	var events []string
+	jobStarted := make(chan struct{})
+	a := newTestApp().
+		AddHook(recordingHook("db", &events, nil)).
+		AddHook(recordingHook("cache", &events, nil)).
+		AddRouter(func(r chi.Router) {
+			r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("pong"))
+			})
+		}).
+		AddJob("relay", func(ctx context.Context) error {
+			close(jobStarted)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.RunContext(ctx) }()
+
+	<-jobStarted
+	require.Eventually(t, func() bool { return a.Addr() != "" }, time.Second, time.Millisecond)
+	resp, err := http.Get("http://" + a.Addr() + "/ping")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "pong", string(body))
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"start db", "start cache", "stop cache", "stop db"}, events)
+}
+
+func TestApp_StartHookFailure(t *testing.T) {
+	var events []string
+	a := newTestApp().
+		AddHook(recordingHook("db", &events, nil)).
+		AddHook(recordingHook("cache", &events, errors.New("unreachable"))).
+		AddHook(recordingHook("bus", &events, nil))
+
+	err := a.RunContext(context.Background())
+	assert.EqualError(t, err, "start hook cache: unreachable")
+	assert.Equal(t, []string{"start db", "start cache", "stop db"}, events)
+}
+
+func TestApp_JobFailure(t *testing.T) {
+	var events []string
+	a := newTestApp().
+		AddHook(recordingHook("db", &events, nil)).
+		AddJob("worker", func(ctx context.Context) error { return errors.New("crashed") })
+
+	err := a.RunContext(context.Background())
+	assert.EqualError(t, err, "job worker: crashed")
+	assert.Equal(t, []string{"start db", "stop db"}, events)
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/fulcrumproject/commons/auth"
+)
+
+// Authorizer decorates an auth.Authorizer recording every decision in a sink
+// Sink failures are logged and never change the decision
+type Authorizer struct {
+	inner auth.Authorizer
+	sink  Sink
+	opts  options
+}
+
+// NewAuthorizer creates an auditing authorizer
+func NewAuthorizer(inner auth.Authorizer, sink Sink, opts ...Option) *Authorizer {
+	return &Authorizer{inner: inner, sink: sink, opts: newOptions(opts)}
+}
+
+// Authorize delegates to the inner authorizer and audits the decision
+func (a *Authorizer) Authorize(identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	return a.AuthorizeCtx(context.Background(), identity, action, object, objectScope)
+}
+
+// AuthorizeCtx is like Authorize propagating the context to the inner authorizer and the sink
+// Denials are audited as denied, other errors such as an unreachable policy engine as failures
+func (a *Authorizer) AuthorizeCtx(ctx context.Context, identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	err := auth.AuthorizeCtx(ctx, a.inner, identity, action, object, objectScope)
+
+	event := NewEvent(identity, action, object, "").WithTime(a.opts.clock.Now())
+	var denied *auth.DeniedError
+	switch {
+	case errors.As(err, &denied):
+		event.WithOutcome(OutcomeDenied, err.Error())
+	case err != nil:
+		event.WithOutcome(OutcomeFailure, err.Error())
+	}
+	if werr := a.sink.Write(context.WithoutCancel(ctx), event); werr != nil {
+		slog.Error("cannot write authorization audit event", "error", werr)
+	}
+
+	return err
+}
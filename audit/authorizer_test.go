@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer(t *testing.T) {
+	identity := &auth.Identity{ID: properties.NewUUID(), Name: "bob", Role: auth.RoleParticipant}
+	rules := auth.NewRuleBasedAuthorizer([]auth.AuthorizationRule{
+		{Roles: []auth.Role{auth.RoleParticipant}, Action: "read", Object: "service"},
+	})
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	tests := []struct {
+		name            string
+		inner           auth.Authorizer
+		sinkErr         error
+		action          auth.Action
+		expectErr       bool
+		expectedOutcome Outcome
+	}{
+		{
+			name:            "Allowed decision",
+			action:          "read",
+			expectedOutcome: OutcomeSuccess,
+		},
+		{
+			name:            "Denied decision",
+			action:          "delete",
+			expectErr:       true,
+			expectedOutcome: OutcomeDenied,
+		},
+		{
+			name:            "Authorizer failure",
+			inner:           failingAuthorizer{err: errors.New("policy engine unreachable")},
+			action:          "read",
+			expectErr:       true,
+			expectedOutcome: OutcomeFailure,
+		},
+		{
+			name:            "Sink failure does not change decision",
+			sinkErr:         errors.New("sink down"),
+			action:          "read",
+			expectedOutcome: OutcomeSuccess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := tt.inner
+			if inner == nil {
+				inner = rules
+			}
+			sink := &recordingSink{err: tt.sinkErr}
+			a := NewAuthorizer(inner, sink, WithClock(clk))
+
+			err := a.Authorize(identity, tt.action, "service", nil)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			require.Len(t, sink.events, 1)
+			event := sink.events[0]
+			assert.Equal(t, tt.expectedOutcome, event.Outcome)
+			assert.Equal(t, tt.action, event.Action)
+			assert.Equal(t, auth.ObjectType("service"), event.ObjectType)
+			assert.Equal(t, "bob", event.ActorName)
+			assert.Equal(t, clk.Now(), event.OccurredAt)
+			if tt.expectErr {
+				assert.Equal(t, err.Error(), event.Reason)
+			}
+		})
+	}
+}
+
+type failingAuthorizer struct {
+	err error
+}
+
+func (a failingAuthorizer) Authorize(*auth.Identity, auth.Action, auth.ObjectType, auth.ObjectScope) error {
+	return a.err
+}
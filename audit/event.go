@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+)
+
+// Outcome represents the result of an audited operation
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+// Event is the canonical audit record, also used as GORM model
+type Event struct {
+	ID         properties.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	OccurredAt time.Time        `json:"occurredAt" gorm:"not null;index"`
+	ActorID    *properties.UUID `json:"actorId,omitempty" gorm:"type:uuid;index"`
+	ActorName  string           `json:"actorName,omitempty"`
+	ActorRole  auth.Role        `json:"actorRole,omitempty"`
+	TenantID   *properties.UUID `json:"tenantId,omitempty" gorm:"type:uuid;index"`
+	Action     auth.Action      `json:"action" gorm:"not null"`
+	ObjectType auth.ObjectType  `json:"objectType" gorm:"not null;index"`
+	ObjectID   string           `json:"objectId,omitempty" gorm:"index"`
+	Outcome    Outcome          `json:"outcome" gorm:"not null"`
+	Reason     string           `json:"reason,omitempty"`
+	Before     properties.JSON  `json:"before,omitempty"`
+	After      properties.JSON  `json:"after,omitempty"`
+}
+
+// TableName returns the audit table name
+func (Event) TableName() string {
+	return "audit_events"
+}
+
+// NewEvent creates a successful event performed by the actor occurring now
+// The tenant is the actor participant, or its organization for organization-wide identities
+// Anonymous actors are recorded without ID
+func NewEvent(actor *auth.Identity, action auth.Action, objectType auth.ObjectType, objectID string) *Event {
+	e := &Event{
+		ID:         properties.NewUUID(),
+		OccurredAt: time.Now(),
+		Action:     action,
+		ObjectType: objectType,
+		ObjectID:   objectID,
+		Outcome:    OutcomeSuccess,
+	}
+	if actor != nil {
+		if !actor.IsAnonymous() {
+			id := actor.ID
+			e.ActorID = &id
+		}
+		e.ActorName = actor.Name
+		e.ActorRole = actor.Role
+		e.TenantID = actor.Scope.ParticipantID
+		if e.TenantID == nil {
+			e.TenantID = actor.Scope.OrganizationID
+		}
+	}
+	return e
+}
+
+// WithTime sets when the event occurred
+func (e *Event) WithTime(t time.Time) *Event {
+	e.OccurredAt = t
+	return e
+}
+
+// WithOutcome sets the outcome and its reason
+func (e *Event) WithOutcome(outcome Outcome, reason string) *Event {
+	e.Outcome = outcome
+	e.Reason = reason
+	return e
+}
+
+// WithDiff sets the object state before and after the operation
+func (e *Event) WithDiff(before, after properties.JSON) *Event {
+	e.Before = before
+	e.After = after
+	return e
+}
+
+// Change describes a top-level field changed by the operation
+type Change struct {
+	Field  string `json:"field"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// Changes returns the top-level fields that differ between Before and After, sorted by name
+func (e *Event) Changes() []Change {
+	fields := map[string]struct{}{}
+	for k := range e.Before {
+		fields[k] = struct{}{}
+	}
+	for k := range e.After {
+		fields[k] = struct{}{}
+	}
+
+	var changes []Change
+	for field := range fields {
+		before, after := e.Before[field], e.After[field]
+		if !reflect.DeepEqual(before, after) {
+			changes = append(changes, Change{Field: field, Before: before, After: after})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
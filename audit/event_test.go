@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEvent(t *testing.T) {
+	participantID := properties.NewUUID()
+	actor := &auth.Identity{
+		ID:    properties.NewUUID(),
+		Name:  "alice",
+		Role:  auth.RoleParticipant,
+		Scope: auth.IdentityScope{ParticipantID: &participantID},
+	}
+
+	e := NewEvent(actor, "update", "service", "svc-1")
+
+	assert.NotEqual(t, properties.UUID{}, e.ID)
+	assert.False(t, e.OccurredAt.IsZero())
+	require.NotNil(t, e.ActorID)
+	assert.Equal(t, actor.ID, *e.ActorID)
+	assert.Equal(t, "alice", e.ActorName)
+	assert.Equal(t, auth.RoleParticipant, e.ActorRole)
+	assert.Equal(t, &participantID, e.TenantID)
+	assert.Equal(t, auth.Action("update"), e.Action)
+	assert.Equal(t, auth.ObjectType("service"), e.ObjectType)
+	assert.Equal(t, "svc-1", e.ObjectID)
+	assert.Equal(t, OutcomeSuccess, e.Outcome)
+
+	e.WithOutcome(OutcomeDenied, "no rule")
+	assert.Equal(t, OutcomeDenied, e.Outcome)
+	assert.Equal(t, "no rule", e.Reason)
+}
+
+func TestNewEvent_NoActor(t *testing.T) {
+	e := NewEvent(nil, "purge", "job", "")
+	assert.Nil(t, e.ActorID)
+	assert.Nil(t, e.TenantID)
+}
+
+func TestNewEvent_OrganizationTenant(t *testing.T) {
+	organizationID := properties.NewUUID()
+	actor := &auth.Identity{
+		ID:    properties.NewUUID(),
+		Name:  "dave",
+		Role:  auth.RoleAdmin,
+		Scope: auth.IdentityScope{OrganizationID: &organizationID},
+	}
+
+	e := NewEvent(actor, "update", "service", "svc-1")
+	assert.Equal(t, &organizationID, e.TenantID)
+}
+
+func TestNewEvent_Anonymous(t *testing.T) {
+	e := NewEvent(auth.Anonymous(), "read", "service", "")
+	assert.Nil(t, e.ActorID)
+	assert.Equal(t, "anonymous", e.ActorName)
+	assert.Equal(t, auth.RoleAnonymous, e.ActorRole)
+}
+
+func TestEvent_Changes(t *testing.T) {
+	e := NewEvent(nil, "update", "service", "svc-1").WithDiff(
+		properties.JSON{"name": "a", "size": 1.0, "removed": true},
+		properties.JSON{"name": "b", "size": 1.0, "added": "x"},
+	)
+
+	assert.Equal(t, []Change{
+		{Field: "added", After: "x"},
+		{Field: "name", Before: "a", After: "b"},
+		{Field: "removed", Before: true},
+	}, e.Changes())
+
+	assert.Empty(t, NewEvent(nil, "read", "service", "").Changes())
+}
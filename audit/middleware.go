@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ObjectIDExtractor returns the ID of the object targeted by the request, if any
+type ObjectIDExtractor func(r *http.Request) string
+
+// Middleware records an event for every request once the handler completed
+// It should be placed after the Auth middleware, requests without identity are audited as anonymous
+// The outcome is derived from the response status
+func Middleware(sink Sink, action auth.Action, object auth.ObjectType, extractor ObjectIDExtractor, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			objectID := ""
+			if extractor != nil {
+				objectID = extractor(r)
+			}
+			identity, ok := auth.GetIdentity(r.Context())
+			if !ok {
+				identity = auth.Anonymous()
+			}
+			event := NewEvent(identity, action, object, objectID).WithTime(o.clock.Now())
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			switch {
+			case status == http.StatusUnauthorized || status == http.StatusForbidden:
+				event.WithOutcome(OutcomeDenied, http.StatusText(status))
+			case status >= http.StatusBadRequest:
+				event.WithOutcome(OutcomeFailure, http.StatusText(status))
+			}
+
+			if err := sink.Write(r.Context(), event); err != nil {
+				slog.ErrorContext(r.Context(), "cannot write audit event", "error", err)
+			}
+		})
+	}
+}
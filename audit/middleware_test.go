@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	identity := &auth.Identity{ID: properties.NewUUID(), Name: "carol", Role: auth.RoleAdmin}
+
+	tests := []struct {
+		name            string
+		status          int
+		expectedOutcome Outcome
+		expectedReason  string
+	}{
+		{
+			name:            "Implicit OK",
+			status:          0,
+			expectedOutcome: OutcomeSuccess,
+		},
+		{
+			name:            "Created",
+			status:          http.StatusCreated,
+			expectedOutcome: OutcomeSuccess,
+		},
+		{
+			name:            "Forbidden",
+			status:          http.StatusForbidden,
+			expectedOutcome: OutcomeDenied,
+			expectedReason:  "Forbidden",
+		},
+		{
+			name:            "Server error",
+			status:          http.StatusInternalServerError,
+			expectedOutcome: OutcomeFailure,
+			expectedReason:  "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte("body"))
+			})
+			extractor := func(r *http.Request) string { return r.URL.Query().Get("id") }
+			mw := Middleware(sink, "update", "service", extractor)(handler)
+
+			req := httptest.NewRequest(http.MethodPut, "/services?id=svc-1", nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+			w := httptest.NewRecorder()
+			mw.ServeHTTP(w, req)
+
+			assert.Equal(t, "body", w.Body.String())
+			require.Len(t, sink.events, 1)
+			event := sink.events[0]
+			assert.Equal(t, tt.expectedOutcome, event.Outcome)
+			assert.Equal(t, tt.expectedReason, event.Reason)
+			assert.Equal(t, "svc-1", event.ObjectID)
+			assert.Equal(t, auth.Action("update"), event.Action)
+			assert.Equal(t, "carol", event.ActorName)
+		})
+	}
+}
+
+func TestMiddleware_Anonymous(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	sink := &recordingSink{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mw := Middleware(sink, "read", "service", nil, WithClock(clk))(handler)
+
+	w := httptest.NewRecorder()
+	mw.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/services", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	assert.Equal(t, OutcomeDenied, event.Outcome)
+	assert.Nil(t, event.ActorID)
+	assert.Equal(t, "anonymous", event.ActorName)
+	assert.Equal(t, auth.RoleAnonymous, event.ActorRole)
+	assert.Equal(t, clk.Now(), event.OccurredAt)
+}
@@ -0,0 +1,25 @@
+package audit
+
+import "github.com/fulcrumproject/commons/clock"
+
+// Option configures the auditing authorizer and middleware
+type Option func(*options)
+
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock sets the clock timestamping the events, defaults to the real clock
+func WithClock(clk clock.Clock) Option {
+	return func(o *options) {
+		o.clock = clk
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: clock.New()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
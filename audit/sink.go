@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fulcrumproject/commons/events"
+	"github.com/fulcrumproject/commons/properties"
+	"gorm.io/gorm"
+)
+
+// Sink stores or forwards audit events
+type Sink interface {
+	Write(ctx context.Context, event *Event) error
+}
+
+// GormSink writes events in the audit table
+type GormSink struct {
+	db *gorm.DB
+}
+
+// NewGormSink creates a sink writing with db, pass a transaction to audit atomically with the change
+func NewGormSink(db *gorm.DB) *GormSink {
+	return &GormSink{db: db}
+}
+
+// Write inserts the event
+func (s *GormSink) Write(ctx context.Context, event *Event) error {
+	return s.db.WithContext(ctx).Create(event).Error
+}
+
+// BrokerSink publishes events on a bus topic
+type BrokerSink struct {
+	broker events.Broker
+	topic  string
+}
+
+// NewBrokerSink creates a sink publishing on topic
+func NewBrokerSink(broker events.Broker, topic string) *BrokerSink {
+	return &BrokerSink{broker: broker, topic: topic}
+}
+
+// Write publishes the event keyed by object ID
+func (s *BrokerSink) Write(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("cannot encode audit event: %w", err)
+	}
+	var payload properties.JSON
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("cannot encode audit event: %w", err)
+	}
+	return s.broker.Publish(ctx, events.Message{
+		ID:         event.ID,
+		Topic:      s.topic,
+		Key:        event.ObjectID,
+		Payload:    payload,
+		OccurredAt: event.OccurredAt,
+	})
+}
+
+// WriterSink writes events as JSON lines
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates a sink writing to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewFileSink creates a sink appending to the file at path
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open audit file: %w", err)
+	}
+	return NewWriterSink(f), nil
+}
+
+// Write appends the event as a JSON line
+func (s *WriterSink) Write(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("cannot encode audit event: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the underlying writer if it is a Closer
+func (s *WriterSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// MultiSink writes to every sink, returning the joined errors
+type MultiSink []Sink
+
+// Write writes the event to all sinks
+func (m MultiSink) Write(ctx context.Context, event *Event) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Write(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
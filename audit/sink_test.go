@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/events"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []*Event
+	err    error
+}
+
+func (s *recordingSink) Write(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func TestGormSink(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Event{}))
+
+	event := NewEvent(nil, "delete", "agent", "a-1").WithDiff(properties.JSON{"name": "old"}, nil)
+	require.NoError(t, NewGormSink(db).Write(context.Background(), event))
+
+	var stored Event
+	require.NoError(t, db.First(&stored, "id = ?", event.ID).Error)
+	assert.Equal(t, auth.Action("delete"), stored.Action)
+	assert.Equal(t, "a-1", stored.ObjectID)
+	assert.Equal(t, "old", stored.Before["name"])
+}
+
+func TestBrokerSink(t *testing.T) {
+	var published []events.Message
+	broker := events.BrokerFunc(func(ctx context.Context, msg events.Message) error {
+		published = append(published, msg)
+		return nil
+	})
+
+	event := NewEvent(nil, "create", "service", "svc-1")
+	require.NoError(t, NewBrokerSink(broker, "audit").Write(context.Background(), event))
+
+	require.Len(t, published, 1)
+	assert.Equal(t, event.ID, published[0].ID)
+	assert.Equal(t, "audit", published[0].Topic)
+	assert.Equal(t, "svc-1", published[0].Key)
+	assert.Equal(t, "create", published[0].Payload["action"])
+	assert.Equal(t, "success", published[0].Payload["outcome"])
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	require.NoError(t, sink.Write(context.Background(), NewEvent(nil, "create", "service", "1")))
+	require.NoError(t, sink.Write(context.Background(), NewEvent(nil, "delete", "service", "1")))
+	require.NoError(t, sink.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var decoded Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+	assert.Equal(t, auth.Action("delete"), decoded.Action)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), NewEvent(nil, "create", "service", "1")))
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"action":"create"`)
+
+	_, err = NewFileSink(filepath.Join(t.TempDir(), "missing", "audit.log"))
+	assert.Error(t, err)
+}
+
+func TestMultiSink(t *testing.T) {
+	ok := &recordingSink{}
+	failing := &recordingSink{err: errors.New("disk full")}
+
+	err := MultiSink{failing, ok}.Write(context.Background(), NewEvent(nil, "create", "service", "1"))
+
+	assert.EqualError(t, err, "disk full")
+	assert.Len(t, ok.events, 1)
+	assert.Len(t, failing.events, 1)
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// AttributeScope is an ObjectScope carrying attributes of the target object (e.g. owner, state, labels)
+type AttributeScope interface {
+	ObjectScope
+	Attributes() map[string]any
+}
+
+// AttributeObjectScope decorates an ObjectScope with object attributes
+type AttributeObjectScope struct {
+	Scope ObjectScope    `json:"scope,omitempty"`
+	Attrs map[string]any `json:"attributes"`
+}
+
+// WithAttributes returns a scope matching like scope and exposing attrs, a nil scope always matches
+func WithAttributes(scope ObjectScope, attrs map[string]any) *AttributeObjectScope {
+	return &AttributeObjectScope{Scope: scope, Attrs: attrs}
+}
+
+// Matches delegates to the wrapped scope
+func (s *AttributeObjectScope) Matches(identity *Identity) bool {
+	if s.Scope == nil {
+		return true
+	}
+	return s.Scope.Matches(identity)
+}
+
+// CacheKey fingerprints the wrapped scope and the JSON encoding of the attributes,
+// attribute values must encode all their state to be cached
+func (s *AttributeObjectScope) CacheKey() (string, bool) {
+	scopeKey, ok := scopeCacheKey(s.Scope)
+	if !ok {
+		return "", false
+	}
+	attrs, err := json.Marshal(s.Attrs)
+	if err != nil {
+		return "", false
+	}
+	return "attributes(" + scopeKey + "," + string(attrs) + ")", true
+}
+
+// Attributes returns the object attributes
+func (s *AttributeObjectScope) Attributes() map[string]any {
+	return s.Attrs
+}
+
+// ScopeAttributes returns the attributes of the scope, or nil if it doesn't carry any
+func ScopeAttributes(scope ObjectScope) map[string]any {
+	if s, ok := scope.(AttributeScope); ok {
+		return s.Attributes()
+	}
+	return nil
+}
+
+// Condition is an additional requirement of an authorization rule on the identity and the object attributes
+type Condition func(identity *Identity, attrs map[string]any) bool
+
+// AttributeIn requires the attribute to be one of values, values are compared by their string representation
+func AttributeIn(key string, values ...any) Condition {
+	want := make([]string, len(values))
+	for i, v := range values {
+		want[i] = fmt.Sprint(v)
+	}
+	return func(identity *Identity, attrs map[string]any) bool {
+		v, ok := attrs[key]
+		return ok && slices.Contains(want, fmt.Sprint(v))
+	}
+}
+
+// AttributeEquals requires the attribute to be equal to value
+func AttributeEquals(key string, value any) Condition {
+	return AttributeIn(key, value)
+}
+
+// AllConditions requires all the conditions to hold
+func AllConditions(conditions ...Condition) Condition {
+	return func(identity *Identity, attrs map[string]any) bool {
+		for _, c := range conditions {
+			if !c(identity, attrs) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// IdentityKindIn requires the identity to be of one of the kinds, identities without kind are users
+func IdentityKindIn(kinds ...IdentityKind) Condition {
+	return func(identity *Identity, attrs map[string]any) bool {
+		return identity != nil && slices.Contains(kinds, identity.EffectiveKind())
+	}
+}
+
+// IdentityAttributeIn requires the identity attribute to be one of values, values are compared by their string representation
+func IdentityAttributeIn(key string, values ...any) Condition {
+	want := make([]string, len(values))
+	for i, v := range values {
+		want[i] = fmt.Sprint(v)
+	}
+	return func(identity *Identity, attrs map[string]any) bool {
+		if identity == nil {
+			return false
+		}
+		v, ok := identity.Attribute(key)
+		return ok && slices.Contains(want, fmt.Sprint(v))
+	}
+}
+
+// IdentityInGroup requires the identity to be a member of any of the groups
+func IdentityInGroup(groups ...string) Condition {
+	return func(identity *Identity, attrs map[string]any) bool {
+		return identity != nil && slices.ContainsFunc(groups, identity.InGroup)
+	}
+}
+
+// AttributeMatchesIdentity requires the object attribute to be equal to the identity attribute, e.g. the same region
+func AttributeMatchesIdentity(objectKey, identityKey string) Condition {
+	return func(identity *Identity, attrs map[string]any) bool {
+		if identity == nil {
+			return false
+		}
+		want, ok := identity.Attribute(identityKey)
+		if !ok {
+			return false
+		}
+		v, ok := attrs[objectKey]
+		return ok && fmt.Sprint(v) == fmt.Sprint(want)
+	}
+}
+
+// registeredClaims are the JWT registered claim names never treated as identity attributes
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// ExtraClaims returns the claims that are neither JWT registered claims nor in mapped, nil when there are none
+func ExtraClaims(claims map[string]any, mapped ...string) map[string]any {
+	var extra map[string]any
+	for k, v := range claims {
+		if slices.Contains(registeredClaims, k) || slices.Contains(mapped, k) {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]any)
+		}
+		extra[k] = v
+	}
+	return extra
+}
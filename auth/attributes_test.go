@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeObjectScope(t *testing.T) {
+	participantID := properties.NewUUID()
+	identity := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	attrs := map[string]any{"state": "draft"}
+
+	scope := WithAttributes(&DefaultObjectScope{ParticipantID: &participantID}, attrs)
+	assert.True(t, scope.Matches(identity))
+	assert.Equal(t, attrs, ScopeAttributes(scope))
+
+	other := properties.NewUUID()
+	assert.False(t, WithAttributes(&DefaultObjectScope{ParticipantID: &other}, attrs).Matches(identity))
+	assert.True(t, WithAttributes(nil, attrs).Matches(identity))
+
+	assert.Nil(t, ScopeAttributes(&DefaultObjectScope{}))
+	assert.Nil(t, ScopeAttributes(nil))
+}
+
+func TestConditions(t *testing.T) {
+	attrs := map[string]any{"state": "draft", "version": 2}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  bool
+	}{
+		{name: "Equals", condition: AttributeEquals("state", "draft"), expected: true},
+		{name: "Not equals", condition: AttributeEquals("state", "active"), expected: false},
+		{name: "Missing attribute", condition: AttributeEquals("owner", "alice"), expected: false},
+		{name: "In", condition: AttributeIn("state", "new", "draft"), expected: true},
+		{name: "Numbers by representation", condition: AttributeEquals("version", 2.0), expected: true},
+		{name: "All", condition: AllConditions(AttributeEquals("state", "draft"), AttributeIn("version", 1, 2)), expected: true},
+		{name: "All failing", condition: AllConditions(AttributeEquals("state", "draft"), AttributeIn("version", 3)), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.condition(&Identity{}, attrs))
+		})
+	}
+}
+
+func TestIdentityKindIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *Identity
+		kinds    []IdentityKind
+		expected bool
+	}{
+		{name: "Service account", identity: &Identity{Kind: KindServiceAccount}, kinds: []IdentityKind{KindServiceAccount}, expected: true},
+		{name: "User", identity: &Identity{Kind: KindUser}, kinds: []IdentityKind{KindServiceAccount}, expected: false},
+		{name: "Empty kind is user", identity: &Identity{}, kinds: []IdentityKind{KindUser}, expected: true},
+		{name: "Nil identity", kinds: []IdentityKind{KindUser}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IdentityKindIn(tt.kinds...)(tt.identity, nil))
+		})
+	}
+}
+
+func TestRuleBasedAuthorizer_Authorize_Condition(t *testing.T) {
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{
+		{Roles: []Role{RoleParticipant}, Action: "update", Object: "service", Condition: AttributeEquals("state", "draft")},
+	})
+	identity := &Identity{Role: RoleParticipant}
+
+	assert.NoError(t, authorizer.Authorize(identity, "update", "service", WithAttributes(nil, map[string]any{"state": "draft"})))
+	assert.Error(t, authorizer.Authorize(identity, "update", "service", WithAttributes(nil, map[string]any{"state": "active"})))
+	assert.Error(t, authorizer.Authorize(identity, "update", "service", nil))
+}
+
+func TestIdentityAttributeConditions(t *testing.T) {
+	identity := &Identity{Role: RoleParticipant, Attributes: map[string]any{"region": "eu-west", "costCenter": 42}, Groups: []string{"/operators"}}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		identity  *Identity
+		attrs     map[string]any
+		expected  bool
+	}{
+		{name: "Identity attribute in", condition: IdentityAttributeIn("region", "eu-west", "eu-central"), identity: identity, expected: true},
+		{name: "Identity attribute by representation", condition: IdentityAttributeIn("costCenter", "42"), identity: identity, expected: true},
+		{name: "Identity attribute not in", condition: IdentityAttributeIn("region", "us-east"), identity: identity, expected: false},
+		{name: "Missing identity attribute", condition: IdentityAttributeIn("tier", "gold"), identity: identity, expected: false},
+		{name: "Nil identity", condition: IdentityAttributeIn("region", "eu-west"), expected: false},
+		{name: "Object matches identity", condition: AttributeMatchesIdentity("region", "region"), identity: identity, attrs: map[string]any{"region": "eu-west"}, expected: true},
+		{name: "Object differs from identity", condition: AttributeMatchesIdentity("region", "region"), identity: identity, attrs: map[string]any{"region": "us-east"}, expected: false},
+		{name: "Object attribute missing", condition: AttributeMatchesIdentity("region", "region"), identity: identity, expected: false},
+		{name: "Identity attribute missing", condition: AttributeMatchesIdentity("region", "tier"), identity: identity, attrs: map[string]any{"region": "eu-west"}, expected: false},
+		{name: "Identity in group", condition: IdentityInGroup("/admins", "/operators"), identity: identity, expected: true},
+		{name: "Identity not in group", condition: IdentityInGroup("/admins"), identity: identity, expected: false},
+		{name: "Nil identity not in group", condition: IdentityInGroup("/operators"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.condition(tt.identity, tt.attrs))
+		})
+	}
+}
+
+func TestExtraClaims(t *testing.T) {
+	claims := map[string]any{
+		"iss":         "https://keycloak/realms/fulcrum",
+		"sub":         "123",
+		"exp":         1700000000,
+		"role":        "admin",
+		"region":      "eu-west",
+		"cost_center": "cc-42",
+	}
+
+	assert.Equal(t, map[string]any{"region": "eu-west", "cost_center": "cc-42"}, ExtraClaims(claims, "role"))
+	assert.Nil(t, ExtraClaims(map[string]any{"sub": "123", "role": "admin"}, "role"))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/fulcrumproject/commons/properties"
 )
@@ -15,18 +17,34 @@ const (
 	RoleAdmin       Role = "admin"
 	RoleParticipant Role = "participant"
 	RoleAgent       Role = "agent"
+	// RoleAnonymous is the pseudo role of unauthenticated callers, it is granted permissions in rules and policies
+	// but not listed by Roles and rejected by Validate, so it can't be assigned from configs or token claims
+	RoleAnonymous Role = "anonymous"
 )
 
-// Validate ensures the Role is one of the predefined values
+// Validate ensures the Role is one of the predefined or registered values an identity can be assigned
 func (r Role) Validate() error {
 	switch r {
 	case RoleAdmin, RoleParticipant, RoleAgent:
 		return nil
+	case RoleAnonymous:
+		return fmt.Errorf("auth role %s is reserved to unauthenticated callers", r)
 	default:
+		if isRegisteredRole(r) {
+			return nil
+		}
 		return fmt.Errorf("invalid auth role: %s", r)
 	}
 }
 
+// validateGrantee is like Validate also accepting RoleAnonymous, for the roles granted permissions
+func (r Role) validateGrantee() error {
+	if r == RoleAnonymous {
+		return nil
+	}
+	return r.Validate()
+}
+
 // Action represents an action that can be performed on an object
 type Action string
 
@@ -45,12 +63,33 @@ func (a AllwaysMatchObjectScope) Matches(identity *Identity) bool {
 	return true // Always matches, used for global actions
 }
 
+// CacheKey fingerprints the scope, it's the same for every instance
+func (a AllwaysMatchObjectScope) CacheKey() (string, bool) {
+	return "always", true
+}
+
 // DefaultObjectScope is the default implementation of ObjectScope
 type DefaultObjectScope struct {
-	ParticipantID *properties.UUID
-	ProviderID    *properties.UUID
-	ConsumerID    *properties.UUID
-	AgentID       *properties.UUID
+	ParticipantID  *properties.UUID
+	ProviderID     *properties.UUID
+	ConsumerID     *properties.UUID
+	AgentID        *properties.UUID
+	OrganizationID *properties.UUID
+}
+
+// CacheKey fingerprints all the scope ids, nil ids included
+func (target *DefaultObjectScope) CacheKey() (string, bool) {
+	if target == nil {
+		return "", false
+	}
+	key := "default"
+	for _, id := range []*properties.UUID{target.ParticipantID, target.ProviderID, target.ConsumerID, target.AgentID, target.OrganizationID} {
+		key += ":"
+		if id != nil {
+			key += id.String()
+		}
+	}
+	return key, true
 }
 
 // Matches checks if the given identity matches the object scope
@@ -59,13 +98,25 @@ func (target *DefaultObjectScope) Matches(id *Identity) bool {
 		return false
 	}
 
+	// Organization isolation: objects of an organization are never visible to identities of another one
+	if target.OrganizationID != nil && id.Scope.OrganizationID != nil && *target.OrganizationID != *id.Scope.OrganizationID {
+		return false
+	}
+
+	// If all fields are nil in the target scope, global access is allowed
+	global := target.ParticipantID == nil && target.ProviderID == nil && target.ConsumerID == nil && target.AgentID == nil
+
+	// Anonymous callers only access global objects
+	if id.IsAnonymous() {
+		return global && target.OrganizationID == nil
+	}
+
 	// If all fields are nil in the caller scope, it has unrestricted access (admin)
 	if id.Scope.ParticipantID == nil && id.Scope.AgentID == nil {
 		return true
 	}
 
-	// If all fields are nil in the target scope, global access is allowed
-	if target.ParticipantID == nil && target.ProviderID == nil && target.ConsumerID == nil && target.AgentID == nil {
+	if global {
 		return true
 	}
 
@@ -91,16 +142,89 @@ func (target *DefaultObjectScope) Matches(id *Identity) bool {
 
 }
 
+// IdentityKind distinguishes human users from automation
+type IdentityKind string
+
+const (
+	KindUser           IdentityKind = "user"
+	KindServiceAccount IdentityKind = "service_account"
+	KindAnonymous      IdentityKind = "anonymous"
+)
+
+// Validate ensures the IdentityKind is one of the predefined values, empty is accepted as user
+func (k IdentityKind) Validate() error {
+	switch k {
+	case "", KindUser, KindServiceAccount, KindAnonymous:
+		return nil
+	default:
+		return fmt.Errorf("invalid identity kind: %s", k)
+	}
+}
+
 // Identity implements the Identifier interface
 type Identity struct {
 	ID    properties.UUID
 	Name  string
 	Role  Role
+	Kind  IdentityKind
 	Scope IdentityScope
+	// OAuthScopes are the scopes granted to the token, not to be confused with the participant and agent Scope
+	OAuthScopes []string
+	// Attributes are the extra claims of the token (e.g. cost center, region)
+	Attributes map[string]any
+	// Groups are the identity provider groups of the identity, e.g. Keycloak group paths
+	Groups []string
+}
+
+// Attribute returns an extra claim of the identity
+func (m *Identity) Attribute(key string) (any, bool) {
+	v, ok := m.Attributes[key]
+	return v, ok
+}
+
+// Clone returns a deep copy of the identity
+func (m *Identity) Clone() *Identity {
+	if m == nil {
+		return nil
+	}
+	cp := *m
+	cp.OAuthScopes = slices.Clone(m.OAuthScopes)
+	cp.Attributes = maps.Clone(m.Attributes)
+	cp.Groups = slices.Clone(m.Groups)
+	return &cp
+}
+
+// InGroup reports whether the identity is a member of the group
+func (m *Identity) InGroup(group string) bool {
+	return slices.Contains(m.Groups, group)
+}
+
+// EffectiveKind returns the identity kind defaulting to user
+func (m *Identity) EffectiveKind() IdentityKind {
+	if m.Kind == "" {
+		return KindUser
+	}
+	return m.Kind
+}
+
+// IsServiceAccount reports whether the identity is an automation account
+func (m *Identity) IsServiceAccount() bool {
+	return m.Kind == KindServiceAccount
 }
 
+// Anonymous returns the identity of unauthenticated callers, it only matches global object scopes
+func Anonymous() *Identity {
+	return &Identity{Name: "anonymous", Role: RoleAnonymous, Kind: KindAnonymous}
+}
+
+// IsAnonymous reports whether the identity is an unauthenticated caller
+func (m *Identity) IsAnonymous() bool {
+	return m.Kind == KindAnonymous || m.Role == RoleAnonymous
+}
+
+// HasRole checks if the identity has the role directly or through the configured role hierarchy
 func (m *Identity) HasRole(role Role) bool {
-	return m.Role == role
+	return currentRoleHierarchy().Implies(m.Role, role)
 }
 
 // validateRoleRequirements ensures that role-specific ID requirements are met
@@ -119,12 +243,21 @@ func (m *Identity) Validate() error {
 		}
 	}
 
+	if err := m.Kind.Validate(); err != nil {
+		return err
+	}
+
+	if m.Scope.OrganizationID == nil && isOrganizationRequired(m.Role) {
+		return fmt.Errorf("%s role requires organization id", m.Role)
+	}
+
 	return nil
 }
 
 type IdentityScope struct {
-	ParticipantID *properties.UUID
-	AgentID       *properties.UUID
+	ParticipantID  *properties.UUID
+	AgentID        *properties.UUID
+	OrganizationID *properties.UUID
 }
 
 type Authenticator interface {
@@ -134,3 +267,19 @@ type Authenticator interface {
 type Authorizer interface {
 	Authorize(identity *Identity, action Action, oject ObjectType, objectScope ObjectScope) error
 }
+
+// ContextAuthorizer is implemented by authorizers doing I/O that honor the request context cancellation and deadline
+type ContextAuthorizer interface {
+	AuthorizeCtx(ctx context.Context, identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error
+}
+
+// AuthorizeCtx authorizes with the context when the authorizer supports it, falling back to Authorize otherwise
+func AuthorizeCtx(ctx context.Context, authorizer Authorizer, identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ca, ok := authorizer.(ContextAuthorizer); ok {
+		return ca.AuthorizeCtx(ctx, identity, action, object, objectScope)
+	}
+	return authorizer.Authorize(identity, action, object, objectScope)
+}
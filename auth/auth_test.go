@@ -29,6 +29,11 @@ func TestRole_Validate(t *testing.T) {
 			role:        RoleAgent,
 			expectError: false,
 		},
+		{
+			name:        "Anonymous role is reserved",
+			role:        RoleAnonymous,
+			expectError: true,
+		},
 		{
 			name:        "Invalid role",
 			role:        Role("invalid"),
@@ -168,6 +173,16 @@ func TestIdentity_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "agent role requires agent id",
 		},
+		{
+			name:     "Valid service account",
+			identity: &Identity{Role: RoleAdmin, Kind: KindServiceAccount},
+		},
+		{
+			name:        "Invalid kind",
+			identity:    &Identity{Role: RoleAdmin, Kind: "robot"},
+			expectError: true,
+			errorMsg:    "invalid identity kind: robot",
+		},
 	}
 
 	for _, tt := range tests {
@@ -183,6 +198,53 @@ func TestIdentity_Validate(t *testing.T) {
 	}
 }
 
+func TestIdentity_Kind(t *testing.T) {
+	legacy := &Identity{Role: RoleAdmin}
+	assert.Equal(t, KindUser, legacy.EffectiveKind())
+	assert.False(t, legacy.IsServiceAccount())
+
+	automation := &Identity{Role: RoleAdmin, Kind: KindServiceAccount}
+	assert.Equal(t, KindServiceAccount, automation.EffectiveKind())
+	assert.True(t, automation.IsServiceAccount())
+}
+
+func TestIdentity_Clone(t *testing.T) {
+	identity := &Identity{Role: RoleAdmin, OAuthScopes: []string{"openid"}, Attributes: map[string]any{"region": "eu-west"}, Groups: []string{"/operators"}}
+
+	clone := identity.Clone()
+	clone.OAuthScopes[0] = "changed"
+	clone.Attributes["region"] = "us-east"
+	clone.Groups[0] = "/changed"
+
+	assert.Equal(t, []string{"openid"}, identity.OAuthScopes)
+	assert.True(t, identity.InGroup("/operators"))
+	assert.False(t, identity.InGroup("/changed"))
+	v, ok := identity.Attribute("region")
+	assert.True(t, ok)
+	assert.Equal(t, "eu-west", v)
+	_, ok = identity.Attribute("missing")
+	assert.False(t, ok)
+	assert.Nil(t, (*Identity)(nil).Clone())
+}
+
+func TestAnonymous(t *testing.T) {
+	anonymous := Anonymous()
+
+	assert.True(t, anonymous.IsAnonymous())
+	assert.Equal(t, RoleAnonymous, anonymous.Role)
+	assert.NoError(t, anonymous.Validate())
+	assert.Error(t, anonymous.Role.Validate(), "anonymous can't be assigned from external input")
+	assert.NotContains(t, Roles(), RoleAnonymous)
+	assert.False(t, (&Identity{Role: RoleAdmin}).IsAnonymous())
+	assert.False(t, anonymous.HasRole(RoleAgent))
+
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{
+		{Roles: []Role{RoleAnonymous, RoleAdmin}, Action: "read", Object: "catalog"},
+	})
+	assert.NoError(t, authorizer.Authorize(anonymous, "read", "catalog", AllwaysMatchObjectScope{}))
+	assert.Error(t, authorizer.Authorize(anonymous, "update", "catalog", AllwaysMatchObjectScope{}))
+}
+
 func TestAllwaysMatchObjectScope_Matches(t *testing.T) {
 	scope := AllwaysMatchObjectScope{}
 	result := scope.Matches(&Identity{})
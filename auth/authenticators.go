@@ -5,6 +5,9 @@ import (
 	"errors"
 )
 
+// ErrAuthenticationFailed is returned by composite and chained authenticators when no authenticator yields an identity
+var ErrAuthenticationFailed = errors.New("authentication failed: no valid identity found")
+
 // CompositeAuthenticator implements Authenticator by trying multiple authenticators in order
 type CompositeAuthenticator struct {
 	authenticators []Authenticator
@@ -32,5 +35,36 @@ func (c *CompositeAuthenticator) Authenticate(ctx context.Context, token string)
 	}
 
 	// All authenticators failed
-	return nil, errors.New("authentication failed: no valid identity found")
+	return nil, ErrAuthenticationFailed
+}
+
+// ChainAuthenticator implements Authenticator by falling back to the next authenticator on any failure
+type ChainAuthenticator struct {
+	authenticators []Authenticator
+}
+
+// Chain creates an authenticator trying the authenticators in order, e.g. OIDC first and then API tokens,
+// and returning the first identity found
+// Unlike CompositeAuthenticator an error does not stop the chain, when every authenticator fails the returned
+// error wraps ErrAuthenticationFailed and the individual errors
+func Chain(authenticators ...Authenticator) *ChainAuthenticator {
+	return &ChainAuthenticator{authenticators: authenticators}
+}
+
+// Authenticate tries each authenticator in order until one returns an identity, a done context stops the chain
+func (c *ChainAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	errs := []error{ErrAuthenticationFailed}
+	for _, authenticator := range c.authenticators {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		identity, err := authenticator.Authenticate(ctx, token)
+		if err == nil && identity != nil {
+			return identity, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return nil, errors.Join(errs...)
 }
@@ -139,3 +139,94 @@ func (m *mockAuthenticator) Authenticate(ctx context.Context, token string) (*Id
 	m.receivedToken = token
 	return m.identity, m.err
 }
+
+func TestChain_Authenticate(t *testing.T) {
+	identity := &Identity{Name: "api-client", Role: RoleAdmin}
+	oidcErr := errors.New("oidc: malformed jwt")
+	keyErr := errors.New("api key expired")
+
+	tests := []struct {
+		name             string
+		authenticators   []*mockAuthenticator
+		expectedIdentity *Identity
+		expectedErrs     []error
+		expectedCalled   []bool
+	}{
+		{
+			name: "First succeeds",
+			authenticators: []*mockAuthenticator{
+				{identity: identity},
+				{err: keyErr},
+			},
+			expectedIdentity: identity,
+			expectedCalled:   []bool{true, false},
+		},
+		{
+			name: "Falls back after error",
+			authenticators: []*mockAuthenticator{
+				{err: oidcErr},
+				{identity: identity},
+			},
+			expectedIdentity: identity,
+			expectedCalled:   []bool{true, true},
+		},
+		{
+			name: "Falls back after nil identity",
+			authenticators: []*mockAuthenticator{
+				{},
+				{identity: identity},
+			},
+			expectedIdentity: identity,
+			expectedCalled:   []bool{true, true},
+		},
+		{
+			name: "All fail",
+			authenticators: []*mockAuthenticator{
+				{err: oidcErr},
+				{},
+				{err: keyErr},
+			},
+			expectedErrs:   []error{ErrAuthenticationFailed, oidcErr, keyErr},
+			expectedCalled: []bool{true, true, true},
+		},
+		{
+			name:         "Empty chain",
+			expectedErrs: []error{ErrAuthenticationFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auths := make([]Authenticator, len(tt.authenticators))
+			for i, a := range tt.authenticators {
+				auths[i] = a
+			}
+
+			got, err := Chain(auths...).Authenticate(context.Background(), "test-token")
+
+			if tt.expectedErrs != nil {
+				require.Error(t, err)
+				for _, expected := range tt.expectedErrs {
+					assert.ErrorIs(t, err, expected)
+				}
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedIdentity, got)
+			}
+			for i, expected := range tt.expectedCalled {
+				assert.Equal(t, expected, tt.authenticators[i].called, "authenticator %d", i)
+			}
+		})
+	}
+}
+
+func TestChain_Authenticate_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := &mockAuthenticator{identity: &Identity{Role: RoleAdmin}}
+
+	_, err := Chain(a).Authenticate(ctx, "test-token")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, a.called)
+}
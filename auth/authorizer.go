@@ -1,7 +1,10 @@
 package auth
 
-import (
-	"fmt"
+const (
+	// AnyAction in a rule matches every action
+	AnyAction Action = "*"
+	// AnyObject in a rule matches every object type
+	AnyObject ObjectType = "*"
 )
 
 // AuthorizationRule represents a single authorization rule with roles, action, and object
@@ -9,6 +12,15 @@ type AuthorizationRule struct {
 	Roles  []Role
 	Action Action
 	Object ObjectType
+	// RequireScope makes the rule apply only when an object scope is provided
+	RequireScope bool
+	// Condition, if set, must hold for the rule to apply
+	Condition Condition
+}
+
+// matches checks the rule action and object type, wildcards included
+func (r AuthorizationRule) matches(action Action, object ObjectType) bool {
+	return (r.Action == AnyAction || r.Action == action) && (r.Object == AnyObject || r.Object == object)
 }
 
 // RuleBasedAuthorizer implements the Authorizer interface using a set of predefined rules
@@ -28,12 +40,17 @@ func NewRuleBasedAuthorizer(rules []AuthorizationRule) *RuleBasedAuthorizer {
 func (a *RuleBasedAuthorizer) Authorize(identity *Identity, action Action, object ObjectType, objectContext ObjectScope) error {
 	// Check if the object context matches the identity (for context-specific authorization)
 	if objectContext != nil && !objectContext.Matches(identity) {
-		return fmt.Errorf("access denied: object context does not match identity")
+		return NewDeniedError(ReasonScopeMismatch, action, object, "object context does not match identity")
 	}
 
 	// Check if any of the identity's roles match the authorization rules
+	var missingRole Role
 	for _, rule := range a.rules {
-		if rule.Action == action && rule.Object == object {
+		if rule.matches(action, object) && (!rule.RequireScope || objectContext != nil) &&
+			(rule.Condition == nil || rule.Condition(identity, ScopeAttributes(objectContext))) {
+			if missingRole == "" && len(rule.Roles) > 0 {
+				missingRole = rule.Roles[0]
+			}
 			// Check if identity has any of the required roles
 			for _, requiredRole := range rule.Roles {
 				if identity.HasRole(requiredRole) {
@@ -43,5 +60,10 @@ func (a *RuleBasedAuthorizer) Authorize(identity *Identity, action Action, objec
 		}
 	}
 
-	return fmt.Errorf("access denied: no matching authorization rule found for action '%s' on object '%s'", action, object)
+	err := NewDeniedError(ReasonNoMatchingRule, action, object, "no matching authorization rule found for action '%s' on object '%s'", action, object)
+	if missingRole != "" {
+		err.Reason = ReasonMissingRole
+		err.MissingRole = missingRole
+	}
+	return err
 }
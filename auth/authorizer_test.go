@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/fulcrumproject/commons/properties"
@@ -303,3 +305,91 @@ type mockObjectScope struct {
 func (m *mockObjectScope) Matches(identity *Identity) bool {
 	return m.shouldMatch
 }
+
+func TestRuleBasedAuthorizer_Authorize_RequireScope(t *testing.T) {
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{
+		{Roles: []Role{RoleParticipant}, Action: "read", Object: "service", RequireScope: true},
+	})
+	identity := &Identity{Role: RoleParticipant}
+
+	assert.Error(t, authorizer.Authorize(identity, "read", "service", nil), "Should fail without object scope")
+	assert.NoError(t, authorizer.Authorize(identity, "read", "service", AllwaysMatchObjectScope{}))
+}
+
+// ctxAuthorizer records the context it is called with
+type ctxAuthorizer struct {
+	ctx context.Context
+}
+
+func (c *ctxAuthorizer) Authorize(identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	return errors.New("Authorize must not be called")
+}
+
+func (c *ctxAuthorizer) AuthorizeCtx(ctx context.Context, identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	c.ctx = ctx
+	return nil
+}
+
+func TestAuthorizeCtx(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	identity := &Identity{Role: RoleAdmin}
+
+	t.Run("Context authorizer", func(t *testing.T) {
+		a := &ctxAuthorizer{}
+		require.NoError(t, AuthorizeCtx(ctx, a, identity, "read", "user", nil))
+		assert.Equal(t, "request", a.ctx.Value(ctxKey{}))
+	})
+
+	t.Run("Fallback to Authorize", func(t *testing.T) {
+		a := NewRuleBasedAuthorizer([]AuthorizationRule{{Roles: []Role{RoleAdmin}, Action: "read", Object: "user"}})
+		assert.NoError(t, AuthorizeCtx(ctx, a, identity, "read", "user", nil))
+		assert.Error(t, AuthorizeCtx(ctx, a, identity, "write", "user", nil))
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		a := &ctxAuthorizer{}
+		assert.ErrorIs(t, AuthorizeCtx(cancelled, a, identity, "read", "user", nil), context.Canceled)
+		assert.Nil(t, a.ctx)
+	})
+}
+
+func TestRuleBasedAuthorizer_Authorize_Wildcards(t *testing.T) {
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{
+		{Roles: []Role{RoleAdmin}, Action: AnyAction, Object: AnyObject},
+		{Roles: []Role{RoleParticipant}, Action: "read", Object: AnyObject},
+		{Roles: []Role{RoleAgent}, Action: AnyAction, Object: "job"},
+	})
+	participantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	admin := &Identity{Role: RoleAdmin}
+	participant := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	agent := &Identity{Role: RoleAgent, Scope: IdentityScope{ParticipantID: &participantID, AgentID: &agentID}}
+
+	tests := []struct {
+		name        string
+		identity    *Identity
+		action      Action
+		object      ObjectType
+		expectError bool
+	}{
+		{name: "Admin any action on any object", identity: admin, action: "delete", object: "participant"},
+		{name: "Participant reads any object", identity: participant, action: "read", object: "agent"},
+		{name: "Participant cannot update", identity: participant, action: "update", object: "agent", expectError: true},
+		{name: "Agent any action on job", identity: agent, action: "update", object: "job"},
+		{name: "Agent cannot act on other objects", identity: agent, action: "read", object: "agent", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(tt.identity, tt.action, tt.object, nil)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// CachingAuthorizer decorates an Authorizer memoizing the decisions,
+// only grants and DeniedError denials are cached, other failures like policy engine errors are not
+type CachingAuthorizer struct {
+	inner       Authorizer
+	ttl         time.Duration
+	negativeTTL time.Duration
+	metrics     CacheMetrics
+	cache       *LRU[error]
+}
+
+// CachedAuthorizer creates an authorizer caching the decisions of inner for ttl and keeping at most maxEntries,
+// decisions are keyed on the identity, the action, the object type and the object scope,
+// only scopes implementing CacheableScope are cached
+func CachedAuthorizer(inner Authorizer, ttl time.Duration, maxEntries int, opts ...CacheOption) *CachingAuthorizer {
+	cfg, negativeTTL := newCacheConfig(ttl, opts)
+	return &CachingAuthorizer{
+		inner:       inner,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		metrics:     cfg.metrics,
+		cache:       NewLRU[error](maxEntries, cfg.clock),
+	}
+}
+
+// Authorize returns the cached decision or delegates to the inner authorizer
+func (c *CachingAuthorizer) Authorize(identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	return c.AuthorizeCtx(context.Background(), identity, action, object, objectScope)
+}
+
+// AuthorizeCtx is like Authorize propagating the context to the inner authorizer
+func (c *CachingAuthorizer) AuthorizeCtx(ctx context.Context, identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	key, ok := decisionKey(identity, action, object, objectScope)
+	if !ok {
+		// scopes that cannot be fingerprinted are never cached
+		return AuthorizeCtx(ctx, c.inner, identity, action, object, objectScope)
+	}
+	if err, ok := c.cache.Get(key); ok {
+		c.metrics.ObserveLookup(true)
+		return err
+	}
+	c.metrics.ObserveLookup(false)
+
+	err := AuthorizeCtx(ctx, c.inner, identity, action, object, objectScope)
+	c.store(key, err)
+	return err
+}
+
+// AuthorizeAll serves the cached decisions and forwards the misses to the inner authorizer in a single batch
+func (c *CachingAuthorizer) AuthorizeAll(ctx context.Context, identity *Identity, requests []AuthzRequest) []error {
+	errs := make([]error, len(requests))
+	keys := make([]string, len(requests))
+	cacheable := make([]bool, len(requests))
+	var misses []AuthzRequest
+	var missIdx []int
+	for i, req := range requests {
+		keys[i], cacheable[i] = decisionKey(identity, req.Action, req.Object, req.Scope)
+		if cacheable[i] {
+			if err, ok := c.cache.Get(keys[i]); ok {
+				c.metrics.ObserveLookup(true)
+				errs[i] = err
+				continue
+			}
+			c.metrics.ObserveLookup(false)
+		}
+		misses = append(misses, req)
+		missIdx = append(missIdx, i)
+	}
+
+	for j, err := range AuthorizeAll(ctx, c.inner, identity, misses) {
+		i := missIdx[j]
+		errs[i] = err
+		if cacheable[i] {
+			c.store(keys[i], err)
+		}
+	}
+	return errs
+}
+
+func (c *CachingAuthorizer) store(key string, err error) {
+	var denied *DeniedError
+	switch {
+	case err == nil:
+		c.cache.Put(key, nil, c.ttl)
+	case errors.As(err, &denied):
+		c.cache.Put(key, err, c.negativeTTL)
+	}
+}
+
+// Len returns the number of cached decisions
+func (c *CachingAuthorizer) Len() int {
+	return c.cache.Len()
+}
+
+// CacheableScope is implemented by the object scopes that can be fingerprinted for the decision cache,
+// the key must identify every field the scope matches on, decisions on other scopes are never cached
+type CacheableScope interface {
+	CacheKey() (string, bool)
+}
+
+// scopeCacheKey fingerprints the scope, the nil scope included
+func scopeCacheKey(scope ObjectScope) (string, bool) {
+	if scope == nil {
+		return "nil", true
+	}
+	cacheable, ok := scope.(CacheableScope)
+	if !ok {
+		return "", false
+	}
+	return cacheable.CacheKey()
+}
+
+// decisionKey fingerprints the authorization request, the identity is included as a whole since
+// its scope, OAuth scopes and attributes can change the decision for the same identity ID
+func decisionKey(identity *Identity, action Action, object ObjectType, objectScope ObjectScope) (string, bool) {
+	if identity == nil {
+		return "", false
+	}
+	scopeKey, ok := scopeCacheKey(objectScope)
+	if !ok {
+		return "", false
+	}
+	data, err := json.Marshal(struct {
+		Identity *Identity
+		Action   Action
+		Object   ObjectType
+		Scope    string
+	}{identity, action, object, scopeKey})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
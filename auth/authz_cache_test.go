@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAuthorizer counts the calls returning err
+type countingAuthorizer struct {
+	calls int
+	err   error
+}
+
+func (a *countingAuthorizer) Authorize(identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	a.calls++
+	return a.err
+}
+
+type recordingCacheMetrics struct {
+	hits, misses int
+}
+
+func (m *recordingCacheMetrics) ObserveLookup(hit bool) {
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func TestCachedAuthorizer_CachesDecisions(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	metrics := &recordingCacheMetrics{}
+	inner := &countingAuthorizer{}
+	cached := CachedAuthorizer(inner, time.Minute, 10, WithCacheClock(clk), WithCacheMetrics(metrics))
+	identity := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+	scope := ParticipantObjectScope{ParticipantID: properties.NewUUID()}
+
+	for range 3 {
+		require.NoError(t, cached.Authorize(identity, "read", "job", scope))
+	}
+	assert.Equal(t, 1, inner.calls)
+	assert.Equal(t, &recordingCacheMetrics{hits: 2, misses: 1}, metrics)
+
+	clk.Advance(time.Minute)
+	require.NoError(t, cached.AuthorizeCtx(context.Background(), identity, "read", "job", scope))
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestCachedAuthorizer_KeyedOnRequest(t *testing.T) {
+	inner := &countingAuthorizer{}
+	cached := CachedAuthorizer(inner, time.Minute, 10)
+	participantID := properties.NewUUID()
+	identity := &Identity{ID: properties.NewUUID(), Name: "participant", Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	scope := ParticipantObjectScope{ParticipantID: participantID}
+
+	require.NoError(t, cached.Authorize(identity, "read", "job", scope))
+	require.NoError(t, cached.Authorize(identity, "update", "job", scope))
+	require.NoError(t, cached.Authorize(identity, "read", "agent", scope))
+	require.NoError(t, cached.Authorize(identity, "read", "job", ParticipantObjectScope{ParticipantID: properties.NewUUID()}))
+	require.NoError(t, cached.Authorize(identity, "read", "job", AgentObjectScope{AgentID: participantID}))
+
+	withScopes := identity.Clone()
+	withScopes.OAuthScopes = []string{"job:read"}
+	require.NoError(t, cached.Authorize(withScopes, "read", "job", scope))
+
+	assert.Equal(t, 6, inner.calls)
+	assert.Equal(t, 6, cached.Len())
+}
+
+func TestCachedAuthorizer_Denials(t *testing.T) {
+	identity := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+	denied := NewDeniedError(ReasonMissingRole, "read", "job", "role admin not allowed")
+
+	tests := []struct {
+		name          string
+		err           error
+		opts          []CacheOption
+		expectedCalls int
+	}{
+		{name: "Denied error cached", err: denied, expectedCalls: 1},
+		{name: "Wrapped denied error cached", err: errors.Join(errors.New("policy"), denied), expectedCalls: 1},
+		{name: "Negative caching disabled", err: denied, opts: []CacheOption{WithNegativeTTL(0)}, expectedCalls: 2},
+		{name: "Other error not cached", err: errors.New("policy engine unavailable"), expectedCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &countingAuthorizer{err: tt.err}
+			cached := CachedAuthorizer(inner, time.Minute, 10, tt.opts...)
+
+			for range 2 {
+				assert.Equal(t, tt.err, cached.Authorize(identity, "read", "job", AllwaysMatchObjectScope{}))
+			}
+			assert.Equal(t, tt.expectedCalls, inner.calls)
+		})
+	}
+}
+
+func TestCachedAuthorizer_EvictsLeastRecentlyUsed(t *testing.T) {
+	inner := &countingAuthorizer{}
+	cached := CachedAuthorizer(inner, time.Minute, 2)
+	identity := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+	scope := AllwaysMatchObjectScope{}
+
+	require.NoError(t, cached.Authorize(identity, "read", "job", scope))
+	require.NoError(t, cached.Authorize(identity, "update", "job", scope))
+	require.NoError(t, cached.Authorize(identity, "read", "job", scope))
+	require.NoError(t, cached.Authorize(identity, "delete", "job", scope))
+	assert.Equal(t, 3, inner.calls)
+	assert.Equal(t, 2, cached.Len())
+
+	require.NoError(t, cached.Authorize(identity, "read", "job", scope))
+	assert.Equal(t, 3, inner.calls)
+	require.NoError(t, cached.Authorize(identity, "update", "job", scope))
+	assert.Equal(t, 4, inner.calls)
+}
+
+func TestCachedAuthorizer_NilIdentityNotCached(t *testing.T) {
+	inner := &countingAuthorizer{}
+	cached := CachedAuthorizer(inner, time.Minute, 10)
+
+	require.NoError(t, cached.Authorize(nil, "read", "job", AllwaysMatchObjectScope{}))
+	require.NoError(t, cached.Authorize(nil, "read", "job", AllwaysMatchObjectScope{}))
+	assert.Equal(t, 2, inner.calls)
+	assert.Equal(t, 0, cached.Len())
+}
+
+// ownerScope matches on unexported state, it can't be fingerprinted
+type ownerScope struct {
+	owner properties.UUID
+}
+
+func (s ownerScope) Matches(identity *Identity) bool {
+	return identity != nil && identity.ID == s.owner
+}
+
+func TestCachedAuthorizer_ScopeKeys(t *testing.T) {
+	first, second := properties.NewUUID(), properties.NewUUID()
+
+	tests := []struct {
+		name          string
+		first, second ObjectScope
+		expectedCalls int
+		expectedLen   int
+	}{
+		{name: "Unexported fields not cached", first: ownerScope{owner: first}, second: ownerScope{owner: second}, expectedCalls: 2, expectedLen: 0},
+		{name: "Same default scope", first: &DefaultObjectScope{ParticipantID: &first}, second: &DefaultObjectScope{ParticipantID: &first}, expectedCalls: 1, expectedLen: 1},
+		{name: "Default scope fields", first: &DefaultObjectScope{ProviderID: &first}, second: &DefaultObjectScope{ConsumerID: &first}, expectedCalls: 2, expectedLen: 2},
+		{name: "Composite scopes", first: AllOf(ParticipantObjectScope{ParticipantID: first}, AgentObjectScope{AgentID: second}), second: AnyOf(ParticipantObjectScope{ParticipantID: first}, AgentObjectScope{AgentID: second}), expectedCalls: 2, expectedLen: 2},
+		{name: "Composite with unexported fields not cached", first: AllOf(ParticipantObjectScope{ParticipantID: first}, ownerScope{owner: first}), second: AllOf(ParticipantObjectScope{ParticipantID: first}, ownerScope{owner: second}), expectedCalls: 2, expectedLen: 0},
+		{name: "Attributes", first: WithAttributes(nil, map[string]any{"state": "new"}), second: WithAttributes(nil, map[string]any{"state": "done"}), expectedCalls: 2, expectedLen: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &countingAuthorizer{}
+			cached := CachedAuthorizer(inner, time.Minute, 10)
+			identity := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+
+			require.NoError(t, cached.Authorize(identity, "read", "job", tt.first))
+			require.NoError(t, cached.Authorize(identity, "read", "job", tt.second))
+			assert.Equal(t, tt.expectedCalls, inner.calls)
+			assert.Equal(t, tt.expectedLen, cached.Len())
+		})
+	}
+}
+
+func TestCachedAuthorizer_AuthorizeAll(t *testing.T) {
+	metrics := &recordingCacheMetrics{}
+	inner := &batchAuthorizer{}
+	cached := CachedAuthorizer(inner, time.Minute, 10, WithCacheMetrics(metrics))
+	identity := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+
+	require.NoError(t, cached.Authorize(identity, "read", "job", nil))
+	errs := cached.AuthorizeAll(context.Background(), identity, []AuthzRequest{
+		{Action: "read", Object: "job"},
+		{Action: "update", Object: "job"},
+		{Action: "delete", Object: "job"},
+	})
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+	assert.Equal(t, 1, inner.calls)
+	assert.Equal(t, [][]AuthzRequest{{{Action: "update", Object: "job"}, {Action: "delete", Object: "job"}}}, inner.batches)
+	assert.Equal(t, &recordingCacheMetrics{hits: 1, misses: 3}, metrics)
+	assert.Equal(t, 3, cached.Len())
+}
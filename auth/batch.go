@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// AuthzRequest is a single check of a batch authorization
+type AuthzRequest struct {
+	Action Action
+	Object ObjectType
+	Scope  ObjectScope
+}
+
+// BatchAuthorizer is implemented by authorizers able to evaluate many checks at once, e.g. in a single policy engine round trip
+type BatchAuthorizer interface {
+	// AuthorizeAll returns the decision of each request at the same index, nil when allowed
+	AuthorizeAll(ctx context.Context, identity *Identity, requests []AuthzRequest) []error
+}
+
+// AuthorizeAll evaluates the requests in one call when the authorizer supports it, falling back to AuthorizeCtx otherwise,
+// the returned slice has the same length and order as requests
+func AuthorizeAll(ctx context.Context, authorizer Authorizer, identity *Identity, requests []AuthzRequest) []error {
+	if len(requests) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		errs := make([]error, len(requests))
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	if ba, ok := authorizer.(BatchAuthorizer); ok {
+		return ba.AuthorizeAll(ctx, identity, requests)
+	}
+	errs := make([]error, len(requests))
+	for i, req := range requests {
+		errs[i] = AuthorizeCtx(ctx, authorizer, identity, req.Action, req.Object, req.Scope)
+	}
+	return errs
+}
+
+// Filter returns the items the identity is allowed to perform the action on, scope returns the object scope of an item
+// The first error that is not a DeniedError is returned, denied items are just left out
+func Filter[T any](ctx context.Context, authorizer Authorizer, identity *Identity, action Action, object ObjectType, items []T, scope func(T) ObjectScope) ([]T, error) {
+	requests := make([]AuthzRequest, len(items))
+	for i, item := range items {
+		requests[i] = AuthzRequest{Action: action, Object: object, Scope: scope(item)}
+	}
+	allowed := make([]T, 0, len(items))
+	var denied *DeniedError
+	for i, err := range AuthorizeAll(ctx, authorizer, identity, requests) {
+		if err == nil {
+			allowed = append(allowed, items[i])
+			continue
+		}
+		if !errors.As(err, &denied) {
+			return nil, err
+		}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchAuthorizer records the batches and allows everything
+type batchAuthorizer struct {
+	countingAuthorizer
+	batches [][]AuthzRequest
+}
+
+func (a *batchAuthorizer) AuthorizeAll(ctx context.Context, identity *Identity, requests []AuthzRequest) []error {
+	a.batches = append(a.batches, requests)
+	return make([]error, len(requests))
+}
+
+func TestAuthorizeAll(t *testing.T) {
+	participantID := properties.NewUUID()
+	otherID := properties.NewUUID()
+	identity := &Identity{ID: properties.NewUUID(), Name: "participant", Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{{Roles: []Role{RoleParticipant}, Action: "read", Object: "job"}})
+
+	errs := AuthorizeAll(context.Background(), authorizer, identity, []AuthzRequest{
+		{Action: "read", Object: "job", Scope: ParticipantObjectScope{ParticipantID: participantID}},
+		{Action: "read", Object: "job", Scope: ParticipantObjectScope{ParticipantID: otherID}},
+		{Action: "delete", Object: "job"},
+	})
+	require.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.ErrorContains(t, errs[1], "object context does not match identity")
+	assert.ErrorContains(t, errs[2], "no matching authorization rule")
+
+	assert.Nil(t, AuthorizeAll(context.Background(), authorizer, identity, nil))
+}
+
+func TestAuthorizeAll_Batch(t *testing.T) {
+	inner := &batchAuthorizer{}
+	requests := []AuthzRequest{{Action: "read", Object: "job"}, {Action: "update", Object: "job"}}
+
+	errs := AuthorizeAll(context.Background(), inner, &Identity{Name: "admin", Role: RoleAdmin}, requests)
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, [][]AuthzRequest{requests}, inner.batches)
+	assert.Zero(t, inner.calls)
+}
+
+func TestAuthorizeAll_CanceledContext(t *testing.T) {
+	inner := &countingAuthorizer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errs := AuthorizeAll(ctx, inner, &Identity{Name: "admin", Role: RoleAdmin}, []AuthzRequest{{Action: "read", Object: "job"}, {Action: "update", Object: "job"}})
+	assert.Equal(t, []error{context.Canceled, context.Canceled}, errs)
+	assert.Zero(t, inner.calls)
+}
+
+func TestFilter(t *testing.T) {
+	participantID := properties.NewUUID()
+	identity := &Identity{ID: properties.NewUUID(), Name: "participant", Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	owners := []properties.UUID{participantID, properties.NewUUID(), participantID}
+	scope := func(owner properties.UUID) ObjectScope { return ParticipantObjectScope{ParticipantID: owner} }
+
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{{Roles: []Role{RoleParticipant}, Action: "read", Object: "job"}})
+	allowed, err := Filter(context.Background(), authorizer, identity, "read", "job", owners, scope)
+	require.NoError(t, err)
+	assert.Equal(t, []properties.UUID{participantID, participantID}, allowed)
+
+	failure := errors.New("policy engine unavailable")
+	allowed, err = Filter(context.Background(), &countingAuthorizer{err: failure}, identity, "read", "job", owners, scope)
+	assert.ErrorIs(t, err, failure)
+	assert.Nil(t, allowed)
+}
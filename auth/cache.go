@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+)
+
+// CacheMetrics receives the cache instrumentation
+type CacheMetrics interface {
+	// ObserveLookup is called once per lookup reporting whether the result was cached
+	ObserveLookup(hit bool)
+}
+
+type noopCacheMetrics struct{}
+
+func (noopCacheMetrics) ObserveLookup(bool) {}
+
+type cacheConfig struct {
+	negativeTTL *time.Duration
+	clock       clock.Clock
+	metrics     CacheMetrics
+}
+
+// CacheOption configures CachingAuthenticator and CachingAuthorizer
+type CacheOption func(*cacheConfig)
+
+// WithCacheClock sets the clock used to expire the entries
+func WithCacheClock(clk clock.Clock) CacheOption {
+	return func(c *cacheConfig) {
+		c.clock = clk
+	}
+}
+
+// WithNegativeTTL sets how long failures are cached, zero disables negative caching
+// Defaults to the positive ttl for authorization denials and to at most defaultNegativeTTL for token rejections
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.negativeTTL = &ttl
+	}
+}
+
+// WithCacheMetrics sets the receiver of the hit and miss events
+func WithCacheMetrics(m CacheMetrics) CacheOption {
+	return func(c *cacheConfig) {
+		c.metrics = m
+	}
+}
+
+// defaultNegativeTTL bounds how long token rejections are cached by default
+const defaultNegativeTTL = 5 * time.Second
+
+func newCacheConfig(defaultNegative time.Duration, opts []CacheOption) (cfg cacheConfig, negativeTTL time.Duration) {
+	cfg = cacheConfig{clock: clock.New(), metrics: noopCacheMetrics{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	negativeTTL = defaultNegative
+	if cfg.negativeTTL != nil {
+		negativeTTL = *cfg.negativeTTL
+	}
+	return cfg, negativeTTL
+}
+
+// LRU is a size bounded cache with per entry expiration evicting the least recently used entries first,
+// it is safe for concurrent use
+type LRU[V any] struct {
+	mu         sync.Mutex
+	clock      clock.Clock
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// NewLRU creates a cache keeping at most maxEntries, expired with clk
+func NewLRU[V any](maxEntries int, clk clock.Clock) *LRU[V] {
+	return &LRU[V]{clock: clk, maxEntries: maxEntries, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// Get returns the value of the key unless it expired
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	el, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*lruEntry[V])
+	if !c.clock.Now().Before(entry.expiresAt) {
+		c.remove(el)
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// Put stores the value for ttl, nothing is stored when ttl is not positive
+func (c *LRU[V]) Put(key string, value V, ttl time.Duration) {
+	if ttl <= 0 || c.maxEntries <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &lruEntry[V]{key: key, value: value, expiresAt: c.clock.Now().Add(ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete removes the key
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries, the expired ones included until they are looked up or evicted
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU[V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry[V]).key)
+}
+
+// isRejection reports whether err definitively rejects the token, e.g. an invalid signature, a malformed or expired token,
+// transient failures like an unreachable key set are not rejections
+func isRejection(err error) bool {
+	return errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenRevoked) ||
+		errors.Is(err, ErrInvalidServiceToken) || errors.Is(err, ErrExpiredServiceToken)
+}
+
+// tokenExpiry reads the exp claim of JWT and service tokens without verifying them,
+// it only bounds the cache lifetime of the tokens accepted by the inner authenticator
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(strings.TrimPrefix(token, HMACTokenPrefix+"."), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return time.Time{}, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[len(parts)-2])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		ExpiresAt *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(data, &claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(*claims.ExpiresAt), 0), true
+}
+
+// CachingAuthenticator decorates an Authenticator memoizing the results by token hash
+type CachingAuthenticator struct {
+	inner       Authenticator
+	ttl         time.Duration
+	negativeTTL time.Duration
+	clock       clock.Clock
+	metrics     CacheMetrics
+	cache       *LRU[authResult]
+}
+
+type authResult struct {
+	identity *Identity
+	err      error
+}
+
+// CachedAuthenticator creates an authenticator caching the identities of inner for ttl and keeping at most maxEntries,
+// the least recently used entries are evicted first and identities are never cached past the token exp claim
+// Only the definitive rejections (ErrInvalidToken, ErrTokenRevoked and the service token errors) and unknown tokens are cached,
+// for at most defaultNegativeTTL unless WithNegativeTTL is set
+func CachedAuthenticator(inner Authenticator, ttl time.Duration, maxEntries int, opts ...CacheOption) *CachingAuthenticator {
+	cfg, negativeTTL := newCacheConfig(min(ttl, defaultNegativeTTL), opts)
+	return &CachingAuthenticator{
+		inner:       inner,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		clock:       cfg.clock,
+		metrics:     cfg.metrics,
+		cache:       NewLRU[authResult](maxEntries, cfg.clock),
+	}
+}
+
+// Authenticate returns the cached result for the token or delegates to the inner authenticator
+func (c *CachingAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	key := HashToken(token)
+	if res, ok := c.cache.Get(key); ok {
+		c.metrics.ObserveLookup(true)
+		return res.identity.Clone(), res.err
+	}
+	c.metrics.ObserveLookup(false)
+
+	identity, err := c.inner.Authenticate(ctx, token)
+	switch {
+	case err != nil && !isRejection(err):
+		return nil, err
+	case err != nil || identity == nil:
+		c.cache.Put(key, authResult{err: err}, c.negativeTTL)
+	default:
+		c.cache.Put(key, authResult{identity: identity.Clone()}, c.positiveTTL(token))
+	}
+	return identity, err
+}
+
+// positiveTTL caps the ttl to the token expiry
+func (c *CachingAuthenticator) positiveTTL(token string) time.Duration {
+	ttl := c.ttl
+	if exp, ok := tokenExpiry(token); ok {
+		ttl = min(ttl, exp.Sub(c.clock.Now()))
+	}
+	return ttl
+}
+
+// Invalidate removes the cached result of a token
+func (c *CachingAuthenticator) Invalidate(token string) {
+	c.cache.Delete(HashToken(token))
+}
+
+// Len returns the number of cached entries
+func (c *CachingAuthenticator) Len() int {
+	return c.cache.Len()
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAuthenticator counts the calls returning err, a nil identity for "unknown" or an identity named after the token
+type countingAuthenticator struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (a *countingAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	a.calls.Add(1)
+	if a.err != nil {
+		return nil, a.err
+	}
+	if token == "unknown" {
+		return nil, nil
+	}
+	return &Identity{Name: token, Role: RoleAdmin}, nil
+}
+
+func TestCachedAuthenticator_CachesIdentity(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	inner := &countingAuthenticator{}
+	cached := CachedAuthenticator(inner, time.Minute, 10, WithCacheClock(clk))
+
+	for range 3 {
+		identity, err := cached.Authenticate(context.Background(), "token")
+		require.NoError(t, err)
+		assert.Equal(t, "token", identity.Name)
+	}
+	assert.Equal(t, int32(1), inner.calls.Load())
+
+	clk.Advance(time.Minute)
+	_, err := cached.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
+
+func TestCachedAuthenticator_ReturnsCopy(t *testing.T) {
+	cached := CachedAuthenticator(&countingAuthenticator{}, time.Minute, 10)
+
+	identity, err := cached.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	identity.Role = RoleAgent
+
+	identity, err = cached.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, identity.Role)
+}
+
+func TestCachedAuthenticator_ClonesAttributes(t *testing.T) {
+	inner := &mockAuthenticator{identity: &Identity{Role: RoleAdmin, Attributes: map[string]any{"region": "eu-west"}}}
+	cached := CachedAuthenticator(inner, time.Minute, 10)
+
+	identity, err := cached.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	identity.Attributes["region"] = "us-east"
+
+	identity, err = cached.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west", identity.Attributes["region"])
+}
+
+func TestCachedAuthenticator_NegativeCaching(t *testing.T) {
+	authErr := fmt.Errorf("%w: token expired", ErrInvalidToken)
+
+	tests := []struct {
+		name          string
+		err           error
+		token         string
+		opts          []CacheOption
+		expectedCalls int32
+	}{
+		{name: "Error cached", err: authErr, token: "token", expectedCalls: 1},
+		{name: "Nil identity cached", token: "unknown", expectedCalls: 1},
+		{name: "Negative caching disabled", err: authErr, token: "token", opts: []CacheOption{WithNegativeTTL(0)}, expectedCalls: 2},
+		{name: "Revoked token cached", err: ErrTokenRevoked, token: "token", expectedCalls: 1},
+		{name: "Expired service token cached", err: ErrExpiredServiceToken, token: "token", expectedCalls: 1},
+		{name: "Context errors not cached", err: context.DeadlineExceeded, token: "token", expectedCalls: 2},
+		{name: "Transient errors not cached", err: errors.New("JWKS request failed"), token: "token", expectedCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &countingAuthenticator{err: tt.err}
+			cached := CachedAuthenticator(inner, time.Minute, 10, tt.opts...)
+
+			for range 2 {
+				identity, err := cached.Authenticate(context.Background(), tt.token)
+				assert.Nil(t, identity)
+				if tt.err != nil {
+					assert.ErrorIs(t, err, tt.err)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+			assert.Equal(t, tt.expectedCalls, inner.calls.Load())
+		})
+	}
+}
+
+func TestCachedAuthenticator_NegativeTTL(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	inner := &countingAuthenticator{err: ErrInvalidToken}
+	cached := CachedAuthenticator(inner, time.Hour, 10, WithCacheClock(clk), WithNegativeTTL(time.Second))
+
+	cached.Authenticate(context.Background(), "token")
+	cached.Authenticate(context.Background(), "token")
+	assert.Equal(t, int32(1), inner.calls.Load())
+	clk.Advance(time.Second)
+	cached.Authenticate(context.Background(), "token")
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
+
+func TestCachedAuthenticator_DefaultNegativeTTL(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	inner := &countingAuthenticator{err: ErrInvalidToken}
+	cached := CachedAuthenticator(inner, time.Hour, 10, WithCacheClock(clk))
+
+	cached.Authenticate(context.Background(), "token")
+	clk.Advance(defaultNegativeTTL - time.Millisecond)
+	cached.Authenticate(context.Background(), "token")
+	assert.Equal(t, int32(1), inner.calls.Load())
+	clk.Advance(time.Millisecond)
+	cached.Authenticate(context.Background(), "token")
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
+
+func TestCachedAuthenticator_CapsToTokenExpiry(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+	payload := func(exp time.Time) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ttl   time.Duration
+	}{
+		{name: "JWT expiring before the ttl", token: "e30." + payload(clk.Now().Add(10*time.Second)) + ".sig", ttl: 10 * time.Second},
+		{name: "Service token expiring before the ttl", token: HMACTokenPrefix + "." + payload(clk.Now().Add(20*time.Second)) + ".sig", ttl: 20 * time.Second},
+		{name: "JWT expiring after the ttl", token: "e30." + payload(clk.Now().Add(time.Hour)) + ".sig", ttl: time.Minute},
+		{name: "Opaque token", token: "opaque", ttl: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(clk.Now())
+			inner := &countingAuthenticator{}
+			cached := CachedAuthenticator(inner, time.Minute, 10, WithCacheClock(clk))
+
+			cached.Authenticate(context.Background(), tt.token)
+			clk.Advance(tt.ttl - time.Millisecond)
+			cached.Authenticate(context.Background(), tt.token)
+			assert.Equal(t, int32(1), inner.calls.Load())
+			clk.Advance(time.Millisecond)
+			cached.Authenticate(context.Background(), tt.token)
+			assert.Equal(t, int32(2), inner.calls.Load())
+		})
+	}
+}
+
+func TestCachedAuthenticator_EvictsLeastRecentlyUsed(t *testing.T) {
+	inner := &countingAuthenticator{}
+	cached := CachedAuthenticator(inner, time.Minute, 2)
+	ctx := context.Background()
+
+	cached.Authenticate(ctx, "a")
+	cached.Authenticate(ctx, "b")
+	cached.Authenticate(ctx, "a") // a is now the most recently used
+	cached.Authenticate(ctx, "c") // evicts b
+	assert.Equal(t, 2, cached.Len())
+	assert.Equal(t, int32(3), inner.calls.Load())
+
+	cached.Authenticate(ctx, "a")
+	assert.Equal(t, int32(3), inner.calls.Load())
+	cached.Authenticate(ctx, "b")
+	assert.Equal(t, int32(4), inner.calls.Load())
+}
+
+func TestCachedAuthenticator_Invalidate(t *testing.T) {
+	inner := &countingAuthenticator{}
+	cached := CachedAuthenticator(inner, time.Minute, 10)
+
+	cached.Authenticate(context.Background(), "token")
+	cached.Invalidate("token")
+	assert.Equal(t, 0, cached.Len())
+
+	cached.Authenticate(context.Background(), "token")
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
@@ -0,0 +1,74 @@
+package casbinauthz
+
+import (
+	"fmt"
+
+	"github.com/fulcrumproject/commons/auth"
+)
+
+// Enforcer is the subset of the Casbin enforcer API used by the authorizer, satisfied by *casbin.Enforcer
+type Enforcer interface {
+	Enforce(rvals ...any) (bool, error)
+}
+
+// RequestFunc maps an authorization check to the Casbin request values
+type RequestFunc func(identity *auth.Identity, action auth.Action, object auth.ObjectType, scope auth.ObjectScope) []any
+
+// DefaultRequest maps to the classic (sub, obj, act) request using the identity role as subject
+func DefaultRequest(identity *auth.Identity, action auth.Action, object auth.ObjectType, scope auth.ObjectScope) []any {
+	return []any{string(identity.Role), string(object), string(action)}
+}
+
+// DomainRequest maps to the (sub, dom, obj, act) request of RBAC with domains using the participant as domain,
+// identities without participant use "*"
+func DomainRequest(identity *auth.Identity, action auth.Action, object auth.ObjectType, scope auth.ObjectScope) []any {
+	domain := "*"
+	if identity.Scope.ParticipantID != nil {
+		domain = identity.Scope.ParticipantID.String()
+	}
+	return []any{string(identity.Role), domain, string(object), string(action)}
+}
+
+// Authorizer implements auth.Authorizer delegating decisions to a Casbin enforcer
+type Authorizer struct {
+	enforcer Enforcer
+	request  RequestFunc
+}
+
+// Option configures the Authorizer
+type Option func(*Authorizer)
+
+// WithRequest sets the mapping to Casbin request values
+func WithRequest(fn RequestFunc) Option {
+	return func(a *Authorizer) {
+		a.request = fn
+	}
+}
+
+// New creates a Casbin backed authorizer
+func New(enforcer Enforcer, opts ...Option) *Authorizer {
+	a := &Authorizer{enforcer: enforcer, request: DefaultRequest}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Authorize checks the object scope like auth.RuleBasedAuthorizer and then enforces the Casbin policy
+func (a *Authorizer) Authorize(identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	if identity == nil {
+		return auth.NewDeniedError(auth.ReasonMissingIdentity, action, object, "missing identity")
+	}
+	if objectScope != nil && !objectScope.Matches(identity) {
+		return auth.NewDeniedError(auth.ReasonScopeMismatch, action, object, "object context does not match identity")
+	}
+
+	allowed, err := a.enforcer.Enforce(a.request(identity, action, object, objectScope)...)
+	if err != nil {
+		return fmt.Errorf("casbin enforce failed: %w", err)
+	}
+	if !allowed {
+		return auth.NewDeniedError(auth.ReasonPolicy, action, object, "casbin policy denies action '%s' on object '%s'", action, object)
+	}
+	return nil
+}
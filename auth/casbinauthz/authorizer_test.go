@@ -0,0 +1,96 @@
+package casbinauthz
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEnforcer allows the requests listed in policies, keyed by the formatted request values
+type fakeEnforcer struct {
+	policies map[string]bool
+	err      error
+	requests [][]any
+}
+
+func (f *fakeEnforcer) Enforce(rvals ...any) (bool, error) {
+	f.requests = append(f.requests, rvals)
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.policies[fmt.Sprint(rvals...)], nil
+}
+
+func TestAuthorizer_Authorize(t *testing.T) {
+	participantID := properties.NewUUID()
+	participant := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID}}
+
+	tests := []struct {
+		name            string
+		enforcer        *fakeEnforcer
+		opts            []Option
+		scope           auth.ObjectScope
+		expectErr       bool
+		expectedRequest []any
+	}{
+		{
+			name:            "Allowed by policy",
+			enforcer:        &fakeEnforcer{policies: map[string]bool{fmt.Sprint("participant", "service", "read"): true}},
+			expectedRequest: []any{"participant", "service", "read"},
+		},
+		{
+			name:            "Denied by policy",
+			enforcer:        &fakeEnforcer{},
+			expectErr:       true,
+			expectedRequest: []any{"participant", "service", "read"},
+		},
+		{
+			name:            "Domain request",
+			enforcer:        &fakeEnforcer{policies: map[string]bool{fmt.Sprint("participant", participantID.String(), "service", "read"): true}},
+			opts:            []Option{WithRequest(DomainRequest)},
+			expectedRequest: []any{"participant", participantID.String(), "service", "read"},
+		},
+		{
+			name:            "Enforcer error",
+			enforcer:        &fakeEnforcer{err: errors.New("bad model")},
+			expectErr:       true,
+			expectedRequest: []any{"participant", "service", "read"},
+		},
+		{
+			name:      "Scope mismatch skips enforcer",
+			enforcer:  &fakeEnforcer{policies: map[string]bool{fmt.Sprint("participant", "service", "read"): true}},
+			scope:     &auth.DefaultObjectScope{ParticipantID: ptr(properties.NewUUID())},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(tt.enforcer, tt.opts...)
+			err := a.Authorize(participant, "read", "service", tt.scope)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectedRequest == nil {
+				assert.Empty(t, tt.enforcer.requests)
+				return
+			}
+			require.Len(t, tt.enforcer.requests, 1)
+			assert.Equal(t, tt.expectedRequest, tt.enforcer.requests[0])
+		})
+	}
+}
+
+func TestDomainRequest_NoParticipant(t *testing.T) {
+	req := DomainRequest(&auth.Identity{Role: auth.RoleAdmin}, "read", "service", nil)
+	assert.Equal(t, []any{"admin", "*", "service", "read"}, req)
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var (
+	catalogMu   sync.RWMutex
+	actions     = map[Action]bool{}
+	objectTypes = map[ObjectType]bool{}
+)
+
+// RegisterActions adds actions to the catalog used by Action.Validate, it panics on empty actions
+func RegisterActions(as ...Action) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	for _, a := range as {
+		if a == "" {
+			panic("auth: cannot register an empty action")
+		}
+		actions[a] = true
+	}
+}
+
+// RegisterObjectTypes adds object types to the catalog used by ObjectType.Validate, it panics on empty object types
+func RegisterObjectTypes(objects ...ObjectType) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	for _, o := range objects {
+		if o == "" {
+			panic("auth: cannot register an empty object type")
+		}
+		objectTypes[o] = true
+	}
+}
+
+// Actions returns the registered actions in name order
+func Actions() []Action {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	out := make([]Action, 0, len(actions))
+	for a := range actions {
+		out = append(out, a)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ObjectTypes returns the registered object types in name order
+func ObjectTypes() []ObjectType {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	out := make([]ObjectType, 0, len(objectTypes))
+	for o := range objectTypes {
+		out = append(out, o)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Validate ensures the Action is registered or is the AnyAction wildcard
+func (a Action) Validate() error {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	if a != AnyAction && !actions[a] {
+		return fmt.Errorf("invalid auth action: %s", a)
+	}
+	return nil
+}
+
+// Validate ensures the ObjectType is registered or is the AnyObject wildcard
+func (o ObjectType) Validate() error {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	if o != AnyObject && !objectTypes[o] {
+		return fmt.Errorf("invalid auth object type: %s", o)
+	}
+	return nil
+}
+
+// catalogEnforced reports which catalogs are populated, empty catalogs are not enforced so that registration is opt-in
+func catalogEnforced() (checkActions, checkObjects bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	return len(actions) > 0, len(objectTypes) > 0
+}
+
+// CheckCatalog validates the action and object type against the populated catalogs
+func CheckCatalog(action Action, object ObjectType) error {
+	checkActions, checkObjects := catalogEnforced()
+	var errs []error
+	if checkActions {
+		if err := action.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if checkObjects {
+		if err := object.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateRules cross-checks the rules against the role, action and object type catalogs, meant to be called at startup
+func ValidateRules(rules []AuthorizationRule) error {
+	var errs []error
+	for _, rule := range rules {
+		for _, role := range rule.Roles {
+			if err := role.validateGrantee(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := CheckCatalog(rule.Action, rule.Object); err != nil {
+			errs = append(errs, fmt.Errorf("rule %s on %s: %w", rule.Action, rule.Object, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetCatalog(t *testing.T) {
+	t.Cleanup(func() {
+		catalogMu.Lock()
+		actions = map[Action]bool{}
+		objectTypes = map[ObjectType]bool{}
+		catalogMu.Unlock()
+	})
+}
+
+func TestRegisterActionsAndObjectTypes(t *testing.T) {
+	resetCatalog(t)
+
+	RegisterActions("update", "read", "read")
+	RegisterObjectTypes("service", "agent")
+
+	assert.Equal(t, []Action{"read", "update"}, Actions())
+	assert.Equal(t, []ObjectType{"agent", "service"}, ObjectTypes())
+	assert.NoError(t, Action("read").Validate())
+	assert.EqualError(t, Action("reed").Validate(), "invalid auth action: reed")
+	assert.NoError(t, ObjectType("service").Validate())
+	assert.EqualError(t, ObjectType("servce").Validate(), "invalid auth object type: servce")
+	assert.NoError(t, AnyAction.Validate())
+	assert.NoError(t, AnyObject.Validate())
+
+	assert.Panics(t, func() { RegisterActions("") })
+	assert.Panics(t, func() { RegisterObjectTypes("") })
+}
+
+func TestCheckCatalog(t *testing.T) {
+	resetCatalog(t)
+
+	// Empty catalogs are not enforced
+	assert.NoError(t, CheckCatalog("anything", "whatever"))
+
+	RegisterActions("read")
+	assert.NoError(t, CheckCatalog("read", "whatever"))
+	assert.EqualError(t, CheckCatalog("write", "whatever"), "invalid auth action: write")
+
+	RegisterObjectTypes("service")
+	assert.NoError(t, CheckCatalog("read", "service"))
+	err := CheckCatalog("write", "servce")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid auth action: write")
+	assert.Contains(t, err.Error(), "invalid auth object type: servce")
+}
+
+func TestValidateRules(t *testing.T) {
+	resetCatalog(t)
+	RegisterActions("read", "update")
+	RegisterObjectTypes("service")
+
+	assert.NoError(t, ValidateRules([]AuthorizationRule{
+		{Roles: []Role{RoleAdmin}, Action: "read", Object: "service"},
+		{Roles: []Role{RoleAnonymous}, Action: "read", Object: "service"},
+	}))
+
+	err := ValidateRules([]AuthorizationRule{
+		{Roles: []Role{RoleAdmin}, Action: "read", Object: "service"},
+		{Roles: []Role{"superuser"}, Action: "update", Object: "service"},
+		{Roles: []Role{RoleAdmin}, Action: "upate", Object: "service"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid auth role: superuser")
+	assert.Contains(t, err.Error(), "rule upate on service: invalid auth action: upate")
+}
+
+func TestPolicy_Validate_Catalog(t *testing.T) {
+	resetCatalog(t)
+	RegisterActions("read")
+	RegisterObjectTypes("service")
+
+	_, err := ParsePolicy([]byte("roles:\n  admin:\n    service:\n      actions: [read]"))
+	assert.NoError(t, err)
+	_, err = ParsePolicy([]byte("roles:\n  anonymous:\n    service:\n      actions: [read]"))
+	assert.NoError(t, err, "policies can grant permissions to anonymous callers")
+
+	_, err = ParsePolicy([]byte("roles:\n  admin:\n    servce:\n      actions: [raed]"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "role admin: invalid auth object type: servce")
+	assert.Contains(t, err.Error(), "role admin on servce: invalid auth action: raed")
+}
@@ -1,6 +1,10 @@
 package auth
 
-import "context"
+import (
+	"context"
+
+	"github.com/fulcrumproject/commons/properties"
+)
 
 type authContextKey string
 
@@ -13,11 +17,35 @@ func WithIdentity(ctx context.Context, id *Identity) context.Context {
 	return context.WithValue(ctx, identityContextKey, id)
 }
 
-// MustGetIdentity retrieves the authenticated identity from the request context
-func MustGetIdentity(ctx context.Context) *Identity {
+// GetIdentity retrieves the authenticated identity from the context, reporting whether it is present
+func GetIdentity(ctx context.Context) (*Identity, bool) {
 	id, ok := ctx.Value(identityContextKey).(*Identity)
 	if !ok || id == nil {
+		return nil, false
+	}
+	return id, true
+}
+
+// MustGetIdentity retrieves the authenticated identity from the request context
+func MustGetIdentity(ctx context.Context) *Identity {
+	id, ok := GetIdentity(ctx)
+	if !ok {
 		panic("cannot find identity in context")
 	}
 	return id
 }
+
+// IdentityID returns the ID of the authenticated identity, reporting whether an identity is present
+func IdentityID(ctx context.Context) (properties.UUID, bool) {
+	id, ok := GetIdentity(ctx)
+	if !ok {
+		return properties.UUID{}, false
+	}
+	return id.ID, true
+}
+
+// HasRole reports whether the context holds an identity having the role, false when there is no identity
+func HasRole(ctx context.Context, role Role) bool {
+	id, ok := GetIdentity(ctx)
+	return ok && id.HasRole(role)
+}
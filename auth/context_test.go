@@ -77,3 +77,47 @@ func TestMustGetIdentity_Panic_NilIdentity(t *testing.T) {
 		MustGetIdentity(ctx)
 	}, "MustGetIdentity should panic when identity in context is nil")
 }
+
+func TestGetIdentity(t *testing.T) {
+	identity := &Identity{ID: properties.NewUUID(), Name: "test-user", Role: RoleAgent}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected *Identity
+		found    bool
+	}{
+		{name: "Present", ctx: WithIdentity(context.Background(), identity), expected: identity, found: true},
+		{name: "Missing", ctx: context.Background()},
+		{name: "Nil identity", ctx: WithIdentity(context.Background(), nil)},
+		{name: "Wrong type", ctx: context.WithValue(context.Background(), identityContextKey, "not-an-identity")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GetIdentity(tt.ctx)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.expected, got)
+
+			id, ok := IdentityID(tt.ctx)
+			assert.Equal(t, tt.found, ok)
+			if tt.found {
+				assert.Equal(t, identity.ID, id)
+			} else {
+				assert.Equal(t, properties.UUID{}, id)
+			}
+		})
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	t.Cleanup(func() { SetRoleHierarchy(nil) })
+	ctx := WithIdentity(context.Background(), &Identity{Role: RoleAdmin})
+
+	assert.True(t, HasRole(ctx, RoleAdmin))
+	assert.False(t, HasRole(ctx, RoleAgent))
+	assert.False(t, HasRole(context.Background(), RoleAdmin))
+
+	require.NoError(t, SetRoleHierarchy(DefaultRoleHierarchy))
+	assert.True(t, HasRole(ctx, RoleAgent), "roles implied by the hierarchy")
+}
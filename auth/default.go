@@ -0,0 +1,102 @@
+package auth
+
+import "slices"
+
+// DefaultRolesAuthorizer encodes the standard Fulcrum rules for the built-in roles:
+// admins can do everything, participants act on objects of their participant and agents on objects of their agent
+// Roles are checked with Identity.HasRole so the roles implying a built-in role through the hierarchy get its rules
+type DefaultRolesAuthorizer struct {
+	overrides  *RuleBasedAuthorizer
+	overridden map[ruleTarget]bool
+}
+
+type ruleTarget struct {
+	action Action
+	object ObjectType
+}
+
+// DefaultAuthorizer creates the standard Fulcrum authorizer, the override rules replace the defaults
+// for the action and object type pairs they define
+func DefaultAuthorizer(overrides ...AuthorizationRule) *DefaultRolesAuthorizer {
+	overridden := make(map[ruleTarget]bool, len(overrides))
+	for _, rule := range overrides {
+		overridden[ruleTarget{rule.Action, rule.Object}] = true
+	}
+	return &DefaultRolesAuthorizer{overrides: NewRuleBasedAuthorizer(overrides), overridden: overridden}
+}
+
+// Authorize applies the override rules of the action and object type if any, otherwise the role defaults
+func (a *DefaultRolesAuthorizer) Authorize(identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	if identity == nil {
+		return NewDeniedError(ReasonMissingIdentity, action, object, "missing identity")
+	}
+	if a.isOverridden(action, object) {
+		return a.overrides.Authorize(identity, action, object, objectScope)
+	}
+
+	switch {
+	case identity.HasRole(RoleAdmin):
+		if objectScope != nil && !objectScope.Matches(identity) {
+			return NewDeniedError(ReasonScopeMismatch, action, object, "object context does not match identity")
+		}
+		return nil
+	case identity.HasRole(RoleParticipant):
+		if identity.Scope.ParticipantID == nil {
+			return NewDeniedError(ReasonScopeMismatch, action, object, "participant identity without participant id")
+		}
+		return ownedScope(identity, action, object, objectScope)
+	case identity.HasRole(RoleAgent):
+		if identity.Scope.AgentID == nil {
+			return NewDeniedError(ReasonScopeMismatch, action, object, "agent identity without agent id")
+		}
+		// agents only own the objects of their agent, not the ones of their participant
+		agent := identity.Clone()
+		agent.Scope.ParticipantID = nil
+		return ownedScope(agent, action, object, objectScope)
+	default:
+		err := NewDeniedError(ReasonMissingRole, action, object, "no matching authorization rule found for action '%s' on object '%s'", action, object)
+		err.MissingRole = RoleAdmin
+		return err
+	}
+}
+
+// isOverridden checks whether override rules are defined for the action and object type, wildcards included
+func (a *DefaultRolesAuthorizer) isOverridden(action Action, object ObjectType) bool {
+	return a.overridden[ruleTarget{action, object}] || a.overridden[ruleTarget{AnyAction, object}] ||
+		a.overridden[ruleTarget{action, AnyObject}] || a.overridden[ruleTarget{AnyAction, AnyObject}]
+}
+
+// ownedScope requires an object scope naming a participant or agent and matching the identity,
+// global scopes are only open to admins
+func ownedScope(identity *Identity, action Action, object ObjectType, objectScope ObjectScope) error {
+	if objectScope == nil {
+		return NewDeniedError(ReasonScopeMismatch, action, object, "object scope required for role %s", identity.Role)
+	}
+	if !namesOwner(objectScope) {
+		return NewDeniedError(ReasonScopeMismatch, action, object, "object scope does not name a participant or agent")
+	}
+	if !objectScope.Matches(identity) {
+		return NewDeniedError(ReasonScopeMismatch, action, object, "object context does not match identity")
+	}
+	return nil
+}
+
+// namesOwner reports whether the scope restricts the object to a participant or agent, unknown scopes never do
+func namesOwner(scope ObjectScope) bool {
+	switch s := scope.(type) {
+	case *DefaultObjectScope:
+		return s != nil && (s.ParticipantID != nil || s.ProviderID != nil || s.ConsumerID != nil || s.AgentID != nil)
+	case ParticipantObjectScope, *ParticipantObjectScope, AgentObjectScope, *AgentObjectScope:
+		return true
+	case *AttributeObjectScope:
+		return s != nil && namesOwner(s.Scope)
+	case AllOfScope:
+		// a single owner is enough, all the scopes must match anyway
+		return slices.ContainsFunc(s, namesOwner)
+	case AnyOfScope:
+		// any unrestricted alternative would match every identity
+		return len(s) > 0 && !slices.ContainsFunc(s, func(scope ObjectScope) bool { return !namesOwner(scope) })
+	default:
+		return false
+	}
+}
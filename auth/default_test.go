@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultAuthorizer_Authorize(t *testing.T) {
+	participantID := properties.NewUUID()
+	otherParticipantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	otherAgentID := properties.NewUUID()
+
+	admin := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+	participant := &Identity{ID: properties.NewUUID(), Name: "participant", Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	agent := &Identity{ID: properties.NewUUID(), Name: "agent", Role: RoleAgent, Scope: IdentityScope{ParticipantID: &participantID, AgentID: &agentID}}
+
+	tests := []struct {
+		name           string
+		identity       *Identity
+		objectScope    ObjectScope
+		expectedReason DenyReason
+	}{
+		{name: "Admin without scope", identity: admin},
+		{name: "Admin on participant object", identity: admin, objectScope: &DefaultObjectScope{ParticipantID: &otherParticipantID}},
+		{name: "Participant on own object", identity: participant, objectScope: &DefaultObjectScope{ParticipantID: &participantID}},
+		{name: "Participant as consumer", identity: participant, objectScope: &DefaultObjectScope{ConsumerID: &participantID}},
+		{name: "Participant on other object", identity: participant, objectScope: &DefaultObjectScope{ParticipantID: &otherParticipantID}, expectedReason: ReasonScopeMismatch},
+		{name: "Participant without scope", identity: participant, expectedReason: ReasonScopeMismatch},
+		{name: "Participant without participant id", identity: &Identity{Name: "p", Role: RoleParticipant}, objectScope: &DefaultObjectScope{}, expectedReason: ReasonScopeMismatch},
+		{name: "Agent on own object", identity: agent, objectScope: &DefaultObjectScope{ParticipantID: &participantID, AgentID: &agentID}},
+		{name: "Agent on other agent object", identity: agent, objectScope: &DefaultObjectScope{AgentID: &otherAgentID}, expectedReason: ReasonScopeMismatch},
+		{name: "Agent on participant object", identity: agent, objectScope: &DefaultObjectScope{ParticipantID: &participantID}, expectedReason: ReasonScopeMismatch},
+		{name: "Agent without scope", identity: agent, expectedReason: ReasonScopeMismatch},
+		{name: "Participant with global scope", identity: participant, objectScope: &DefaultObjectScope{}, expectedReason: ReasonScopeMismatch},
+		{name: "Participant with always match scope", identity: participant, objectScope: AllwaysMatchObjectScope{}, expectedReason: ReasonScopeMismatch},
+		{name: "Agent with global scope", identity: agent, objectScope: &DefaultObjectScope{}, expectedReason: ReasonScopeMismatch},
+		{name: "Agent with always match scope", identity: agent, objectScope: &AllwaysMatchObjectScope{}, expectedReason: ReasonScopeMismatch},
+		{name: "Participant with participant scope", identity: participant, objectScope: ParticipantObjectScope{ParticipantID: participantID}},
+		{name: "Agent with agent scope and attributes", identity: agent, objectScope: WithAttributes(AgentObjectScope{AgentID: agentID}, map[string]any{"state": "new"})},
+		{name: "Participant with global attributes scope", identity: participant, objectScope: WithAttributes(nil, map[string]any{"state": "new"}), expectedReason: ReasonScopeMismatch},
+		{name: "Participant with owned and global alternatives", identity: participant, objectScope: AnyOf(ParticipantObjectScope{ParticipantID: participantID}, AllwaysMatchObjectScope{}), expectedReason: ReasonScopeMismatch},
+		{name: "Participant with owned and global scopes", identity: participant, objectScope: AllOf(&DefaultObjectScope{ParticipantID: &participantID}, AllwaysMatchObjectScope{})},
+		{name: "Participant with organization scope", identity: participant, objectScope: OrganizationObjectScope{}, expectedReason: ReasonScopeMismatch},
+		{name: "Admin with global scope", identity: admin, objectScope: &DefaultObjectScope{}},
+		{name: "Anonymous", identity: Anonymous(), objectScope: &DefaultObjectScope{}, expectedReason: ReasonMissingRole},
+		{name: "Missing identity", expectedReason: ReasonMissingIdentity},
+	}
+
+	authorizer := DefaultAuthorizer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(tt.identity, "read", "job", tt.objectScope)
+			if tt.expectedReason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var denied *DeniedError
+			require.ErrorAs(t, err, &denied)
+			assert.Equal(t, tt.expectedReason, denied.Reason)
+		})
+	}
+}
+
+func TestDefaultAuthorizer_RoleHierarchy(t *testing.T) {
+	t.Cleanup(func() {
+		SetRoleHierarchy(nil)
+		rolesMu.Lock()
+		registeredRoles = map[Role]bool{}
+		rolesMu.Unlock()
+	})
+	RegisterRoles("operator")
+	require.NoError(t, SetRoleHierarchy(RoleHierarchy{"operator": {RoleAdmin}, RoleAdmin: {RoleParticipant}, RoleParticipant: {RoleAgent}}))
+
+	participantID := properties.NewUUID()
+	otherParticipantID := properties.NewUUID()
+	operator := &Identity{ID: properties.NewUUID(), Name: "operator", Role: "operator"}
+	participant := &Identity{ID: properties.NewUUID(), Name: "participant", Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	authorizer := DefaultAuthorizer()
+
+	assert.NoError(t, authorizer.Authorize(operator, "read", "job", &DefaultObjectScope{ParticipantID: &otherParticipantID}), "operator implies admin")
+	assert.NoError(t, authorizer.Authorize(participant, "read", "job", &DefaultObjectScope{ParticipantID: &participantID}))
+	var denied *DeniedError
+	require.ErrorAs(t, authorizer.Authorize(participant, "read", "job", &DefaultObjectScope{ParticipantID: &otherParticipantID}), &denied)
+	assert.Equal(t, ReasonScopeMismatch, denied.Reason, "participant rules apply before the implied agent ones")
+}
+
+func TestDefaultAuthorizer_Overrides(t *testing.T) {
+	participantID := properties.NewUUID()
+	participant := &Identity{ID: properties.NewUUID(), Name: "participant", Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	admin := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+
+	authorizer := DefaultAuthorizer(
+		AuthorizationRule{Roles: []Role{RoleAdmin, RoleParticipant}, Action: "list", Object: "service_type"},
+		AuthorizationRule{Roles: []Role{RoleParticipant}, Action: "delete", Object: "participant"},
+	)
+
+	assert.NoError(t, authorizer.Authorize(participant, "list", "service_type", nil))
+	assert.NoError(t, authorizer.Authorize(admin, "list", "service_type", nil))
+	assert.NoError(t, authorizer.Authorize(participant, "delete", "participant", &DefaultObjectScope{ParticipantID: &participantID}))
+
+	err := authorizer.Authorize(admin, "delete", "participant", nil)
+	var denied *DeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Equal(t, ReasonMissingRole, denied.Reason)
+	assert.Equal(t, RoleParticipant, denied.MissingRole)
+
+	assert.NoError(t, authorizer.Authorize(admin, "delete", "job", nil), "pairs not overridden keep the defaults")
+}
+
+func TestDefaultAuthorizer_WildcardOverrides(t *testing.T) {
+	admin := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+	authorizer := DefaultAuthorizer(AuthorizationRule{Roles: []Role{RoleParticipant}, Action: AnyAction, Object: "participant"})
+
+	assert.Error(t, authorizer.Authorize(admin, "delete", "participant", nil))
+	assert.Error(t, authorizer.Authorize(admin, "read", "participant", nil))
+	assert.NoError(t, authorizer.Authorize(admin, "read", "job", nil))
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DenyReason is a machine readable reason of an authorization denial
+type DenyReason string
+
+const (
+	ReasonNoMatchingRule  DenyReason = "no_matching_rule"
+	ReasonScopeMismatch   DenyReason = "scope_mismatch"
+	ReasonMissingRole     DenyReason = "missing_role"
+	ReasonMissingScope    DenyReason = "missing_scope"
+	ReasonPolicy          DenyReason = "policy"
+	ReasonMissingIdentity DenyReason = "missing_identity"
+)
+
+// DeniedError is returned by authorizers when access is denied
+type DeniedError struct {
+	Reason DenyReason
+	// MissingRole is a role that would have granted access, if known
+	MissingRole Role
+	Action      Action
+	ObjectType  ObjectType
+	// RequiredScopes are the OAuth scopes that were missing, if any
+	RequiredScopes []string
+	// Message is the human readable detail
+	Message string
+}
+
+// Error returns the access denied message
+func (e *DeniedError) Error() string {
+	return "access denied: " + e.Message
+}
+
+// DeniedReason returns the machine readable reason
+func (e *DeniedError) DeniedReason() string {
+	return string(e.Reason)
+}
+
+// NewDeniedError creates a DeniedError with a formatted message
+func NewDeniedError(reason DenyReason, action Action, object ObjectType, format string, args ...any) *DeniedError {
+	return &DeniedError{
+		Reason:     reason,
+		Action:     action,
+		ObjectType: object,
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+// Details returns the non empty structured fields of the denial
+func (e *DeniedError) Details() map[string]string {
+	details := map[string]string{}
+	if e.Action != "" {
+		details["action"] = string(e.Action)
+	}
+	if e.ObjectType != "" {
+		details["objectType"] = string(e.ObjectType)
+	}
+	if e.MissingRole != "" {
+		details["missingRole"] = string(e.MissingRole)
+	}
+	if len(e.RequiredScopes) > 0 {
+		details["requiredScopes"] = strings.Join(e.RequiredScopes, " ")
+	}
+	return details
+}
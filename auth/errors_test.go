@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeniedError(t *testing.T) {
+	err := NewDeniedError(ReasonPolicy, "read", "service", "policy denies action '%s'", "read")
+
+	assert.Equal(t, "access denied: policy denies action 'read'", err.Error())
+	assert.Equal(t, "policy", err.DeniedReason())
+	assert.Equal(t, Action("read"), err.Action)
+	assert.Equal(t, ObjectType("service"), err.ObjectType)
+
+	var denied *DeniedError
+	require.True(t, errors.As(fmt.Errorf("wrapped: %w", err), &denied))
+	assert.Equal(t, ReasonPolicy, denied.Reason)
+}
+
+func TestRuleBasedAuthorizer_DeniedError(t *testing.T) {
+	participantID := properties.NewUUID()
+	otherID := properties.NewUUID()
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{
+		{Roles: []Role{RoleAdmin}, Action: "delete", Object: "service"},
+	})
+	participant := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+
+	tests := []struct {
+		name        string
+		action      Action
+		object      ObjectType
+		scope       ObjectScope
+		reason      DenyReason
+		missingRole Role
+		message     string
+	}{
+		{
+			name:        "Missing role",
+			action:      "delete",
+			object:      "service",
+			reason:      ReasonMissingRole,
+			missingRole: RoleAdmin,
+			message:     "access denied: no matching authorization rule found for action 'delete' on object 'service'",
+		},
+		{
+			name:    "No matching rule",
+			action:  "read",
+			object:  "service",
+			reason:  ReasonNoMatchingRule,
+			message: "access denied: no matching authorization rule found for action 'read' on object 'service'",
+		},
+		{
+			name:    "Scope mismatch",
+			action:  "delete",
+			object:  "service",
+			scope:   &DefaultObjectScope{ParticipantID: &otherID},
+			reason:  ReasonScopeMismatch,
+			message: "access denied: object context does not match identity",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(participant, tt.action, tt.object, tt.scope)
+
+			var denied *DeniedError
+			require.True(t, errors.As(err, &denied))
+			assert.Equal(t, tt.reason, denied.Reason)
+			assert.Equal(t, tt.missingRole, denied.MissingRole)
+			assert.Equal(t, tt.action, denied.Action)
+			assert.Equal(t, tt.object, denied.ObjectType)
+			assert.Equal(t, tt.message, err.Error())
+		})
+	}
+}
+
+func TestDeniedError_Details(t *testing.T) {
+	err := NewDeniedError(ReasonMissingScope, "update", "service", "missing scopes")
+	err.RequiredScopes = []string{"service:write", "service:admin"}
+
+	assert.Equal(t, map[string]string{
+		"action":         "update",
+		"objectType":     "service",
+		"requiredScopes": "service:write service:admin",
+	}, err.Details())
+	assert.Empty(t, (&DeniedError{Reason: ReasonPolicy}).Details())
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+)
+
+// HMACTokenPrefix marks the service tokens so that other tokens can be skipped cheaply
+const HMACTokenPrefix = "fst1"
+
+// MinHMACSecretLength is the minimum length in bytes of the HMAC secrets
+const MinHMACSecretLength = 32
+
+var (
+	ErrInvalidServiceToken = errors.New("invalid service token")
+	ErrExpiredServiceToken = errors.New("service token expired")
+)
+
+// serviceClaims is the payload of the service tokens
+type serviceClaims struct {
+	Subject        properties.UUID  `json:"sub"`
+	Name           string           `json:"name,omitempty"`
+	Role           Role             `json:"role"`
+	Kind           IdentityKind     `json:"kind,omitempty"`
+	ParticipantID  *properties.UUID `json:"participantId,omitempty"`
+	AgentID        *properties.UUID `json:"agentId,omitempty"`
+	OrganizationID *properties.UUID `json:"organizationId,omitempty"`
+	Attributes     map[string]any   `json:"attrs,omitempty"`
+	IssuedAt       int64            `json:"iat"`
+	ExpiresAt      int64            `json:"exp"`
+}
+
+// HMACOption configures the HMAC token issuer and authenticator
+type HMACOption func(*hmacConfig)
+
+type hmacConfig struct {
+	clock clock.Clock
+}
+
+// WithHMACClock sets the clock used for issuing and expiring tokens
+func WithHMACClock(clk clock.Clock) HMACOption {
+	return func(c *hmacConfig) {
+		c.clock = clk
+	}
+}
+
+func newHMACConfig(opts []HMACOption) hmacConfig {
+	cfg := hmacConfig{clock: clock.New()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+func validateHMACSecret(secret []byte) error {
+	if len(secret) < MinHMACSecretLength {
+		return fmt.Errorf("HMAC secret must be at least %d bytes", MinHMACSecretLength)
+	}
+	return nil
+}
+
+func signHMAC(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(HMACTokenPrefix + "." + payload))
+	return mac.Sum(nil)
+}
+
+// HMACTokenIssuer issues short lived symmetric key tokens for internal service-to-service calls
+type HMACTokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+	clock  clock.Clock
+}
+
+// NewHMACTokenIssuer creates an issuer signing with secret tokens valid for ttl
+func NewHMACTokenIssuer(secret []byte, ttl time.Duration, opts ...HMACOption) (*HMACTokenIssuer, error) {
+	if err := validateHMACSecret(secret); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return nil, errors.New("HMAC token ttl must be positive")
+	}
+	cfg := newHMACConfig(opts)
+	return &HMACTokenIssuer{secret: secret, ttl: ttl, clock: cfg.clock}, nil
+}
+
+// Issue returns a token for the identity, only agent and participant identities can be issued
+func (i *HMACTokenIssuer) Issue(identity *Identity) (string, error) {
+	if identity == nil {
+		return "", errors.New("cannot issue service token: missing identity")
+	}
+	if identity.Role != RoleAgent && identity.Role != RoleParticipant {
+		return "", fmt.Errorf("cannot issue service token for role %s", identity.Role)
+	}
+	if err := identity.Validate(); err != nil {
+		return "", fmt.Errorf("cannot issue service token: %w", err)
+	}
+
+	now := i.clock.Now()
+	payload, err := json.Marshal(serviceClaims{
+		Subject:        identity.ID,
+		Name:           identity.Name,
+		Role:           identity.Role,
+		Kind:           identity.Kind,
+		ParticipantID:  identity.Scope.ParticipantID,
+		AgentID:        identity.Scope.AgentID,
+		OrganizationID: identity.Scope.OrganizationID,
+		Attributes:     identity.Attributes,
+		IssuedAt:       now.Unix(),
+		ExpiresAt:      now.Add(i.ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot encode service token: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	sig := base64.RawURLEncoding.EncodeToString(signHMAC(i.secret, encoded))
+	return HMACTokenPrefix + "." + encoded + "." + sig, nil
+}
+
+// HMACTokenAuthenticator implements Authenticator verifying the tokens of HMACTokenIssuer
+type HMACTokenAuthenticator struct {
+	secrets [][]byte
+	clock   clock.Clock
+}
+
+// NewHMACTokenAuthenticator creates an authenticator accepting tokens signed with any of the secrets,
+// multiple secrets allow rotating keys without downtime
+func NewHMACTokenAuthenticator(secrets [][]byte, opts ...HMACOption) (*HMACTokenAuthenticator, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("at least one HMAC secret is required")
+	}
+	for _, secret := range secrets {
+		if err := validateHMACSecret(secret); err != nil {
+			return nil, err
+		}
+	}
+	cfg := newHMACConfig(opts)
+	return &HMACTokenAuthenticator{secrets: secrets, clock: cfg.clock}, nil
+}
+
+// Authenticate verifies the signature and expiration of a service token
+// Tokens without the service token prefix return a nil identity without error so other authenticators can be chained
+func (a *HMACTokenAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	rest, ok := strings.CutPrefix(token, HMACTokenPrefix+".")
+	if !ok {
+		return nil, nil
+	}
+	payload, encodedSig, ok := strings.Cut(rest, ".")
+	if !ok {
+		return nil, ErrInvalidServiceToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, ErrInvalidServiceToken
+	}
+	if !a.verify(payload, sig) {
+		return nil, ErrInvalidServiceToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidServiceToken
+	}
+	var claims serviceClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, ErrInvalidServiceToken
+	}
+	if !a.clock.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrExpiredServiceToken
+	}
+
+	identity := &Identity{
+		ID:   claims.Subject,
+		Name: claims.Name,
+		Role: claims.Role,
+		Kind: claims.Kind,
+		Scope: IdentityScope{
+			ParticipantID:  claims.ParticipantID,
+			AgentID:        claims.AgentID,
+			OrganizationID: claims.OrganizationID,
+		},
+		Attributes: claims.Attributes,
+	}
+	if err := identity.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidServiceToken, err)
+	}
+	return identity, nil
+}
+
+func (a *HMACTokenAuthenticator) verify(payload string, sig []byte) bool {
+	for _, secret := range a.secrets {
+		if hmac.Equal(sig, signHMAC(secret, payload)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testHMACSecret  = []byte("0123456789abcdef0123456789abcdef")
+	otherHMACSecret = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestHMACToken_IssueAndAuthenticate(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	participantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	agent := &Identity{
+		ID:         properties.NewUUID(),
+		Name:       "agent-1",
+		Role:       RoleAgent,
+		Kind:       KindServiceAccount,
+		Scope:      IdentityScope{ParticipantID: &participantID, AgentID: &agentID},
+		Attributes: map[string]any{"region": "eu-west"},
+	}
+
+	issuer, err := NewHMACTokenIssuer(testHMACSecret, time.Minute, WithHMACClock(clk))
+	require.NoError(t, err)
+	authenticator, err := NewHMACTokenAuthenticator([][]byte{testHMACSecret}, WithHMACClock(clk))
+	require.NoError(t, err)
+
+	token, err := issuer.Issue(agent)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, HMACTokenPrefix+"."))
+
+	identity, err := authenticator.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, agent, identity)
+
+	clk.Advance(time.Minute)
+	_, err = authenticator.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrExpiredServiceToken)
+}
+
+func TestHMACTokenAuthenticator_Authenticate(t *testing.T) {
+	participantID := properties.NewUUID()
+	participant := &Identity{ID: participantID, Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+
+	issuer, err := NewHMACTokenIssuer(testHMACSecret, time.Hour)
+	require.NoError(t, err)
+	token, err := issuer.Issue(participant)
+	require.NoError(t, err)
+	otherIssuer, err := NewHMACTokenIssuer(otherHMACSecret, time.Hour)
+	require.NoError(t, err)
+	otherToken, err := otherIssuer.Issue(participant)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	otherParts := strings.Split(otherToken, ".")
+
+	tests := []struct {
+		name        string
+		secrets     [][]byte
+		token       string
+		expectNil   bool
+		expectError error
+	}{
+		{name: "Valid token", secrets: [][]byte{testHMACSecret}, token: token},
+		{name: "Rotated secret", secrets: [][]byte{otherHMACSecret, testHMACSecret}, token: token},
+		{name: "Foreign token is skipped", secrets: [][]byte{testHMACSecret}, token: "eyJhbGciOi.jwt.token", expectNil: true},
+		{name: "Wrong secret", secrets: [][]byte{testHMACSecret}, token: otherToken, expectError: ErrInvalidServiceToken},
+		{name: "Tampered payload", secrets: [][]byte{testHMACSecret}, token: strings.Join([]string{parts[0], otherParts[1] + "x", parts[2]}, "."), expectError: ErrInvalidServiceToken},
+		{name: "Missing signature", secrets: [][]byte{testHMACSecret}, token: parts[0] + "." + parts[1], expectError: ErrInvalidServiceToken},
+		{name: "Malformed signature", secrets: [][]byte{testHMACSecret}, token: parts[0] + "." + parts[1] + ".!!", expectError: ErrInvalidServiceToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := NewHMACTokenAuthenticator(tt.secrets)
+			require.NoError(t, err)
+
+			identity, err := authenticator.Authenticate(context.Background(), tt.token)
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				assert.Nil(t, identity)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectNil {
+				assert.Nil(t, identity)
+			} else {
+				assert.Equal(t, participant, identity)
+			}
+		})
+	}
+}
+
+func TestHMACTokenIssuer_Issue_Invalid(t *testing.T) {
+	issuer, err := NewHMACTokenIssuer(testHMACSecret, time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		identity      *Identity
+		errorContains string
+	}{
+		{name: "Missing identity", errorContains: "missing identity"},
+		{name: "Admin role", identity: &Identity{Role: RoleAdmin}, errorContains: "cannot issue service token for role admin"},
+		{name: "Invalid scope", identity: &Identity{Role: RoleAgent}, errorContains: "agent role requires participant id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := issuer.Issue(tt.identity)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorContains)
+		})
+	}
+}
+
+func TestNewHMACToken_InvalidConfig(t *testing.T) {
+	_, err := NewHMACTokenIssuer([]byte("short"), time.Hour)
+	assert.EqualError(t, err, "HMAC secret must be at least 32 bytes")
+
+	_, err = NewHMACTokenIssuer(testHMACSecret, 0)
+	assert.EqualError(t, err, "HMAC token ttl must be positive")
+
+	_, err = NewHMACTokenAuthenticator(nil)
+	assert.EqualError(t, err, "at least one HMAC secret is required")
+
+	_, err = NewHMACTokenAuthenticator([][]byte{testHMACSecret, []byte("short")})
+	assert.EqualError(t, err, "HMAC secret must be at least 32 bytes")
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// ErrInvalidToken is returned by TokenVerifier for tokens failing signature or claims validation
+var ErrInvalidToken = errors.New("invalid token")
+
+// tokenClaims follows the Keycloak claim layout so that minted tokens are interchangeable with Keycloak ones
+type tokenClaims struct {
+	jwt.Claims
+	Name              string           `json:"name,omitempty"`
+	PreferredUsername string           `json:"preferred_username,omitempty"`
+	Role              Role             `json:"role,omitempty"`
+	RealmAccess       *realmAccess     `json:"realm_access,omitempty"`
+	Kind              IdentityKind     `json:"kind,omitempty"`
+	ParticipantID     *properties.UUID `json:"participant_id,omitempty"`
+	AgentID           *properties.UUID `json:"agent_id,omitempty"`
+	OrganizationID    *properties.UUID `json:"organization_id,omitempty"`
+	Scope             string           `json:"scope,omitempty"`
+	Groups            []string         `json:"groups,omitempty"`
+}
+
+// tokenClaimNames are the claims of tokenClaims mapped to identity fields
+var tokenClaimNames = []string{
+	"name", "preferred_username", "role", "realm_access", "kind", "participant_id", "agent_id", "organization_id", "scope", "groups",
+}
+
+type realmAccess struct {
+	Roles []string `json:"roles"`
+}
+
+// TokenOption configures TokenIssuer and TokenVerifier
+type TokenOption func(*tokenConfig)
+
+type tokenConfig struct {
+	issuer   string
+	audience []string
+	ttl      time.Duration
+	leeway   time.Duration
+	clock    clock.Clock
+}
+
+// WithTokenIssuer sets the iss claim set by the issuer and required by the verifier
+func WithTokenIssuer(iss string) TokenOption {
+	return func(c *tokenConfig) {
+		c.issuer = iss
+	}
+}
+
+// WithTokenAudience sets the aud claim set by the issuer, the verifier requires any of the audiences
+func WithTokenAudience(aud ...string) TokenOption {
+	return func(c *tokenConfig) {
+		c.audience = aud
+	}
+}
+
+// WithTokenTTL sets the lifetime of the issued tokens, defaults to 5 minutes
+func WithTokenTTL(ttl time.Duration) TokenOption {
+	return func(c *tokenConfig) {
+		c.ttl = ttl
+	}
+}
+
+// WithTokenLeeway sets the clock skew tolerated by the verifier, defaults to none
+func WithTokenLeeway(leeway time.Duration) TokenOption {
+	return func(c *tokenConfig) {
+		c.leeway = leeway
+	}
+}
+
+// WithTokenClock sets the clock used to issue and validate the tokens
+func WithTokenClock(clk clock.Clock) TokenOption {
+	return func(c *tokenConfig) {
+		c.clock = clk
+	}
+}
+
+func newTokenConfig(opts []TokenOption) tokenConfig {
+	cfg := tokenConfig{ttl: 5 * time.Minute, clock: clock.New()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// TokenIssuer mints signed JWTs from identities for short lived internal calls
+type TokenIssuer struct {
+	signer jose.Signer
+	cfg    tokenConfig
+}
+
+// NewTokenIssuer creates an issuer signing with key, e.g. jose.SigningKey{Algorithm: jose.RS256, Key: privateKey}
+func NewTokenIssuer(key jose.SigningKey, opts ...TokenOption) (*TokenIssuer, error) {
+	cfg := newTokenConfig(opts)
+	if cfg.ttl <= 0 {
+		return nil, errors.New("token ttl must be positive")
+	}
+	signer, err := jose.NewSigner(key, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token signer: %w", err)
+	}
+	return &TokenIssuer{signer: signer, cfg: cfg}, nil
+}
+
+// Issue returns a signed token for the identity, the identity attributes and the extra claims are added
+// without the identity and JWT registered claims, so they cannot set or override them
+func (i *TokenIssuer) Issue(identity *Identity, extra map[string]any) (string, error) {
+	if identity == nil {
+		return "", errors.New("cannot issue token: missing identity")
+	}
+	if err := identity.Validate(); err != nil {
+		return "", fmt.Errorf("cannot issue token: %w", err)
+	}
+
+	now := i.cfg.clock.Now()
+	claims := tokenClaims{
+		Claims: jwt.Claims{
+			ID:       properties.NewUUID().String(),
+			Issuer:   i.cfg.issuer,
+			Subject:  identity.ID.String(),
+			Audience: i.cfg.audience,
+			IssuedAt: jwt.NewNumericDate(now),
+			Expiry:   jwt.NewNumericDate(now.Add(i.cfg.ttl)),
+		},
+		Name:              identity.Name,
+		PreferredUsername: identity.Name,
+		Role:              identity.Role,
+		RealmAccess:       &realmAccess{Roles: []string{string(identity.Role)}},
+		Kind:              identity.Kind,
+		ParticipantID:     identity.Scope.ParticipantID,
+		AgentID:           identity.Scope.AgentID,
+		OrganizationID:    identity.Scope.OrganizationID,
+		Scope:             strings.Join(identity.OAuthScopes, " "),
+		Groups:            identity.Groups,
+	}
+
+	builder := jwt.Signed(i.signer)
+	for _, custom := range []map[string]any{identity.Attributes, extra} {
+		if custom = ExtraClaims(custom, tokenClaimNames...); custom != nil {
+			builder = builder.Claims(custom)
+		}
+	}
+	token, err := builder.Claims(claims).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("cannot sign token: %w", err)
+	}
+	return token, nil
+}
+
+// TokenVerifier implements Authenticator for the tokens minted by TokenIssuer
+type TokenVerifier struct {
+	key  any
+	algs []jose.SignatureAlgorithm
+	cfg  tokenConfig
+}
+
+// NewTokenVerifier creates a verifier checking signatures with key, the public key or the shared secret,
+// only the given algorithms are accepted
+func NewTokenVerifier(key any, algs []jose.SignatureAlgorithm, opts ...TokenOption) (*TokenVerifier, error) {
+	if key == nil {
+		return nil, errors.New("token verification key is required")
+	}
+	if len(algs) == 0 {
+		return nil, errors.New("at least one signature algorithm is required")
+	}
+	return &TokenVerifier{key: key, algs: algs, cfg: newTokenConfig(opts)}, nil
+}
+
+// Authenticate verifies the token signature and claims and maps them to an identity
+func (v *TokenVerifier) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	parsed, err := jwt.ParseSigned(token, v.algs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	var claims tokenClaims
+	var raw map[string]any
+	if err := parsed.Claims(v.key, &claims, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	expected := jwt.Expected{Issuer: v.cfg.issuer, AnyAudience: v.cfg.audience, Time: v.cfg.clock.Now()}
+	if err := claims.ValidateWithLeeway(expected, v.cfg.leeway); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	id, err := properties.ParseUUID(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid subject: %w", ErrInvalidToken, err)
+	}
+	role := claims.Role
+	if role == "" && claims.RealmAccess != nil && len(claims.RealmAccess.Roles) > 0 {
+		role = Role(claims.RealmAccess.Roles[0])
+	}
+	if err := role.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+
+	identity := &Identity{
+		ID:   id,
+		Name: name,
+		Role: role,
+		Kind: claims.Kind,
+		Scope: IdentityScope{
+			ParticipantID:  claims.ParticipantID,
+			AgentID:        claims.AgentID,
+			OrganizationID: claims.OrganizationID,
+		},
+		OAuthScopes: ParseOAuthScopes(claims.Scope),
+		Attributes:  ExtraClaims(raw, tokenClaimNames...),
+		Groups:      claims.Groups,
+	}
+	if err := identity.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return identity, nil
+}
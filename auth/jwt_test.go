@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeTokenPayload(t *testing.T, token string) map[string]any {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(data, &payload))
+	return payload
+}
+
+func TestTokenIssuer_IssueAndVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []TokenOption{WithTokenIssuer("fulcrum-core"), WithTokenAudience("fulcrum"), WithTokenTTL(time.Minute), WithTokenClock(clk)}
+
+	issuer, err := NewTokenIssuer(jose.SigningKey{Algorithm: jose.ES256, Key: key}, opts...)
+	require.NoError(t, err)
+	verifier, err := NewTokenVerifier(&key.PublicKey, []jose.SignatureAlgorithm{jose.ES256}, opts...)
+	require.NoError(t, err)
+
+	participantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	identity := &Identity{
+		ID:          properties.NewUUID(),
+		Name:        "agent-1",
+		Role:        RoleAgent,
+		Kind:        KindServiceAccount,
+		Scope:       IdentityScope{ParticipantID: &participantID, AgentID: &agentID},
+		OAuthScopes: []string{"job:read", "job:write"},
+		Attributes:  map[string]any{"region": "eu-west"},
+		Groups:      []string{"/participants/acme"},
+	}
+
+	token, err := issuer.Issue(identity, map[string]any{"tenant": "acme", "role": "admin"})
+	require.NoError(t, err)
+
+	payload := decodeTokenPayload(t, token)
+	assert.Equal(t, "fulcrum-core", payload["iss"])
+	assert.Equal(t, identity.ID.String(), payload["sub"])
+	assert.Equal(t, "agent", payload["role"], "extra claims cannot override identity claims")
+	assert.Equal(t, "acme", payload["tenant"])
+	assert.Equal(t, "eu-west", payload["region"])
+	assert.Equal(t, participantID.String(), payload["participant_id"])
+	assert.Equal(t, agentID.String(), payload["agent_id"])
+	assert.Equal(t, map[string]any{"roles": []any{"agent"}}, payload["realm_access"])
+	assert.Equal(t, "job:read job:write", payload["scope"])
+	assert.Equal(t, []any{"/participants/acme"}, payload["groups"])
+	assert.NotEmpty(t, payload["jti"])
+
+	got, err := verifier.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	expected := identity.Clone()
+	expected.Attributes["tenant"] = "acme"
+	assert.Equal(t, expected, got)
+
+	clk.Advance(time.Minute + time.Second)
+	_, err = verifier.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, err, jwt.ErrExpired)
+}
+
+func TestTokenIssuer_ReservedClaims(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := []TokenOption{WithTokenIssuer("fulcrum-core"), WithTokenClock(clk)}
+
+	issuer, err := NewTokenIssuer(jose.SigningKey{Algorithm: jose.ES256, Key: key}, opts...)
+	require.NoError(t, err)
+	verifier, err := NewTokenVerifier(&key.PublicKey, []jose.SignatureAlgorithm{jose.ES256}, opts...)
+	require.NoError(t, err)
+
+	participantID := properties.NewUUID()
+	other := properties.NewUUID()
+	identity := &Identity{
+		ID:         properties.NewUUID(),
+		Name:       "participant-1",
+		Role:       RoleParticipant,
+		Scope:      IdentityScope{ParticipantID: &participantID},
+		Attributes: map[string]any{"organization_id": other.String(), "kind": string(KindServiceAccount), "region": "eu-west"},
+	}
+	extra := map[string]any{
+		"participant_id": other.String(),
+		"agent_id":       other.String(),
+		"groups":         []string{"/admins"},
+		"scope":          "admin:all",
+		"aud":            "other-service",
+		"nbf":            clk.Now().Add(-time.Hour).Unix(),
+		"exp":            clk.Now().Add(time.Hour).Unix(),
+		"sub":            other.String(),
+		"iss":            "attacker",
+		"tenant":         "acme",
+	}
+
+	token, err := issuer.Issue(identity, extra)
+	require.NoError(t, err)
+
+	payload := decodeTokenPayload(t, token)
+	for _, claim := range []string{"organization_id", "kind", "agent_id", "groups", "scope", "aud", "nbf"} {
+		assert.NotContains(t, payload, claim)
+	}
+	assert.Equal(t, participantID.String(), payload["participant_id"])
+	assert.Equal(t, identity.ID.String(), payload["sub"])
+	assert.Equal(t, "fulcrum-core", payload["iss"])
+	assert.Equal(t, float64(clk.Now().Add(5*time.Minute).Unix()), payload["exp"])
+
+	got, err := verifier.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, IdentityScope{ParticipantID: &participantID}, got.Scope)
+	assert.Empty(t, got.Kind)
+	assert.Empty(t, got.Groups)
+	assert.Empty(t, got.OAuthScopes)
+	assert.Equal(t, map[string]any{"region": "eu-west", "tenant": "acme"}, got.Attributes)
+}
+
+func TestTokenVerifier_Authenticate_Invalid(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	otherSecret := []byte("fedcba9876543210fedcba9876543210")
+	hs256 := []jose.SignatureAlgorithm{jose.HS256}
+	admin := &Identity{ID: properties.NewUUID(), Name: "admin", Role: RoleAdmin}
+
+	issue := func(secret []byte, identity *Identity, opts ...TokenOption) string {
+		issuer, err := NewTokenIssuer(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, opts...)
+		require.NoError(t, err)
+		token, err := issuer.Issue(identity, nil)
+		require.NoError(t, err)
+		return token
+	}
+	sign := func(claims any) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, nil)
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(claims).Serialize()
+		require.NoError(t, err)
+		return token
+	}
+	exp := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name          string
+		token         string
+		opts          []TokenOption
+		errorContains string
+	}{
+		{name: "Malformed", token: "not-a-jwt", errorContains: "invalid token"},
+		{name: "Wrong key", token: issue(otherSecret, admin), errorContains: "invalid token"},
+		{name: "Wrong issuer", token: issue(secret, admin, WithTokenIssuer("other")), opts: []TokenOption{WithTokenIssuer("fulcrum-core")}, errorContains: "issuer"},
+		{name: "Wrong audience", token: issue(secret, admin, WithTokenAudience("other")), opts: []TokenOption{WithTokenAudience("fulcrum")}, errorContains: "audience"},
+		{name: "Missing exp", token: sign(map[string]any{"sub": admin.ID.String(), "role": "admin"}), errorContains: "missing exp claim"},
+		{name: "Invalid subject", token: sign(map[string]any{"sub": "alice", "role": "admin", "exp": exp}), errorContains: "invalid subject"},
+		{name: "Invalid role", token: sign(map[string]any{"sub": admin.ID.String(), "role": "superuser", "exp": exp}), errorContains: "invalid auth role"},
+		{name: "Anonymous role", token: sign(map[string]any{"sub": admin.ID.String(), "role": "anonymous", "exp": exp}), errorContains: "reserved to unauthenticated callers"},
+		{name: "Invalid scope", token: sign(map[string]any{"sub": admin.ID.String(), "role": "participant", "exp": exp}), errorContains: "participant role requires participant id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewTokenVerifier(secret, hs256, tt.opts...)
+			require.NoError(t, err)
+
+			identity, err := verifier.Authenticate(context.Background(), tt.token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+			assert.ErrorContains(t, err, tt.errorContains)
+			assert.Nil(t, identity)
+		})
+	}
+}
+
+func TestTokenVerifier_RealmRoleFallback(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, nil)
+	require.NoError(t, err)
+	id := properties.NewUUID()
+	token, err := jwt.Signed(signer).Claims(map[string]any{
+		"sub":                id.String(),
+		"preferred_username": "ops",
+		"realm_access":       map[string]any{"roles": []string{"admin"}},
+		"exp":                jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).Serialize()
+	require.NoError(t, err)
+
+	verifier, err := NewTokenVerifier(secret, []jose.SignatureAlgorithm{jose.HS256})
+	require.NoError(t, err)
+	identity, err := verifier.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{ID: id, Name: "ops", Role: RoleAdmin}, identity)
+}
+
+func TestNewTokenIssuerAndVerifier_InvalidConfig(t *testing.T) {
+	_, err := NewTokenIssuer(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, WithTokenTTL(0))
+	assert.EqualError(t, err, "token ttl must be positive")
+
+	_, err = NewTokenIssuer(jose.SigningKey{Algorithm: jose.RS256, Key: "not a key"})
+	assert.ErrorContains(t, err, "cannot create token signer")
+
+	issuer, err := NewTokenIssuer(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")})
+	require.NoError(t, err)
+	_, err = issuer.Issue(nil, nil)
+	assert.EqualError(t, err, "cannot issue token: missing identity")
+
+	_, err = NewTokenVerifier(nil, []jose.SignatureAlgorithm{jose.HS256})
+	assert.EqualError(t, err, "token verification key is required")
+
+	_, err = NewTokenVerifier([]byte("secret"), nil)
+	assert.EqualError(t, err, "at least one signature algorithm is required")
+}
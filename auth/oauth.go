@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+// ParseOAuthScopes splits a space separated OAuth scope claim, an empty claim returns nil
+func ParseOAuthScopes(claim string) []string {
+	scopes := strings.Fields(claim)
+	if len(scopes) == 0 {
+		return nil
+	}
+	return scopes
+}
+
+// HasScope reports whether the identity token was granted the OAuth scope
+func (m *Identity) HasScope(scope string) bool {
+	return slices.Contains(m.OAuthScopes, scope)
+}
+
+// MissingScopes returns the scopes not granted to the identity
+func (m *Identity) MissingScopes(scopes ...string) []string {
+	var missing []string
+	for _, s := range scopes {
+		if !m.HasScope(s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// RequireScope requires the identity to have all the OAuth scopes, e.g. RequireScope("service:write")
+func RequireScope(scopes ...string) Condition {
+	return func(identity *Identity, attrs map[string]any) bool {
+		return identity != nil && len(identity.MissingScopes(scopes...)) == 0
+	}
+}
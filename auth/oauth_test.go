@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOAuthScopes(t *testing.T) {
+	assert.Equal(t, []string{"openid", "service:read", "service:write"}, ParseOAuthScopes(" openid  service:read\tservice:write "))
+	assert.Nil(t, ParseOAuthScopes(""))
+}
+
+func TestIdentity_Scopes(t *testing.T) {
+	identity := &Identity{Role: RoleAdmin, OAuthScopes: []string{"service:read", "service:write"}}
+
+	assert.True(t, identity.HasScope("service:read"))
+	assert.False(t, identity.HasScope("service:delete"))
+	assert.Empty(t, identity.MissingScopes("service:read", "service:write"))
+	assert.Equal(t, []string{"service:delete", "agent:read"}, identity.MissingScopes("service:write", "service:delete", "agent:read"))
+}
+
+func TestRequireScope(t *testing.T) {
+	identity := &Identity{Role: RoleAdmin, OAuthScopes: []string{"service:write"}}
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		scopes   []string
+		expected bool
+	}{
+		{name: "Granted", identity: identity, scopes: []string{"service:write"}, expected: true},
+		{name: "Missing", identity: identity, scopes: []string{"service:write", "service:delete"}, expected: false},
+		{name: "No scopes required", identity: &Identity{}, expected: true},
+		{name: "Nil identity", scopes: []string{"service:write"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RequireScope(tt.scopes...)(tt.identity, nil))
+		})
+	}
+}
+
+func TestRuleBasedAuthorizer_Authorize_RequireScopeCondition(t *testing.T) {
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{
+		{Roles: []Role{RoleAdmin}, Action: "update", Object: "service", Condition: RequireScope("service:write")},
+	})
+
+	assert.NoError(t, authorizer.Authorize(&Identity{Role: RoleAdmin, OAuthScopes: []string{"service:write"}}, "update", "service", nil))
+	assert.Error(t, authorizer.Authorize(&Identity{Role: RoleAdmin, OAuthScopes: []string{"service:read"}}, "update", "service", nil))
+}
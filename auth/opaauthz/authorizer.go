@@ -0,0 +1,188 @@
+package opaauthz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+)
+
+// Input is the document sent to OPA as input
+type Input struct {
+	Identity IdentityInput   `json:"identity"`
+	Action   auth.Action     `json:"action"`
+	Object   auth.ObjectType `json:"object"`
+	// Scope is the JSON encoding of the object scope, nil when no scope is provided
+	Scope any `json:"scope"`
+	// ScopeMatches is the result of ObjectScope.Matches, true when no scope is provided
+	// The authorizer denies mismatching scopes before querying OPA, so policies always see true
+	ScopeMatches bool `json:"scopeMatches"`
+}
+
+// IdentityInput is the identity as exposed to policies
+type IdentityInput struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Role           auth.Role         `json:"role"`
+	Kind           auth.IdentityKind `json:"kind,omitempty"`
+	ParticipantID  string            `json:"participantId,omitempty"`
+	AgentID        string            `json:"agentId,omitempty"`
+	OrganizationID string            `json:"organizationId,omitempty"`
+	// OAuthScopes are the scopes granted to the token
+	OAuthScopes []string `json:"oauthScopes,omitempty"`
+	// Attributes are the extra claims of the token
+	Attributes map[string]any `json:"attributes,omitempty"`
+	// Groups are the identity provider groups
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Authorizer implements auth.Authorizer querying an OPA server through its data API,
+// the decision document can be a boolean or an object with allow and reason fields
+type Authorizer struct {
+	url     string
+	client  *http.Client
+	timeout time.Duration
+}
+
+// Option configures the Authorizer
+type Option func(*Authorizer)
+
+// WithHTTPClient sets the HTTP client used to query OPA
+func WithHTTPClient(c *http.Client) Option {
+	return func(a *Authorizer) {
+		a.client = c
+	}
+}
+
+// WithTimeout sets the timeout of each decision query
+func WithTimeout(d time.Duration) Option {
+	return func(a *Authorizer) {
+		a.timeout = d
+	}
+}
+
+// New creates an authorizer querying the decision at path (e.g. "fulcrum/authz/allow") on the OPA server at baseURL
+func New(baseURL, path string, opts ...Option) *Authorizer {
+	a := &Authorizer{
+		url:     strings.TrimRight(baseURL, "/") + "/v1/data/" + strings.Trim(path, "/"),
+		client:  http.DefaultClient,
+		timeout: 2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Authorize queries OPA for the decision
+func (a *Authorizer) Authorize(identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	return a.AuthorizeCtx(context.Background(), identity, action, object, objectScope)
+}
+
+// AuthorizeCtx queries OPA for the decision within the context deadline and the configured timeout
+// Object scopes not matching the identity are denied without querying OPA
+func (a *Authorizer) AuthorizeCtx(ctx context.Context, identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	if identity == nil {
+		return auth.NewDeniedError(auth.ReasonMissingIdentity, action, object, "missing identity")
+	}
+	if objectScope != nil && !objectScope.Matches(identity) {
+		return auth.NewDeniedError(auth.ReasonScopeMismatch, action, object, "object context does not match identity")
+	}
+
+	body, err := json.Marshal(map[string]any{"input": NewInput(identity, action, object, objectScope)})
+	if err != nil {
+		return fmt.Errorf("cannot encode OPA input: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create OPA request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OPA request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OPA returned status %d", resp.StatusCode)
+	}
+
+	var decision struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return fmt.Errorf("cannot decode OPA response: %w", err)
+	}
+
+	allowed, reason, err := parseResult(decision.Result)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		if reason == "" {
+			reason = fmt.Sprintf("policy denies action '%s' on object '%s'", action, object)
+		}
+		return auth.NewDeniedError(auth.ReasonPolicy, action, object, "%s", reason)
+	}
+	return nil
+}
+
+// NewInput builds the OPA input for an authorization check
+func NewInput(identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) Input {
+	in := Input{
+		Identity: IdentityInput{
+			ID:          identity.ID.String(),
+			Name:        identity.Name,
+			Role:        identity.Role,
+			Kind:        identity.Kind,
+			OAuthScopes: identity.OAuthScopes,
+			Attributes:  identity.Attributes,
+			Groups:      identity.Groups,
+		},
+		Action:       action,
+		Object:       object,
+		ScopeMatches: true,
+	}
+	if identity.Scope.ParticipantID != nil {
+		in.Identity.ParticipantID = identity.Scope.ParticipantID.String()
+	}
+	if identity.Scope.AgentID != nil {
+		in.Identity.AgentID = identity.Scope.AgentID.String()
+	}
+	if identity.Scope.OrganizationID != nil {
+		in.Identity.OrganizationID = identity.Scope.OrganizationID.String()
+	}
+	if objectScope != nil {
+		in.Scope = objectScope
+		in.ScopeMatches = objectScope.Matches(identity)
+	}
+	return in
+}
+
+func parseResult(raw json.RawMessage) (bool, string, error) {
+	if len(raw) == 0 {
+		// undefined decision
+		return false, "", nil
+	}
+	var allowed bool
+	if err := json.Unmarshal(raw, &allowed); err == nil {
+		return allowed, "", nil
+	}
+	var obj struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return false, "", fmt.Errorf("unexpected OPA decision: %s", raw)
+	}
+	return obj.Allow, obj.Reason, nil
+}
@@ -0,0 +1,174 @@
+package opaauthz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer_Authorize(t *testing.T) {
+	participantID := properties.NewUUID()
+	identity := &auth.Identity{ID: properties.NewUUID(), Name: "alice", Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID}}
+
+	tests := []struct {
+		name          string
+		status        int
+		response      string
+		scope         auth.ObjectScope
+		expectErr     bool
+		errorContains string
+	}{
+		{
+			name:     "Boolean allow",
+			status:   http.StatusOK,
+			response: `{"result": true}`,
+		},
+		{
+			name:          "Object deny with reason",
+			status:        http.StatusOK,
+			response:      `{"result": {"allow": false, "reason": "service is not in draft"}}`,
+			expectErr:     true,
+			errorContains: "service is not in draft",
+		},
+		{
+			name:          "Undefined decision",
+			status:        http.StatusOK,
+			response:      `{}`,
+			expectErr:     true,
+			errorContains: "policy denies",
+		},
+		{
+			name:     "Scope passed to policy",
+			status:   http.StatusOK,
+			response: `{"result": true}`,
+			scope:    &auth.DefaultObjectScope{ParticipantID: &participantID},
+		},
+		{
+			name:          "Server error",
+			status:        http.StatusInternalServerError,
+			response:      `{}`,
+			expectErr:     true,
+			errorContains: "status 500",
+		},
+		{
+			name:          "Unexpected decision",
+			status:        http.StatusOK,
+			response:      `{"result": "yes"}`,
+			expectErr:     true,
+			errorContains: "unexpected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received struct {
+				Input Input `json:"input"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/data/fulcrum/authz/allow", r.URL.Path)
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			a := New(srv.URL+"/", "/fulcrum/authz/allow")
+			err := a.Authorize(identity, "update", "service", tt.scope)
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, identity.ID.String(), received.Input.Identity.ID)
+			assert.Equal(t, participantID.String(), received.Input.Identity.ParticipantID)
+			assert.Equal(t, auth.Action("update"), received.Input.Action)
+			assert.Equal(t, auth.ObjectType("service"), received.Input.Object)
+			assert.True(t, received.Input.ScopeMatches)
+			if tt.scope != nil {
+				assert.NotNil(t, received.Input.Scope)
+			}
+		})
+	}
+}
+
+func TestNewInput_Identity(t *testing.T) {
+	participantID := properties.NewUUID()
+	organizationID := properties.NewUUID()
+	identity := &auth.Identity{
+		ID:          properties.NewUUID(),
+		Name:        "alice",
+		Role:        auth.RoleParticipant,
+		Kind:        auth.KindUser,
+		Scope:       auth.IdentityScope{ParticipantID: &participantID, OrganizationID: &organizationID},
+		OAuthScopes: []string{"service:read"},
+		Attributes:  map[string]any{"region": "eu-west"},
+		Groups:      []string{"/participants/acme"},
+	}
+
+	in := NewInput(identity, "read", "service", nil)
+	assert.Equal(t, IdentityInput{
+		ID:             identity.ID.String(),
+		Name:           "alice",
+		Role:           auth.RoleParticipant,
+		Kind:           auth.KindUser,
+		ParticipantID:  participantID.String(),
+		OrganizationID: organizationID.String(),
+		OAuthScopes:    []string{"service:read"},
+		Attributes:     map[string]any{"region": "eu-west"},
+		Groups:         []string{"/participants/acme"},
+	}, in.Identity)
+}
+
+func TestNewInput_ScopeMismatch(t *testing.T) {
+	participantID := properties.NewUUID()
+	other := properties.NewUUID()
+	identity := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID}}
+
+	in := NewInput(identity, "read", "service", &auth.DefaultObjectScope{ParticipantID: &other})
+	assert.False(t, in.ScopeMatches)
+}
+
+func TestAuthorizer_ScopeMismatch(t *testing.T) {
+	queried := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queried = true
+		w.Write([]byte(`{"result": true}`))
+	}))
+	defer srv.Close()
+
+	participantID := properties.NewUUID()
+	other := properties.NewUUID()
+	identity := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID}}
+
+	err := New(srv.URL, "fulcrum/authz/allow").Authorize(identity, "read", "service", &auth.DefaultObjectScope{ParticipantID: &other})
+	var denied *auth.DeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Equal(t, auth.ReasonScopeMismatch, denied.Reason)
+	assert.False(t, queried, "mismatching scopes are denied before the policy")
+}
+
+func TestAuthorizer_AuthorizeCtx_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	a := New(srv.URL, "fulcrum/authz/allow", WithTimeout(time.Minute))
+	err := a.AuthorizeCtx(ctx, &auth.Identity{Role: auth.RoleAdmin}, "read", "service", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
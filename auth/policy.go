@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is a declarative permission matrix mapping roles to object types to granted actions
+type Policy struct {
+	Roles map[Role]map[ObjectType]PolicyGrant `json:"roles" yaml:"roles"`
+}
+
+// PolicyGrant lists the actions a role can perform on an object type
+type PolicyGrant struct {
+	Actions []Action `json:"actions" yaml:"actions"`
+	// RequireScope grants the actions only when the object scope is provided and matches
+	RequireScope bool `json:"requireScope" yaml:"requireScope"`
+	// When grants the actions only when the object attributes have the given values,
+	// a list value means any of the listed values
+	When map[string]any `json:"when,omitempty" yaml:"when,omitempty"`
+	// Kinds grants the actions only to identities of the given kinds, e.g. service_account
+	Kinds []IdentityKind `json:"kinds,omitempty" yaml:"kinds,omitempty"`
+	// Scopes grants the actions only to tokens having all the OAuth scopes
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+func (g PolicyGrant) condition() Condition {
+	if len(g.When) == 0 && len(g.Kinds) == 0 && len(g.Scopes) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(g.When))
+	for k := range g.When {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	conditions := make([]Condition, 0, len(keys)+2)
+	if len(g.Kinds) > 0 {
+		conditions = append(conditions, IdentityKindIn(g.Kinds...))
+	}
+	if len(g.Scopes) > 0 {
+		conditions = append(conditions, RequireScope(g.Scopes...))
+	}
+	for _, k := range keys {
+		if values, ok := g.When[k].([]any); ok {
+			conditions = append(conditions, AttributeIn(k, values...))
+		} else {
+			conditions = append(conditions, AttributeEquals(k, g.When[k]))
+		}
+	}
+	return AllConditions(conditions...)
+}
+
+// ParsePolicy parses a JSON or YAML policy document and validates it
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("cannot parse JSON policy: %w", err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(trimmed))
+		dec.KnownFields(true)
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("cannot parse YAML policy: %w", err)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LoadPolicy reads and parses a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read policy file: %w", err)
+	}
+	p, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return p, nil
+}
+
+// Validate ensures roles are valid and every grant has at least one action,
+// actions and object types are checked against the catalogs when they are populated
+func (p *Policy) Validate() error {
+	if len(p.Roles) == 0 {
+		return errors.New("policy has no roles")
+	}
+	checkActions, checkObjects := catalogEnforced()
+	var errs []error
+	for role, objects := range p.Roles {
+		if err := role.validateGrantee(); err != nil {
+			errs = append(errs, err)
+		}
+		for object, grant := range objects {
+			if strings.TrimSpace(string(object)) == "" {
+				errs = append(errs, fmt.Errorf("role %s has an empty object type", role))
+			}
+			if len(grant.Actions) == 0 {
+				errs = append(errs, fmt.Errorf("role %s grants no actions on %s", role, object))
+			}
+			for _, action := range grant.Actions {
+				if strings.TrimSpace(string(action)) == "" {
+					errs = append(errs, fmt.Errorf("role %s grants an empty action on %s", role, object))
+				}
+			}
+			if checkObjects && strings.TrimSpace(string(object)) != "" {
+				if err := object.Validate(); err != nil {
+					errs = append(errs, fmt.Errorf("role %s: %w", role, err))
+				}
+			}
+			for _, action := range grant.Actions {
+				if checkActions && strings.TrimSpace(string(action)) != "" {
+					if err := action.Validate(); err != nil {
+						errs = append(errs, fmt.Errorf("role %s on %s: %w", role, object, err))
+					}
+				}
+			}
+			for _, kind := range grant.Kinds {
+				if kind == "" {
+					errs = append(errs, fmt.Errorf("role %s has an empty identity kind on %s", role, object))
+				} else if err := kind.Validate(); err != nil {
+					errs = append(errs, fmt.Errorf("role %s on %s: %w", role, object, err))
+				}
+			}
+			for _, scope := range grant.Scopes {
+				if strings.TrimSpace(scope) == "" || strings.ContainsAny(scope, " \t") {
+					errs = append(errs, fmt.Errorf("role %s has an invalid OAuth scope %q on %s", role, scope, object))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rules flattens the policy into authorization rules sorted by role, object and action
+func (p *Policy) Rules() []AuthorizationRule {
+	var rules []AuthorizationRule
+	for role, objects := range p.Roles {
+		for object, grant := range objects {
+			for _, action := range grant.Actions {
+				rules = append(rules, AuthorizationRule{
+					Roles:        []Role{role},
+					Action:       action,
+					Object:       object,
+					RequireScope: grant.RequireScope,
+					Condition:    grant.condition(),
+				})
+			}
+		}
+	}
+	slices.SortFunc(rules, func(a, b AuthorizationRule) int {
+		return strings.Compare(
+			string(a.Roles[0])+"\x00"+string(a.Object)+"\x00"+string(a.Action),
+			string(b.Roles[0])+"\x00"+string(b.Object)+"\x00"+string(b.Action),
+		)
+	})
+	return rules
+}
+
+// NewPolicyAuthorizer creates a RuleBasedAuthorizer from the policy
+func NewPolicyAuthorizer(p *Policy) (*RuleBasedAuthorizer, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return NewRuleBasedAuthorizer(p.Rules()), nil
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testYAMLPolicy = `
+roles:
+  admin:
+    service:
+      actions: [create, read, delete]
+  participant:
+    service:
+      actions: [read]
+      requireScope: true
+`
+
+const testJSONPolicy = `{
+  "roles": {
+    "admin": {"service": {"actions": ["create", "read", "delete"]}},
+    "participant": {"service": {"actions": ["read"], "requireScope": true}}
+  }
+}`
+
+func TestParsePolicy(t *testing.T) {
+	expected := []AuthorizationRule{
+		{Roles: []Role{RoleAdmin}, Action: "create", Object: "service"},
+		{Roles: []Role{RoleAdmin}, Action: "delete", Object: "service"},
+		{Roles: []Role{RoleAdmin}, Action: "read", Object: "service"},
+		{Roles: []Role{RoleParticipant}, Action: "read", Object: "service", RequireScope: true},
+	}
+
+	tests := []struct {
+		name          string
+		document      string
+		errorContains string
+	}{
+		{name: "YAML", document: testYAMLPolicy},
+		{name: "JSON", document: testJSONPolicy},
+		{name: "Empty", document: "", errorContains: "cannot parse"},
+		{name: "No roles", document: "roles: {}", errorContains: "no roles"},
+		{name: "Unknown role", document: "roles:\n  superuser:\n    service:\n      actions: [read]", errorContains: "invalid auth role"},
+		{name: "No actions", document: "roles:\n  admin:\n    service:\n      actions: []", errorContains: "grants no actions"},
+		{name: "Unknown kind", document: "roles:\n  admin:\n    service:\n      actions: [read]\n      kinds: [robot]", errorContains: "invalid identity kind: robot"},
+		{name: "Invalid scope", document: "roles:\n  admin:\n    service:\n      actions: [read]\n      scopes: ['service read']", errorContains: "invalid OAuth scope"},
+		{name: "Unknown field", document: `{"roles": {"admin": {"service": {"action": ["read"]}}}}`, errorContains: "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParsePolicy([]byte(tt.document))
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expected, policy.Rules())
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testYAMLPolicy), 0o600))
+
+	policy, err := LoadPolicy(path)
+	require.NoError(t, err)
+	assert.Len(t, policy.Roles, 2)
+
+	_, err = LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestNewPolicyAuthorizer(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testYAMLPolicy))
+	require.NoError(t, err)
+	authorizer, err := NewPolicyAuthorizer(policy)
+	require.NoError(t, err)
+
+	participantID := properties.NewUUID()
+	participant := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	admin := &Identity{Role: RoleAdmin}
+
+	assert.NoError(t, authorizer.Authorize(admin, "delete", "service", nil))
+	assert.Error(t, authorizer.Authorize(participant, "delete", "service", nil))
+	assert.Error(t, authorizer.Authorize(participant, "read", "service", nil), "scope is required")
+	assert.NoError(t, authorizer.Authorize(participant, "read", "service", &DefaultObjectScope{ParticipantID: &participantID}))
+
+	_, err = NewPolicyAuthorizer(&Policy{})
+	assert.Error(t, err)
+}
+
+func TestNewPolicyAuthorizer_When(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+roles:
+  participant:
+    service:
+      actions: [update]
+      when:
+        state: [draft, new]
+        locked: false
+`))
+	require.NoError(t, err)
+	authorizer, err := NewPolicyAuthorizer(policy)
+	require.NoError(t, err)
+	participant := &Identity{Role: RoleParticipant}
+
+	tests := []struct {
+		name      string
+		attrs     map[string]any
+		expectErr bool
+	}{
+		{name: "Draft", attrs: map[string]any{"state": "draft", "locked": false}},
+		{name: "New", attrs: map[string]any{"state": "new", "locked": false}},
+		{name: "Active", attrs: map[string]any{"state": "active", "locked": false}, expectErr: true},
+		{name: "Locked", attrs: map[string]any{"state": "draft", "locked": true}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(participant, "update", "service", WithAttributes(nil, tt.attrs))
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewPolicyAuthorizer_Kinds(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+roles:
+  admin:
+    service:
+      actions: [read]
+    secret:
+      actions: [rotate]
+      kinds: [service_account]
+`))
+	require.NoError(t, err)
+	authorizer, err := NewPolicyAuthorizer(policy)
+	require.NoError(t, err)
+
+	human := &Identity{Role: RoleAdmin}
+	automation := &Identity{Role: RoleAdmin, Kind: KindServiceAccount}
+
+	assert.NoError(t, authorizer.Authorize(human, "read", "service", nil))
+	assert.NoError(t, authorizer.Authorize(automation, "read", "service", nil))
+	assert.Error(t, authorizer.Authorize(human, "rotate", "secret", nil))
+	assert.NoError(t, authorizer.Authorize(automation, "rotate", "secret", nil))
+}
+
+func TestNewPolicyAuthorizer_Scopes(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{"roles": {"admin": {"service": {"actions": ["update"], "scopes": ["service:write"]}}}}`))
+	require.NoError(t, err)
+	authorizer, err := NewPolicyAuthorizer(policy)
+	require.NoError(t, err)
+
+	assert.NoError(t, authorizer.Authorize(&Identity{Role: RoleAdmin, OAuthScopes: []string{"openid", "service:write"}}, "update", "service", nil))
+	assert.Error(t, authorizer.Authorize(&Identity{Role: RoleAdmin, OAuthScopes: []string{"openid"}}, "update", "service", nil))
+}
+
+func TestNewPolicyAuthorizer_Wildcards(t *testing.T) {
+	resetCatalog(t)
+	RegisterActions("read", "delete")
+	RegisterObjectTypes("service", "agent")
+
+	policy, err := ParsePolicy([]byte(`
+roles:
+  admin:
+    "*":
+      actions: ["*"]
+  participant:
+    "*":
+      actions: [read]
+  agent:
+    service:
+      actions: ["*"]
+`))
+	require.NoError(t, err)
+	authorizer, err := NewPolicyAuthorizer(policy)
+	require.NoError(t, err)
+
+	participantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	admin := &Identity{Role: RoleAdmin}
+	participant := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	agent := &Identity{Role: RoleAgent, Scope: IdentityScope{ParticipantID: &participantID, AgentID: &agentID}}
+
+	assert.NoError(t, authorizer.Authorize(admin, "delete", "agent", nil))
+	assert.NoError(t, authorizer.Authorize(participant, "read", "agent", nil))
+	assert.Error(t, authorizer.Authorize(participant, "delete", "agent", nil))
+	assert.NoError(t, authorizer.Authorize(agent, "delete", "service", nil))
+	assert.Error(t, authorizer.Authorize(agent, "read", "agent", nil))
+}
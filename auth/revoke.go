@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+)
+
+// ErrTokenRevoked is returned when an authenticated token has been revoked
+var ErrTokenRevoked = errors.New("token revoked")
+
+// Revoker is a deny-list of tokens identified by their jti or hash
+type Revoker interface {
+	// Revoke denies the key until the given time, usually the natural expiration of the token
+	Revoke(ctx context.Context, key string, until time.Time) error
+	// IsRevoked reports whether the key is currently denied
+	IsRevoked(ctx context.Context, key string) (bool, error)
+}
+
+// RevocationKeyFunc returns the key of a token in the deny-list
+type RevocationKeyFunc func(token string) string
+
+// RevocationKey returns the jti claim of JWT tokens and the token hash for other tokens
+// The token is not verified, it must be called on tokens already authenticated
+func RevocationKey(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			var claims struct {
+				JTI string `json:"jti"`
+			}
+			if json.Unmarshal(payload, &claims) == nil && claims.JTI != "" {
+				return "jti:" + claims.JTI
+			}
+		}
+	}
+	return "sha256:" + HashToken(token)
+}
+
+// RevokingAuthenticator decorates an Authenticator rejecting revoked tokens
+type RevokingAuthenticator struct {
+	inner   Authenticator
+	revoker Revoker
+	key     RevocationKeyFunc
+}
+
+// NewRevokingAuthenticator creates an authenticator consulting the revoker after inner succeeds,
+// a nil key function uses RevocationKey
+func NewRevokingAuthenticator(inner Authenticator, revoker Revoker, key RevocationKeyFunc) *RevokingAuthenticator {
+	if key == nil {
+		key = RevocationKey
+	}
+	return &RevokingAuthenticator{inner: inner, revoker: revoker, key: key}
+}
+
+// Authenticate authenticates with the inner authenticator and then checks the deny-list,
+// revoker failures deny the authentication
+func (a *RevokingAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	identity, err := a.inner.Authenticate(ctx, token)
+	if err != nil || identity == nil {
+		return identity, err
+	}
+	revoked, err := a.revoker.IsRevoked(ctx, a.key(token))
+	if err != nil {
+		return nil, fmt.Errorf("cannot check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return identity, nil
+}
+
+type revokerConfig struct {
+	clock clock.Clock
+}
+
+// RevokerOption configures MemoryRevoker and RedisRevoker
+type RevokerOption func(*revokerConfig)
+
+// WithRevokerClock sets the clock used to expire the revocations, defaults to the real clock
+func WithRevokerClock(clk clock.Clock) RevokerOption {
+	return func(c *revokerConfig) {
+		c.clock = clk
+	}
+}
+
+func newRevokerConfig(opts []RevokerOption) revokerConfig {
+	cfg := revokerConfig{clock: clock.New()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// MemoryRevoker is an in-process Revoker, expired entries are dropped lazily
+type MemoryRevoker struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	revoked map[string]time.Time
+}
+
+// NewMemoryRevoker creates an in-memory revoker
+func NewMemoryRevoker(opts ...RevokerOption) *MemoryRevoker {
+	cfg := newRevokerConfig(opts)
+	return &MemoryRevoker{clock: cfg.clock, revoked: make(map[string]time.Time)}
+}
+
+// Revoke denies the key until the given time
+func (r *MemoryRevoker) Revoke(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	for k, exp := range r.revoked {
+		if !now.Before(exp) {
+			delete(r.revoked, k)
+		}
+	}
+	if now.Before(until) {
+		r.revoked[key] = until
+	}
+	return nil
+}
+
+// IsRevoked reports whether the key is denied
+func (r *MemoryRevoker) IsRevoked(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.revoked[key]
+	if !ok {
+		return false, nil
+	}
+	if !r.clock.Now().Before(until) {
+		delete(r.revoked, key)
+		return false, nil
+	}
+	return true, nil
+}
+
+// RedisClient is the subset of a Redis client used by RedisRevoker
+type RedisClient interface {
+	// SetEX sets the key with the given expiration
+	SetEX(ctx context.Context, key, value string, expiration time.Duration) error
+	// Exists reports whether the key exists
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// RedisRevoker is a Revoker shared between instances, entries expire with the Redis key TTL
+type RedisRevoker struct {
+	client RedisClient
+	prefix string
+	clock  clock.Clock
+}
+
+// NewRedisRevoker creates a revoker storing the keys under prefix
+func NewRedisRevoker(client RedisClient, prefix string, opts ...RevokerOption) *RedisRevoker {
+	cfg := newRevokerConfig(opts)
+	return &RedisRevoker{client: client, prefix: prefix, clock: cfg.clock}
+}
+
+// Revoke denies the key until the given time
+func (r *RedisRevoker) Revoke(ctx context.Context, key string, until time.Time) error {
+	ttl := until.Sub(r.clock.Now())
+	if ttl <= 0 {
+		return nil
+	}
+	if err := r.client.SetEX(ctx, r.prefix+key, "1", ttl); err != nil {
+		return fmt.Errorf("cannot revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether the key is denied
+func (r *RedisRevoker) IsRevoked(ctx context.Context, key string) (bool, error) {
+	return r.client.Exists(ctx, r.prefix+key)
+}
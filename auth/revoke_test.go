@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationKey(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{name: "JWT with jti", token: jwt(`{"jti":"abc-123","sub":"u"}`), expected: "jti:abc-123"},
+		{name: "JWT without jti", token: jwt(`{"sub":"u"}`), expected: "sha256:" + HashToken(jwt(`{"sub":"u"}`))},
+		{name: "Opaque token", token: "api-token", expected: "sha256:" + HashToken("api-token")},
+		{name: "Malformed payload", token: "a.!!.c", expected: "sha256:" + HashToken("a.!!.c")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RevocationKey(tt.token))
+		})
+	}
+}
+
+func TestMemoryRevoker(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Now())
+	revoker := NewMemoryRevoker(WithRevokerClock(clk))
+
+	require.NoError(t, revoker.Revoke(ctx, "key", clk.Now().Add(time.Minute)))
+	require.NoError(t, revoker.Revoke(ctx, "expired", clk.Now().Add(-time.Minute)))
+
+	revoked, err := revoker.IsRevoked(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = revoker.IsRevoked(ctx, "expired")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	clk.Advance(time.Minute)
+	revoked, err = revoker.IsRevoked(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Empty(t, revoker.revoked)
+}
+
+type fakeRedis struct {
+	keys map[string]time.Duration
+	err  error
+}
+
+func (f *fakeRedis) SetEX(ctx context.Context, key, value string, expiration time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.keys[key] = expiration
+	return nil
+}
+
+func (f *fakeRedis) Exists(ctx context.Context, key string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	_, ok := f.keys[key]
+	return ok, nil
+}
+
+func TestRedisRevoker(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Now())
+	redis := &fakeRedis{keys: make(map[string]time.Duration)}
+	revoker := NewRedisRevoker(redis, "revoked:", WithRevokerClock(clk))
+
+	require.NoError(t, revoker.Revoke(ctx, "key", clk.Now().Add(time.Hour)))
+	require.NoError(t, revoker.Revoke(ctx, "expired", clk.Now()))
+	assert.Equal(t, map[string]time.Duration{"revoked:key": time.Hour}, redis.keys)
+
+	revoked, err := revoker.IsRevoked(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	redis.err = errors.New("connection refused")
+	assert.ErrorContains(t, revoker.Revoke(ctx, "other", clk.Now().Add(time.Hour)), "cannot revoke token: connection refused")
+}
+
+type failingRevoker struct{}
+
+func (failingRevoker) Revoke(ctx context.Context, key string, until time.Time) error { return nil }
+func (failingRevoker) IsRevoked(ctx context.Context, key string) (bool, error) {
+	return false, errors.New("redis down")
+}
+
+func TestRevokingAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	identity := &Identity{Name: "user", Role: RoleAdmin}
+	memory := NewMemoryRevoker()
+	require.NoError(t, memory.Revoke(ctx, RevocationKey("revoked"), time.Now().Add(time.Hour)))
+
+	tests := []struct {
+		name          string
+		inner         *mockAuthenticator
+		revoker       Revoker
+		token         string
+		expected      *Identity
+		errorContains string
+	}{
+		{name: "Not revoked", inner: &mockAuthenticator{identity: identity}, revoker: memory, token: "valid", expected: identity},
+		{name: "Revoked", inner: &mockAuthenticator{identity: identity}, revoker: memory, token: "revoked", errorContains: ErrTokenRevoked.Error()},
+		{name: "Inner error", inner: &mockAuthenticator{err: errors.New("bad signature")}, revoker: memory, token: "revoked", errorContains: "bad signature"},
+		{name: "Inner nil identity", inner: &mockAuthenticator{}, revoker: failingRevoker{}, token: "valid"},
+		{name: "Revoker failure denies", inner: &mockAuthenticator{identity: identity}, revoker: failingRevoker{}, token: "valid", errorContains: "cannot check token revocation: redis down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRevokingAuthenticator(tt.inner, tt.revoker, nil).Authenticate(ctx, tt.token)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
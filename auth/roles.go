@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// RoleHierarchy maps a role to the roles it directly implies
+type RoleHierarchy map[Role][]Role
+
+// DefaultRoleHierarchy is the Fulcrum hierarchy where admin implies participant that implies agent
+var DefaultRoleHierarchy = RoleHierarchy{
+	RoleAdmin:       {RoleParticipant},
+	RoleParticipant: {RoleAgent},
+}
+
+var (
+	rolesMu         sync.RWMutex
+	roleHierarchy   RoleHierarchy
+	registeredRoles = map[Role]bool{}
+	orgRoles        = map[Role]bool{}
+)
+
+// RequireOrganization makes Identity.Validate reject identities of the roles without an organization id
+func RequireOrganization(roles ...Role) {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	for _, r := range roles {
+		orgRoles[r] = true
+	}
+}
+
+func isOrganizationRequired(r Role) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return orgRoles[r]
+}
+
+// RegisterRoles makes additional roles valid for Role.Validate, it panics on empty roles
+func RegisterRoles(roles ...Role) {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	for _, r := range roles {
+		if r == "" {
+			panic("auth: cannot register an empty role")
+		}
+		registeredRoles[r] = true
+	}
+}
+
+// Roles returns the built-in roles followed by the registered ones in name order
+func Roles() []Role {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	extra := make([]Role, 0, len(registeredRoles))
+	for r := range registeredRoles {
+		extra = append(extra, r)
+	}
+	slices.Sort(extra)
+	return append([]Role{RoleAdmin, RoleParticipant, RoleAgent}, extra...)
+}
+
+func isRegisteredRole(r Role) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return registeredRoles[r]
+}
+
+// SetRoleHierarchy sets the hierarchy used by Identity.HasRole, nil disables inheritance (the default)
+func SetRoleHierarchy(h RoleHierarchy) error {
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	roleHierarchy = h
+	return nil
+}
+
+func currentRoleHierarchy() RoleHierarchy {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return roleHierarchy
+}
+
+// Validate ensures the hierarchy is a DAG
+func (h RoleHierarchy) Validate() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[Role]int, len(h))
+	var visit func(r Role) error
+	visit = func(r Role) error {
+		switch state[r] {
+		case visiting:
+			return fmt.Errorf("role hierarchy has a cycle through role: %s", r)
+		case done:
+			return nil
+		}
+		state[r] = visiting
+		for _, implied := range h[r] {
+			if err := visit(implied); err != nil {
+				return err
+			}
+		}
+		state[r] = done
+		return nil
+	}
+	for r := range h {
+		if err := visit(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Implies checks if have is or transitively implies want
+func (h RoleHierarchy) Implies(have, want Role) bool {
+	if have == want {
+		return true
+	}
+	for _, implied := range h[have] {
+		if h.Implies(implied, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleHierarchy_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		hierarchy RoleHierarchy
+		expectErr bool
+	}{
+		{
+			name:      "Default hierarchy",
+			hierarchy: DefaultRoleHierarchy,
+		},
+		{
+			name:      "Diamond",
+			hierarchy: RoleHierarchy{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}},
+		},
+		{
+			name:      "Cycle",
+			hierarchy: RoleHierarchy{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			expectErr: true,
+		},
+		{
+			name:      "Self reference",
+			hierarchy: RoleHierarchy{"a": {"a"}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hierarchy.Validate()
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRoleHierarchy_Implies(t *testing.T) {
+	tests := []struct {
+		name     string
+		have     Role
+		want     Role
+		expected bool
+	}{
+		{name: "Same role", have: RoleAgent, want: RoleAgent, expected: true},
+		{name: "Direct", have: RoleAdmin, want: RoleParticipant, expected: true},
+		{name: "Transitive", have: RoleAdmin, want: RoleAgent, expected: true},
+		{name: "Not upwards", have: RoleAgent, want: RoleParticipant, expected: false},
+		{name: "Unknown role", have: "auditor", want: RoleAgent, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DefaultRoleHierarchy.Implies(tt.have, tt.want))
+		})
+	}
+}
+
+func TestSetRoleHierarchy(t *testing.T) {
+	t.Cleanup(func() { SetRoleHierarchy(nil) })
+
+	admin := &Identity{Role: RoleAdmin}
+	assert.False(t, admin.HasRole(RoleAgent))
+
+	require.NoError(t, SetRoleHierarchy(DefaultRoleHierarchy))
+	assert.True(t, admin.HasRole(RoleAgent))
+
+	authorizer := NewRuleBasedAuthorizer([]AuthorizationRule{
+		{Roles: []Role{RoleParticipant}, Action: "read", Object: "data"},
+	})
+	assert.NoError(t, authorizer.Authorize(admin, "read", "data", nil))
+	assert.Error(t, authorizer.Authorize(&Identity{Role: RoleAgent}, "read", "data", nil))
+
+	assert.Error(t, SetRoleHierarchy(RoleHierarchy{"a": {"a"}}))
+	assert.True(t, admin.HasRole(RoleAgent), "invalid hierarchy must not replace the current one")
+
+	require.NoError(t, SetRoleHierarchy(nil))
+	assert.False(t, admin.HasRole(RoleAgent))
+}
+
+func TestRegisterRoles(t *testing.T) {
+	t.Cleanup(func() {
+		rolesMu.Lock()
+		registeredRoles = map[Role]bool{}
+		rolesMu.Unlock()
+	})
+
+	assert.Error(t, Role("operator").Validate())
+
+	RegisterRoles("operator", "auditor")
+	assert.NoError(t, Role("operator").Validate())
+	assert.NoError(t, Role("auditor").Validate())
+	assert.Error(t, Role("superuser").Validate())
+	assert.Equal(t, []Role{RoleAdmin, RoleParticipant, RoleAgent, "auditor", "operator"}, Roles())
+
+	assert.Panics(t, func() { RegisterRoles("") })
+}
+
+func TestRequireOrganization(t *testing.T) {
+	t.Cleanup(func() {
+		rolesMu.Lock()
+		orgRoles = map[Role]bool{}
+		rolesMu.Unlock()
+	})
+
+	participantID := properties.NewUUID()
+	orgID := properties.NewUUID()
+	identity := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	assert.NoError(t, identity.Validate())
+
+	RequireOrganization(RoleParticipant)
+	err := identity.Validate()
+	require.Error(t, err)
+	assert.Equal(t, "participant role requires organization id", err.Error())
+
+	identity.Scope.OrganizationID = &orgID
+	assert.NoError(t, identity.Validate())
+	assert.NoError(t, (&Identity{Role: RoleAdmin}).Validate())
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/fulcrumproject/commons/properties"
+)
+
+// AllOfScope matches when all the scopes match, an empty list always matches
+type AllOfScope []ObjectScope
+
+// AnyOfScope matches when at least one of the scopes matches, an empty list never matches
+type AnyOfScope []ObjectScope
+
+// AllOf combines scopes requiring all of them to match, nil scopes are ignored
+func AllOf(scopes ...ObjectScope) AllOfScope {
+	return AllOfScope(compactScopes(scopes))
+}
+
+// AnyOf combines scopes requiring any of them to match, nil scopes are ignored
+func AnyOf(scopes ...ObjectScope) AnyOfScope {
+	return AnyOfScope(compactScopes(scopes))
+}
+
+// Matches checks all the scopes
+func (s AllOfScope) Matches(identity *Identity) bool {
+	for _, scope := range s {
+		if !scope.Matches(identity) {
+			return false
+		}
+	}
+	return true
+}
+
+// Attributes merges the attributes of the combined scopes, later scopes win on conflicts
+func (s AllOfScope) Attributes() map[string]any {
+	return mergeAttributes(s)
+}
+
+// Matches checks any of the scopes
+func (s AnyOfScope) Matches(identity *Identity) bool {
+	for _, scope := range s {
+		if scope.Matches(identity) {
+			return true
+		}
+	}
+	return false
+}
+
+// Attributes merges the attributes of the combined scopes, later scopes win on conflicts
+func (s AnyOfScope) Attributes() map[string]any {
+	return mergeAttributes(s)
+}
+
+// CacheKey fingerprints the combined scopes, only when all of them are cacheable
+func (s AllOfScope) CacheKey() (string, bool) {
+	return combinedCacheKey("allOf", s)
+}
+
+// CacheKey fingerprints the combined scopes, only when all of them are cacheable
+func (s AnyOfScope) CacheKey() (string, bool) {
+	return combinedCacheKey("anyOf", s)
+}
+
+func combinedCacheKey(kind string, scopes []ObjectScope) (string, bool) {
+	keys := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		key, ok := scopeCacheKey(scope)
+		if !ok {
+			return "", false
+		}
+		keys = append(keys, key)
+	}
+	return kind + "(" + strings.Join(keys, ",") + ")", true
+}
+
+func compactScopes(scopes []ObjectScope) []ObjectScope {
+	out := make([]ObjectScope, 0, len(scopes))
+	for _, s := range scopes {
+		if s != nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func mergeAttributes(scopes []ObjectScope) map[string]any {
+	var merged map[string]any
+	for _, s := range scopes {
+		for k, v := range ScopeAttributes(s) {
+			if merged == nil {
+				merged = make(map[string]any)
+			}
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// ParticipantObjectScope matches identities of the participant owning the object and unrestricted identities
+type ParticipantObjectScope struct {
+	ParticipantID properties.UUID `json:"participantId"`
+}
+
+// CacheKey fingerprints the participant
+func (s ParticipantObjectScope) CacheKey() (string, bool) {
+	return "participant:" + s.ParticipantID.String(), true
+}
+
+// Matches checks the identity participant
+func (s ParticipantObjectScope) Matches(identity *Identity) bool {
+	if identity == nil {
+		return false
+	}
+	if isUnrestricted(identity) {
+		return true
+	}
+	return identity.Scope.ParticipantID != nil && *identity.Scope.ParticipantID == s.ParticipantID
+}
+
+// AgentObjectScope matches the agent owning the object and unrestricted identities
+type AgentObjectScope struct {
+	AgentID properties.UUID `json:"agentId"`
+}
+
+// CacheKey fingerprints the agent
+func (s AgentObjectScope) CacheKey() (string, bool) {
+	return "agent:" + s.AgentID.String(), true
+}
+
+// Matches checks the identity agent
+func (s AgentObjectScope) Matches(identity *Identity) bool {
+	if identity == nil {
+		return false
+	}
+	if isUnrestricted(identity) {
+		return true
+	}
+	return identity.Scope.AgentID != nil && *identity.Scope.AgentID == s.AgentID
+}
+
+// OrganizationObjectScope matches identities of the organization owning the object and global identities,
+// identities bound to a participant or agent but not to an organization don't match
+type OrganizationObjectScope struct {
+	OrganizationID properties.UUID `json:"organizationId"`
+}
+
+// CacheKey fingerprints the organization
+func (s OrganizationObjectScope) CacheKey() (string, bool) {
+	return "organization:" + s.OrganizationID.String(), true
+}
+
+// Matches checks the identity organization
+func (s OrganizationObjectScope) Matches(identity *Identity) bool {
+	if identity == nil {
+		return false
+	}
+	if identity.Scope.OrganizationID == nil {
+		return isUnrestricted(identity)
+	}
+	return *identity.Scope.OrganizationID == s.OrganizationID
+}
+
+// isUnrestricted reports if the identity is not bound to any participant or agent (e.g. admins), anonymous identities never are
+func isUnrestricted(identity *Identity) bool {
+	return !identity.IsAnonymous() && identity.Scope.ParticipantID == nil && identity.Scope.AgentID == nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllOfAnyOf_Matches(t *testing.T) {
+	participantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	otherID := properties.NewUUID()
+	identity := &Identity{Role: RoleAgent, Scope: IdentityScope{ParticipantID: &participantID, AgentID: &agentID}}
+
+	participantMatch := &DefaultObjectScope{ParticipantID: &participantID}
+	agentMatch := &DefaultObjectScope{AgentID: &agentID}
+	mismatch := &DefaultObjectScope{ParticipantID: &otherID}
+
+	tests := []struct {
+		name     string
+		scope    ObjectScope
+		expected bool
+	}{
+		{name: "AllOf all matching", scope: AllOf(participantMatch, agentMatch), expected: true},
+		{name: "AllOf one mismatch", scope: AllOf(participantMatch, mismatch), expected: false},
+		{name: "AllOf empty", scope: AllOf(), expected: true},
+		{name: "AllOf ignores nil", scope: AllOf(nil, participantMatch), expected: true},
+		{name: "AnyOf one matching", scope: AnyOf(mismatch, agentMatch), expected: true},
+		{name: "AnyOf none matching", scope: AnyOf(mismatch, &mockObjectScope{shouldMatch: false}), expected: false},
+		{name: "AnyOf empty", scope: AnyOf(), expected: false},
+		{name: "Nested", scope: AnyOf(mismatch, AllOf(participantMatch, agentMatch)), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.scope.Matches(identity))
+		})
+	}
+}
+
+func TestAllOf_Attributes(t *testing.T) {
+	scope := AllOf(
+		WithAttributes(nil, map[string]any{"state": "draft", "owner": "alice"}),
+		&DefaultObjectScope{},
+		WithAttributes(nil, map[string]any{"state": "active"}),
+	)
+	assert.Equal(t, map[string]any{"state": "active", "owner": "alice"}, ScopeAttributes(scope))
+	assert.Nil(t, ScopeAttributes(AnyOf(&DefaultObjectScope{})))
+}
+
+func TestParticipantAndAgentObjectScope_Matches(t *testing.T) {
+	participantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	otherID := properties.NewUUID()
+
+	admin := &Identity{Role: RoleAdmin}
+	participant := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+	agent := &Identity{Role: RoleAgent, Scope: IdentityScope{ParticipantID: &participantID, AgentID: &agentID}}
+
+	tests := []struct {
+		name     string
+		scope    ObjectScope
+		identity *Identity
+		expected bool
+	}{
+		{name: "Participant scope, admin", scope: ParticipantObjectScope{ParticipantID: participantID}, identity: admin, expected: true},
+		{name: "Participant scope, same participant", scope: ParticipantObjectScope{ParticipantID: participantID}, identity: participant, expected: true},
+		{name: "Participant scope, agent of participant", scope: ParticipantObjectScope{ParticipantID: participantID}, identity: agent, expected: true},
+		{name: "Participant scope, other participant", scope: ParticipantObjectScope{ParticipantID: otherID}, identity: participant, expected: false},
+		{name: "Participant scope, nil identity", scope: ParticipantObjectScope{ParticipantID: participantID}, identity: nil, expected: false},
+		{name: "Agent scope, admin", scope: AgentObjectScope{AgentID: agentID}, identity: admin, expected: true},
+		{name: "Agent scope, same agent", scope: AgentObjectScope{AgentID: agentID}, identity: agent, expected: true},
+		{name: "Agent scope, other agent", scope: AgentObjectScope{AgentID: otherID}, identity: agent, expected: false},
+		{name: "Agent scope, participant", scope: AgentObjectScope{AgentID: agentID}, identity: participant, expected: false},
+		{name: "Agent scope, nil identity", scope: AgentObjectScope{AgentID: agentID}, identity: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.scope.Matches(tt.identity))
+		})
+	}
+}
+
+func TestOrganizationObjectScope_Matches(t *testing.T) {
+	orgID := properties.NewUUID()
+	otherOrgID := properties.NewUUID()
+	participantID := properties.NewUUID()
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		expected bool
+	}{
+		{name: "Global admin", identity: &Identity{Role: RoleAdmin}, expected: true},
+		{name: "Organization admin", identity: &Identity{Role: RoleAdmin, Scope: IdentityScope{OrganizationID: &orgID}}, expected: true},
+		{name: "Participant of organization", identity: &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID, OrganizationID: &orgID}}, expected: true},
+		{name: "Participant of other organization", identity: &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID, OrganizationID: &otherOrgID}}, expected: false},
+		{name: "Participant without organization", identity: &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}, expected: false},
+		{name: "Nil identity", identity: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, OrganizationObjectScope{OrganizationID: orgID}.Matches(tt.identity))
+		})
+	}
+}
+
+func TestDefaultObjectScope_Matches_Organization(t *testing.T) {
+	orgID := properties.NewUUID()
+	otherOrgID := properties.NewUUID()
+	participantID := properties.NewUUID()
+
+	participant := &Identity{Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID, OrganizationID: &orgID}}
+	orgAdmin := &Identity{Role: RoleAdmin, Scope: IdentityScope{OrganizationID: &otherOrgID}}
+
+	assert.True(t, (&DefaultObjectScope{ParticipantID: &participantID, OrganizationID: &orgID}).Matches(participant))
+	assert.False(t, (&DefaultObjectScope{ParticipantID: &participantID, OrganizationID: &otherOrgID}).Matches(participant))
+	assert.False(t, (&DefaultObjectScope{OrganizationID: &orgID}).Matches(orgAdmin))
+	assert.True(t, (&DefaultObjectScope{ParticipantID: &participantID}).Matches(participant), "objects without organization keep the previous behaviour")
+}
+
+func TestAnonymous_Matches(t *testing.T) {
+	id := properties.NewUUID()
+	anonymous := Anonymous()
+	claimedAnonymous := &Identity{Role: RoleAnonymous}
+
+	tests := []struct {
+		name     string
+		scope    ObjectScope
+		expected bool
+	}{
+		{name: "Always match", scope: AllwaysMatchObjectScope{}, expected: true},
+		{name: "Global default scope", scope: &DefaultObjectScope{}, expected: true},
+		{name: "Participant default scope", scope: &DefaultObjectScope{ParticipantID: &id}, expected: false},
+		{name: "Organization default scope", scope: &DefaultObjectScope{OrganizationID: &id}, expected: false},
+		{name: "Participant scope", scope: &ParticipantObjectScope{ParticipantID: id}, expected: false},
+		{name: "Agent scope", scope: &AgentObjectScope{AgentID: id}, expected: false},
+		{name: "Organization scope", scope: &OrganizationObjectScope{OrganizationID: id}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.scope.Matches(anonymous))
+			assert.Equal(t, tt.expected, tt.scope.Matches(claimedAnonymous), "anonymous role without kind")
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// HashToken returns the hex encoded SHA-256 hash of a token as stored by StaticTokenAuthenticator
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// StaticTokenAuthenticator implements Authenticator from a fixed set of API tokens,
+// meant for machine-to-machine callers and local development
+// Only token hashes are kept in memory
+type StaticTokenAuthenticator struct {
+	identities map[string]*Identity
+}
+
+// NewStaticTokenAuthenticator creates an authenticator from a map of token hashes, as returned by HashToken, to identities
+func NewStaticTokenAuthenticator(hashes map[string]*Identity) (*StaticTokenAuthenticator, error) {
+	a := &StaticTokenAuthenticator{identities: make(map[string]*Identity, len(hashes))}
+	for hash, identity := range hashes {
+		if err := a.add(hash, identity); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// NewStaticTokenAuthenticatorFromTokens creates an authenticator from a map of plain tokens to identities,
+// the tokens are hashed and not retained
+func NewStaticTokenAuthenticatorFromTokens(tokens map[string]*Identity) (*StaticTokenAuthenticator, error) {
+	hashes := make(map[string]*Identity, len(tokens))
+	for token, identity := range tokens {
+		if token == "" {
+			return nil, errors.New("static token cannot be empty")
+		}
+		hashes[HashToken(token)] = identity
+	}
+	return NewStaticTokenAuthenticator(hashes)
+}
+
+func (a *StaticTokenAuthenticator) add(hash string, identity *Identity) error {
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("invalid static token hash %q: must be a hex encoded SHA-256", hash)
+	}
+	if identity == nil {
+		return fmt.Errorf("missing identity for static token hash %s", hash)
+	}
+	if err := identity.Role.Validate(); err != nil {
+		return fmt.Errorf("invalid identity %s: %w", identity.Name, err)
+	}
+	if err := identity.Validate(); err != nil {
+		return fmt.Errorf("invalid identity %s: %w", identity.Name, err)
+	}
+	a.identities[strings.ToLower(hash)] = identity.Clone()
+	return nil
+}
+
+// Authenticate returns a copy of the identity of the token
+// Unknown tokens return a nil identity without error so other authenticators of a CompositeAuthenticator are tried
+func (a *StaticTokenAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	if token == "" {
+		return nil, nil
+	}
+	identity, ok := a.identities[HashToken(token)]
+	if !ok {
+		return nil, nil
+	}
+	return identity.Clone(), nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashToken(t *testing.T) {
+	assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", HashToken("test"))
+}
+
+func TestStaticTokenAuthenticator_Authenticate(t *testing.T) {
+	participantID := properties.NewUUID()
+	admin := &Identity{ID: properties.NewUUID(), Name: "ci", Role: RoleAdmin}
+	participant := &Identity{ID: participantID, Name: "billing", Role: RoleParticipant, Scope: IdentityScope{ParticipantID: &participantID}}
+
+	authenticator, err := NewStaticTokenAuthenticator(map[string]*Identity{
+		HashToken("admin-token"):                    admin,
+		strings.ToUpper(HashToken("billing-token")): participant,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		expected *Identity
+	}{
+		{name: "Admin token", token: "admin-token", expected: admin},
+		{name: "Upper case hash", token: "billing-token", expected: participant},
+		{name: "Unknown token", token: "other-token"},
+		{name: "Empty token", token: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := authenticator.Authenticate(context.Background(), tt.token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, identity)
+		})
+	}
+}
+
+func TestStaticTokenAuthenticator_ReturnsCopy(t *testing.T) {
+	authenticator, err := NewStaticTokenAuthenticatorFromTokens(map[string]*Identity{
+		"token": {Name: "ci", Role: RoleAdmin},
+	})
+	require.NoError(t, err)
+
+	identity, err := authenticator.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	identity.Role = RoleAgent
+
+	identity, err = authenticator.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, identity.Role)
+}
+
+func TestStaticTokenAuthenticator_Composite(t *testing.T) {
+	static, err := NewStaticTokenAuthenticatorFromTokens(map[string]*Identity{
+		"token": {Name: "ci", Role: RoleAdmin},
+	})
+	require.NoError(t, err)
+	fallback := &mockAuthenticator{identity: &Identity{Name: "fallback", Role: RoleAdmin}}
+
+	identity, err := NewCompositeAuthenticator(static, fallback).Authenticate(context.Background(), "jwt")
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", identity.Name)
+}
+
+func TestNewStaticTokenAuthenticator_Invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		hashes        map[string]*Identity
+		errorContains string
+	}{
+		{
+			name:          "Not hex",
+			hashes:        map[string]*Identity{"plain-token": {Role: RoleAdmin}},
+			errorContains: "must be a hex encoded SHA-256",
+		},
+		{
+			name:          "Wrong length",
+			hashes:        map[string]*Identity{"abcd": {Role: RoleAdmin}},
+			errorContains: "must be a hex encoded SHA-256",
+		},
+		{
+			name:          "Missing identity",
+			hashes:        map[string]*Identity{HashToken("token"): nil},
+			errorContains: "missing identity",
+		},
+		{
+			name:          "Invalid role",
+			hashes:        map[string]*Identity{HashToken("token"): {Name: "ci", Role: "unknown"}},
+			errorContains: "invalid auth role",
+		},
+		{
+			name:          "Anonymous role",
+			hashes:        map[string]*Identity{HashToken("token"): {Name: "ci", Role: RoleAnonymous}},
+			errorContains: "reserved to unauthenticated callers",
+		},
+		{
+			name:          "Invalid scope",
+			hashes:        map[string]*Identity{HashToken("token"): {Name: "ci", Role: RoleParticipant}},
+			errorContains: "participant role requires participant id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStaticTokenAuthenticator(tt.hashes)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorContains)
+		})
+	}
+
+	_, err := NewStaticTokenAuthenticatorFromTokens(map[string]*Identity{"": {Role: RoleAdmin}})
+	assert.EqualError(t, err, "static token cannot be empty")
+}
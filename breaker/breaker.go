@@ -0,0 +1,299 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+)
+
+var (
+	ErrOpen            = errors.New("circuit breaker is open")
+	ErrTooManyRequests = errors.New("circuit breaker is half-open and probe limit reached")
+)
+
+// errPanicked records a call that panicked, it's always a failure whatever the classification
+var errPanicked = errors.New("call panicked")
+
+// State represents the circuit breaker state
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// String returns the state name
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Metrics receives the breaker instrumentation
+type Metrics interface {
+	ObserveResult(name string, success bool)
+	ObserveRejected(name string)
+	ObserveStateChange(name string, from, to State)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveResult(string, bool)              {}
+func (noopMetrics) ObserveRejected(string)                  {}
+func (noopMetrics) ObserveStateChange(string, State, State) {}
+
+// Settings configures a Breaker, zero values are replaced by defaults
+type Settings struct {
+	// Window is the duration of the sliding window used to compute the failure rate (default 60s)
+	Window time.Duration
+	// Buckets is the number of buckets the window is split into (default 10)
+	Buckets int
+	// MinRequests is the number of requests in the window before the failure rate is evaluated (default 20)
+	MinRequests int
+	// FailureRate is the ratio of failures (0 to 1) that opens the breaker (default 0.5)
+	FailureRate float64
+	// OpenTimeout is how long the breaker stays open before allowing probes (default 30s)
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful probes needed to close the breaker (default 1)
+	HalfOpenRequests int
+	// IsFailure classifies results, nil counts every non-nil error as a failure and ignores the context cancellations
+	IsFailure func(err error) bool
+	// OnStateChange is called on every transition after the breaker lock is released, so it may use the breaker
+	OnStateChange func(name string, from, to State)
+	// Metrics optionally receives results, rejections and transitions
+	Metrics Metrics
+	// Clock is used to measure the window and the open timeout (default real clock)
+	Clock clock.Clock
+}
+
+func (s Settings) withDefaults() Settings {
+	if s.Window <= 0 {
+		s.Window = 60 * time.Second
+	}
+	if s.Buckets <= 0 {
+		s.Buckets = 10
+	}
+	if s.MinRequests <= 0 {
+		s.MinRequests = 20
+	}
+	if s.FailureRate <= 0 {
+		s.FailureRate = 0.5
+	}
+	if s.OpenTimeout <= 0 {
+		s.OpenTimeout = 30 * time.Second
+	}
+	if s.HalfOpenRequests <= 0 {
+		s.HalfOpenRequests = 1
+	}
+	if s.IsFailure == nil {
+		s.IsFailure = defaultIsFailure
+	}
+	if s.Metrics == nil {
+		s.Metrics = noopMetrics{}
+	}
+	if s.Clock == nil {
+		s.Clock = clock.New()
+	}
+	return s
+}
+
+// validate rejects the settings the defaults cannot fix
+func (s Settings) validate() error {
+	if s.Window < time.Duration(s.Buckets) {
+		return fmt.Errorf("breaker window %s is too short for %d buckets", s.Window, s.Buckets)
+	}
+	if s.FailureRate > 1 {
+		return fmt.Errorf("breaker failure rate %v is greater than 1", s.FailureRate)
+	}
+	return nil
+}
+
+func defaultIsFailure(err error) bool {
+	return err != nil
+}
+
+type stateChange struct {
+	from, to State
+}
+
+// Breaker is a circuit breaker tracking the failure rate over a sliding window
+type Breaker struct {
+	name     string
+	settings Settings
+
+	// ignoreCanceled makes the context cancellations neutral with the default failure classification
+	ignoreCanceled bool
+
+	mu         sync.Mutex
+	changes    []stateChange
+	state      State
+	generation uint64
+	window     *window
+	openedAt   time.Time
+	probes     int
+	successes  int
+}
+
+// New creates a closed breaker, it fails when the window is shorter than the number of buckets in nanoseconds
+// or the failure rate is greater than 1
+func New(name string, settings Settings) (*Breaker, error) {
+	s := settings.withDefaults()
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	b := &Breaker{
+		name:           name,
+		settings:       s,
+		ignoreCanceled: settings.IsFailure == nil,
+	}
+	b.window = newWindow(s.Window, s.Buckets)
+	return b, nil
+}
+
+// Name returns the breaker name
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state, moving from open to half-open if the timeout elapsed
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.unlock()
+	b.refresh(b.settings.Clock.Now())
+	return b.state
+}
+
+// Execute runs fn if the breaker allows it and records its result, a panic is recorded as a failure and propagated
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			done(errPanicked)
+			panic(r)
+		}
+	}()
+	err = fn(ctx)
+	done(err)
+	return err
+}
+
+// Allow checks if a call is permitted and returns the function recording its outcome
+// It is meant for call sites that cannot be wrapped in a closure
+// The returned function must be called exactly once whatever happens to the call, e.g. deferred:
+// a half-open breaker keeps the probe slot taken until then and rejects the other calls
+func (b *Breaker) Allow() (func(err error), error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := b.settings.Clock.Now()
+	b.refresh(now)
+
+	switch b.state {
+	case StateOpen:
+		b.settings.Metrics.ObserveRejected(b.name)
+		return nil, fmt.Errorf("%s: %w", b.name, ErrOpen)
+	case StateHalfOpen:
+		if b.probes >= b.settings.HalfOpenRequests {
+			b.settings.Metrics.ObserveRejected(b.name)
+			return nil, fmt.Errorf("%s: %w", b.name, ErrTooManyRequests)
+		}
+		b.probes++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.record(generation, err) })
+	}, nil
+}
+
+func (b *Breaker) record(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	// Cancelled calls are neutral, a half-open probe slot is released for another call
+	if b.ignoreCanceled && errors.Is(err, context.Canceled) {
+		if generation == b.generation && b.state == StateHalfOpen {
+			b.probes--
+		}
+		return
+	}
+
+	failure := errors.Is(err, errPanicked) || b.settings.IsFailure(err)
+	b.settings.Metrics.ObserveResult(b.name, !failure)
+
+	// Ignore results of calls started before the last transition
+	if generation != b.generation {
+		return
+	}
+
+	now := b.settings.Clock.Now()
+	switch b.state {
+	case StateClosed:
+		b.window.add(now, failure)
+		total, failures := b.window.totals(now)
+		if total >= b.settings.MinRequests && float64(failures)/float64(total) >= b.settings.FailureRate {
+			b.transition(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if failure {
+			b.transition(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			b.transition(StateClosed, now)
+		}
+	}
+}
+
+func (b *Breaker) refresh(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.settings.OpenTimeout {
+		b.transition(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) transition(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.probes = 0
+	b.successes = 0
+	switch to {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.window.reset()
+	}
+
+	b.changes = append(b.changes, stateChange{from, to})
+}
+
+// unlock releases the lock then reports the transitions made while holding it
+func (b *Breaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	for _, c := range changes {
+		b.settings.Metrics.ObserveStateChange(b.name, c.from, c.to)
+		if b.settings.OnStateChange != nil {
+			b.settings.OnStateChange(b.name, c.from, c.to)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackend = errors.New("backend failure")
+
+type transition struct {
+	from, to State
+}
+
+func newTestBreaker(t *testing.T, settings Settings) (*Breaker, *clock.Fake, *[]transition) {
+	t.Helper()
+	var transitions []transition
+	settings.OnStateChange = func(name string, from, to State) {
+		assert.Equal(t, "test", name)
+		transitions = append(transitions, transition{from, to})
+	}
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	settings.Clock = fake
+	b, err := New("test", settings)
+	require.NoError(t, err)
+	return b, fake, &transitions
+}
+
+func call(b *Breaker, err error) error {
+	return b.Execute(context.Background(), func(ctx context.Context) error { return err })
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "unknown(7)", State(7).String())
+}
+
+func TestBreaker_Opens(t *testing.T) {
+	b, _, transitions := newTestBreaker(t, Settings{MinRequests: 4, FailureRate: 0.5})
+
+	// Below minimum requests the breaker stays closed
+	assert.ErrorIs(t, call(b, errBackend), errBackend)
+	assert.ErrorIs(t, call(b, errBackend), errBackend)
+	assert.ErrorIs(t, call(b, errBackend), errBackend)
+	assert.Equal(t, StateClosed, b.State())
+
+	// Fourth request reaches the threshold with 100% failures
+	assert.ErrorIs(t, call(b, errBackend), errBackend)
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, []transition{{StateClosed, StateOpen}}, *transitions)
+
+	// Calls are rejected without running fn
+	ran := false
+	err := b.Execute(context.Background(), func(ctx context.Context) error { ran = true; return nil })
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.EqualError(t, err, "test: circuit breaker is open")
+	assert.False(t, ran)
+}
+
+func TestBreaker_StaysClosedBelowRate(t *testing.T) {
+	b, _, _ := newTestBreaker(t, Settings{MinRequests: 4, FailureRate: 0.5})
+
+	for range 10 {
+		require.NoError(t, call(b, nil))
+		assert.ErrorIs(t, call(b, errBackend), errBackend)
+		require.NoError(t, call(b, nil))
+	}
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_SlidingWindowExpiresFailures(t *testing.T) {
+	b, clk, _ := newTestBreaker(t, Settings{MinRequests: 4, FailureRate: 0.5, Window: 10 * time.Second, Buckets: 10})
+
+	for range 3 {
+		_ = call(b, errBackend)
+	}
+	// Old failures leave the window
+	clk.Advance(11 * time.Second)
+	_ = call(b, errBackend)
+	require.NoError(t, call(b, nil))
+	require.NoError(t, call(b, nil))
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name          string
+		probeErr      error
+		expectedState State
+	}{
+		{
+			name:          "Successful probe closes",
+			probeErr:      nil,
+			expectedState: StateClosed,
+		},
+		{
+			name:          "Failed probe reopens",
+			probeErr:      errBackend,
+			expectedState: StateOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, clk, transitions := newTestBreaker(t, Settings{MinRequests: 1, OpenTimeout: 5 * time.Second})
+			_ = call(b, errBackend)
+			require.Equal(t, StateOpen, b.State())
+
+			clk.Advance(5 * time.Second)
+			assert.Equal(t, StateHalfOpen, b.State())
+
+			// Only one probe is allowed at a time
+			done, err := b.Allow()
+			require.NoError(t, err)
+			_, err = b.Allow()
+			assert.ErrorIs(t, err, ErrTooManyRequests)
+
+			done(tt.probeErr)
+			assert.Equal(t, tt.expectedState, b.State())
+			assert.Equal(t, []transition{
+				{StateClosed, StateOpen},
+				{StateOpen, StateHalfOpen},
+				{StateHalfOpen, tt.expectedState},
+			}, *transitions)
+		})
+	}
+}
+
+func TestBreaker_IgnoresStaleResults(t *testing.T) {
+	b, _, _ := newTestBreaker(t, Settings{MinRequests: 1})
+
+	done, err := b.Allow()
+	require.NoError(t, err)
+	_ = call(b, errBackend)
+	require.Equal(t, StateOpen, b.State())
+
+	// A success started before opening does not affect the new state
+	done(nil)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreaker_IsFailure(t *testing.T) {
+	b, _, _ := newTestBreaker(t, Settings{
+		MinRequests: 1,
+		IsFailure:   func(err error) bool { return errors.Is(err, errBackend) },
+	})
+
+	_ = call(b, errors.New("client error"))
+	_ = call(b, context.Canceled)
+	assert.Equal(t, StateClosed, b.State())
+
+	_ = call(b, errBackend)
+	assert.Equal(t, StateClosed, b.State(), "1 failure out of 3 is below the default rate")
+}
+
+func TestBreaker_CanceledIsNeutral(t *testing.T) {
+	metrics := &recordingMetrics{}
+	b, clk, _ := newTestBreaker(t, Settings{MinRequests: 2, OpenTimeout: 5 * time.Second, Metrics: metrics})
+
+	_ = call(b, errBackend)
+	_ = call(b, context.Canceled)
+	assert.Equal(t, StateClosed, b.State(), "cancellations are not counted as requests")
+	assert.Equal(t, 1, metrics.failures+metrics.successes)
+
+	_ = call(b, errBackend)
+	require.Equal(t, StateOpen, b.State())
+	clk.Advance(5 * time.Second)
+
+	// A cancelled probe releases its slot without closing the breaker
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done(context.Canceled)
+	assert.Equal(t, StateHalfOpen, b.State())
+	done, err = b.Allow()
+	require.NoError(t, err)
+	done(nil)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_PanicIsFailure(t *testing.T) {
+	b, clk, _ := newTestBreaker(t, Settings{
+		MinRequests: 1,
+		OpenTimeout: 5 * time.Second,
+		IsFailure:   func(err error) bool { return errors.Is(err, errBackend) },
+	})
+	panicking := func(ctx context.Context) error { panic("boom") }
+
+	assert.PanicsWithValue(t, "boom", func() { _ = b.Execute(context.Background(), panicking) })
+	require.Equal(t, StateOpen, b.State(), "panics are failures whatever the classification")
+
+	// A panicking probe releases its slot and opens the breaker again
+	clk.Advance(5 * time.Second)
+	assert.Panics(t, func() { _ = b.Execute(context.Background(), panicking) })
+	assert.Equal(t, StateOpen, b.State())
+	clk.Advance(5 * time.Second)
+	assert.NoError(t, call(b, nil))
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_StateChangeOutsideLock(t *testing.T) {
+	var b *Breaker
+	var states []State
+	b, err := New("test", Settings{MinRequests: 1, OnStateChange: func(name string, from, to State) {
+		states = append(states, b.State())
+	}})
+	require.NoError(t, err)
+
+	_ = call(b, errBackend)
+	assert.Equal(t, []State{StateOpen}, states, "the callback can use the breaker")
+}
+
+func TestNew_InvalidSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		errMsg   string
+	}{
+		{name: "Window shorter than buckets", settings: Settings{Window: 5 * time.Nanosecond, Buckets: 10}, errMsg: "too short for 10 buckets"},
+		{name: "Failure rate above 1", settings: Settings{FailureRate: 1.5}, errMsg: "greater than 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New("test", tt.settings)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestBreaker_Metrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	b, _, _ := newTestBreaker(t, Settings{MinRequests: 1, Metrics: metrics})
+
+	_ = call(b, nil)
+	_ = call(b, errBackend)
+	_ = call(b, nil)
+
+	assert.Equal(t, 1, metrics.successes)
+	assert.Equal(t, 1, metrics.failures)
+	assert.Equal(t, 1, metrics.rejected)
+	assert.Equal(t, []transition{{StateClosed, StateOpen}}, metrics.transitions)
+}
+
+type recordingMetrics struct {
+	successes   int
+	failures    int
+	rejected    int
+	transitions []transition
+}
+
+func (m *recordingMetrics) ObserveResult(name string, success bool) {
+	if success {
+		m.successes++
+	} else {
+		m.failures++
+	}
+}
+
+func (m *recordingMetrics) ObserveRejected(name string) {
+	m.rejected++
+}
+
+func (m *recordingMetrics) ObserveStateChange(name string, from, to State) {
+	m.transitions = append(m.transitions, transition{from, to})
+}
@@ -0,0 +1,50 @@
+package breaker
+
+import "sync"
+
+// Registry holds named breakers sharing the same settings, created on first use
+type Registry struct {
+	settings Settings
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry creates a new registry using settings for every breaker, it fails on invalid settings like New
+func NewRegistry(settings Settings) (*Registry, error) {
+	if err := settings.withDefaults().validate(); err != nil {
+		return nil, err
+	}
+	return &Registry{
+		settings: settings,
+		breakers: make(map[string]*Breaker),
+	}, nil
+}
+
+// Get returns the breaker with the given name, creating it if needed
+func (r *Registry) Get(name string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[name]
+	if !ok {
+		// the settings are validated by NewRegistry
+		b, _ = New(name, r.settings)
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// States returns the current state of every registered breaker
+func (r *Registry) States() map[string]State {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	states := make(map[string]State, len(breakers))
+	for _, b := range breakers {
+		states[b.Name()] = b.State()
+	}
+	return states
+}
@@ -0,0 +1,28 @@
+package breaker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry, err := NewRegistry(Settings{MinRequests: 1})
+	require.NoError(t, err)
+
+	db := registry.Get("db")
+	assert.Same(t, db, registry.Get("db"))
+	assert.NotSame(t, db, registry.Get("bus"))
+	assert.Equal(t, "db", db.Name())
+
+	_ = call(db, errBackend)
+
+	assert.Equal(t, map[string]State{
+		"db":  StateOpen,
+		"bus": StateClosed,
+	}, registry.States())
+
+	_, err = NewRegistry(Settings{Window: 5, Buckets: 10})
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package breaker
+
+import "time"
+
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// window counts results over a sliding time window split into fixed buckets
+type window struct {
+	size    time.Duration
+	width   time.Duration
+	buckets []bucket
+}
+
+func newWindow(size time.Duration, buckets int) *window {
+	return &window{
+		size:    size,
+		width:   size / time.Duration(buckets),
+		buckets: make([]bucket, buckets),
+	}
+}
+
+func (w *window) bucketFor(now time.Time) *bucket {
+	start := now.Truncate(w.width)
+	idx := int(start.UnixNano()/int64(w.width)) % len(w.buckets)
+	b := &w.buckets[idx]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	return b
+}
+
+func (w *window) add(now time.Time, failure bool) {
+	b := w.bucketFor(now)
+	b.total++
+	if failure {
+		b.failures++
+	}
+}
+
+func (w *window) totals(now time.Time) (total int, failures int) {
+	for _, b := range w.buckets {
+		if now.Sub(b.start) < w.size {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return total, failures
+}
+
+func (w *window) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
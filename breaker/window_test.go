@@ -0,0 +1,36 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow(t *testing.T) {
+	w := newWindow(10*time.Second, 5)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w.add(start, true)
+	w.add(start.Add(time.Second), false)
+	w.add(start.Add(3*time.Second), true)
+
+	total, failures := w.totals(start.Add(3 * time.Second))
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 2, failures)
+
+	// The first bucket (0-2s) slides out after 10s
+	total, failures = w.totals(start.Add(10 * time.Second))
+	assert.Equal(t, 1, total)
+	assert.Equal(t, 1, failures)
+
+	// Reusing a ring slot discards its stale counts
+	w.add(start.Add(10*time.Second), false)
+	total, failures = w.totals(start.Add(10 * time.Second))
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 1, failures)
+
+	w.reset()
+	total, _ = w.totals(start.Add(10 * time.Second))
+	assert.Zero(t, total)
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/fulcrumproject/commons/response"
+	"github.com/fulcrumproject/commons/retry"
+)
+
+// CursorParam is the query parameter used to request the next page of a listing
+const CursorParam = "cursor"
+
+// Client is a JSON REST client for commons based APIs
+type Client struct {
+	baseURL string
+	http    *http.Client
+	tokens  TokenSource
+	headers http.Header
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.http = c
+	}
+}
+
+// WithTokenSource sets the source of the bearer tokens
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = ts
+	}
+}
+
+// WithHeader adds a header sent with every request
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Add(key, value)
+	}
+}
+
+// New creates a client for the API at baseURL
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    http.DefaultClient,
+		headers: make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Do sends a request encoding body as JSON when not nil and decoding the response into out when not nil,
+// error responses are decoded into errs errors wrapping a retry.StatusError
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("cannot encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot get token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("cannot decode response: %w", err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	var body response.ErrResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(data, &body); err != nil || body.ErrorText == "" {
+		body.ErrorText = http.StatusText(resp.StatusCode)
+	}
+
+	var kind errs.Kind
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		kind = errs.KindInvalid
+	case http.StatusUnauthorized, http.StatusForbidden:
+		kind = errs.KindUnauthorized
+	case http.StatusNotFound:
+		kind = errs.KindNotFound
+	case http.StatusConflict:
+		kind = errs.KindConflict
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		kind = errs.KindUnavailable
+	default:
+		kind = errs.KindUnknown
+	}
+
+	err := errs.New(kind, "%s", body.ErrorText)
+	for _, f := range body.ValidationErrors {
+		err = err.WithField(f.Path, f.Message)
+	}
+	return err.WithCause(&retry.StatusError{StatusCode: resp.StatusCode})
+}
+
+// Get fetches a resource
+func Get[T any](ctx context.Context, c *Client, path string, query url.Values) (T, error) {
+	var out T
+	err := c.Do(ctx, http.MethodGet, path, query, nil, &out)
+	return out, err
+}
+
+// Post creates a resource
+func Post[Req, Resp any](ctx context.Context, c *Client, path string, req Req) (Resp, error) {
+	var out Resp
+	err := c.Do(ctx, http.MethodPost, path, nil, req, &out)
+	return out, err
+}
+
+// Put replaces a resource
+func Put[Req, Resp any](ctx context.Context, c *Client, path string, req Req) (Resp, error) {
+	var out Resp
+	err := c.Do(ctx, http.MethodPut, path, nil, req, &out)
+	return out, err
+}
+
+// Patch updates a resource
+func Patch[Req, Resp any](ctx context.Context, c *Client, path string, req Req) (Resp, error) {
+	var out Resp
+	err := c.Do(ctx, http.MethodPatch, path, nil, req, &out)
+	return out, err
+}
+
+// Delete deletes a resource
+func Delete(ctx context.Context, c *Client, path string) error {
+	return c.Do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+// List iterates over all the items of a listing following the page cursors, iteration stops at the first error
+func List[T any](ctx context.Context, c *Client, path string, query url.Values) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		for {
+			page, err := Get[response.Page[T]](ctx, c, path, q)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if !page.HasMore || page.NextCursor == "" {
+				return
+			}
+			q.Set(CursorParam, page.NextCursor)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/fulcrumproject/commons/response"
+	"github.com/fulcrumproject/commons/retry"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testService struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				render.Render(w, r, response.ErrUnauthenticated(errors.New("missing token")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/services/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") != "1" {
+			render.Render(w, r, response.ErrNotFound(errors.New("service not found")))
+			return
+		}
+		render.JSON(w, r, testService{ID: "1", Name: "db"})
+	})
+	r.Post("/services", func(w http.ResponseWriter, r *http.Request) {
+		var req testService
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Name == "" {
+			render.Render(w, r, response.MultiErrInvalidRequest([]response.ValidationError{{Path: "name", Message: "is required"}}))
+			return
+		}
+		req.ID = "2"
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, req)
+	})
+	r.Delete("/services/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	r.Get("/services", func(w http.ResponseWriter, r *http.Request) {
+		pages := map[string]response.Page[testService]{
+			"":   {Items: []testService{{ID: "1"}, {ID: "2"}}, NextCursor: "p2", HasMore: true},
+			"p2": {Items: []testService{{ID: "3"}}},
+		}
+		assert.Equal(t, "active", r.URL.Query().Get("status"))
+		page := pages[r.URL.Query().Get(CursorParam)]
+		render.JSON(w, r, &page)
+	})
+	r.Get("/unavailable", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_Requests(t *testing.T) {
+	srv := newTestServer(t)
+	c := New(srv.URL+"/", WithTokenSource(StaticToken("token")), WithHeader("X-Request-Source", "test"))
+	ctx := context.Background()
+
+	svc, err := Get[testService](ctx, c, "/services/1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, testService{ID: "1", Name: "db"}, svc)
+
+	created, err := Post[testService, testService](ctx, c, "/services", testService{Name: "cache"})
+	require.NoError(t, err)
+	assert.Equal(t, testService{ID: "2", Name: "cache"}, created)
+
+	assert.NoError(t, Delete(ctx, c, "/services/2"))
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		client       *Client
+		call         func(c *Client) error
+		expectedKind errs.Kind
+		expectedCode int
+	}{
+		{
+			name:         "Not found",
+			client:       New(srv.URL, WithTokenSource(StaticToken("token"))),
+			call:         func(c *Client) error { _, err := Get[testService](ctx, c, "/services/9", nil); return err },
+			expectedKind: errs.KindNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Unauthenticated",
+			client:       New(srv.URL),
+			call:         func(c *Client) error { _, err := Get[testService](ctx, c, "/services/1", nil); return err },
+			expectedKind: errs.KindUnauthorized,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "Validation",
+			client: New(srv.URL, WithTokenSource(StaticToken("token"))),
+			call: func(c *Client) error {
+				_, err := Post[testService, testService](ctx, c, "/services", testService{})
+				return err
+			},
+			expectedKind: errs.KindInvalid,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unavailable without body",
+			client:       New(srv.URL, WithTokenSource(StaticToken("token"))),
+			call:         func(c *Client) error { return c.Do(ctx, http.MethodGet, "/unavailable", nil, nil, nil) },
+			expectedKind: errs.KindUnavailable,
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.client)
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedKind, errs.KindOf(err))
+
+			var statusErr *retry.StatusError
+			require.ErrorAs(t, err, &statusErr)
+			assert.Equal(t, tt.expectedCode, statusErr.StatusCode)
+			if tt.expectedKind == errs.KindInvalid {
+				assert.Equal(t, []errs.FieldError{{Path: "name", Message: "is required"}}, errs.FieldsOf(err))
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	srv := newTestServer(t)
+	c := New(srv.URL, WithTokenSource(StaticToken("token")))
+
+	var ids []string
+	for svc, err := range List[testService](context.Background(), c, "/services", url.Values{"status": {"active"}}) {
+		require.NoError(t, err)
+		ids = append(ids, svc.ID)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+
+	ids = nil
+	for svc, err := range List[testService](context.Background(), c, "/services", url.Values{"status": {"active"}}) {
+		require.NoError(t, err)
+		ids = append(ids, svc.ID)
+		break
+	}
+	assert.Equal(t, []string{"1"}, ids)
+
+	unauthenticated := New(srv.URL)
+	for _, err := range List[testService](context.Background(), unauthenticated, "/services", nil) {
+		assert.True(t, errors.Is(err, errs.ErrUnauthorized))
+	}
+}
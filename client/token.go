@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+)
+
+// TokenSource provides bearer tokens for outgoing requests
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls the underlying function
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticToken is a TokenSource always returning the same token
+type StaticToken string
+
+// Token returns the static token
+func (s StaticToken) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// FetchFunc fetches a new token and its expiration
+type FetchFunc func(ctx context.Context) (token string, expiresAt time.Time, err error)
+
+// CachedTokenSource reuses a fetched token until it is about to expire
+type CachedTokenSource struct {
+	mu        sync.Mutex
+	fetch     FetchFunc
+	clock     clock.Clock
+	leeway    time.Duration
+	token     string
+	expiresAt time.Time
+}
+
+// TokenSourceOption configures a CachedTokenSource
+type TokenSourceOption func(*CachedTokenSource)
+
+// WithTokenSourceClock sets the clock used to check the token expiration, defaults to the real clock
+func WithTokenSourceClock(clk clock.Clock) TokenSourceOption {
+	return func(s *CachedTokenSource) {
+		s.clock = clk
+	}
+}
+
+// NewCachedTokenSource creates a token source refreshing the token leeway before its expiration
+func NewCachedTokenSource(fetch FetchFunc, leeway time.Duration, opts ...TokenSourceOption) *CachedTokenSource {
+	s := &CachedTokenSource{fetch: fetch, leeway: leeway, clock: clock.New()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Token returns the cached token or fetches a new one
+func (s *CachedTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.clock.Now().Add(s.leeway).Before(s.expiresAt) {
+		return s.token, nil
+	}
+	token, expiresAt, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiresAt = token, expiresAt
+	return token, nil
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedTokenSource(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	fetches := 0
+	var fetchErr error
+	ts := NewCachedTokenSource(func(ctx context.Context) (string, time.Time, error) {
+		if fetchErr != nil {
+			return "", time.Time{}, fetchErr
+		}
+		fetches++
+		return fmt.Sprintf("token-%d", fetches), clk.Now().Add(time.Minute), nil
+	}, 10*time.Second, WithTokenSourceClock(clk))
+	ctx := context.Background()
+
+	token, err := ts.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	clk.Advance(45 * time.Second)
+	token, err = ts.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	clk.Advance(10 * time.Second)
+	token, err = ts.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	fetchErr = errors.New("idp down")
+	clk.Advance(time.Minute)
+	_, err = ts.Token(ctx)
+	assert.ErrorIs(t, err, fetchErr)
+}
+
+func TestStaticToken(t *testing.T) {
+	token, err := StaticToken("abc").Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "abc", token)
+}
@@ -0,0 +1,27 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReal(t *testing.T) {
+	c := New()
+
+	before := time.Now()
+	assert.False(t, c.Now().Before(before))
+	assert.GreaterOrEqual(t, c.Since(before), time.Duration(0))
+
+	<-c.After(time.Millisecond)
+	c.Sleep(time.Millisecond)
+
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+	assert.False(t, timer.Stop())
+
+	ticker := c.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+}
@@ -0,0 +1,101 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func received(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFake_Now(t *testing.T) {
+	f := NewFake(epoch)
+	assert.Equal(t, epoch, f.Now())
+
+	f.Advance(time.Minute)
+	assert.Equal(t, epoch.Add(time.Minute), f.Now())
+	assert.Equal(t, time.Minute, f.Since(epoch))
+
+	f.Set(epoch.Add(time.Hour))
+	assert.Equal(t, epoch.Add(time.Hour), f.Now())
+}
+
+func TestFake_After(t *testing.T) {
+	f := NewFake(epoch)
+	ch := f.After(10 * time.Second)
+
+	f.Advance(9 * time.Second)
+	_, ok := received(ch)
+	assert.False(t, ok)
+
+	f.Advance(time.Second)
+	fired, ok := received(ch)
+	assert.True(t, ok)
+	assert.Equal(t, epoch.Add(10*time.Second), fired)
+	assert.Zero(t, f.Waiters())
+
+	// Non-positive durations fire immediately
+	_, ok = received(f.After(0))
+	assert.True(t, ok)
+}
+
+func TestFake_Timer(t *testing.T) {
+	f := NewFake(epoch)
+	timer := f.NewTimer(time.Second)
+	assert.Equal(t, 1, f.Waiters())
+
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+	f.Advance(time.Second)
+	_, ok := received(timer.C())
+	assert.False(t, ok)
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(epoch)
+	ticker := f.NewTicker(time.Second)
+
+	f.Advance(time.Second)
+	_, ok := received(ticker.C())
+	assert.True(t, ok)
+
+	// Missed ticks are dropped
+	f.Advance(5 * time.Second)
+	_, ok = received(ticker.C())
+	assert.True(t, ok)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+
+	ticker.Stop()
+	f.Advance(time.Second)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+	assert.Panics(t, func() { f.NewTicker(0) })
+}
+
+func TestFake_SleepAndBlockUntil(t *testing.T) {
+	f := NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Minute)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sleeper was not woken up")
+	}
+}
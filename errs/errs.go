@@ -0,0 +1,172 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error independently of the layer it was raised in
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindNotFound
+	KindConflict
+	KindInvalid
+	KindUnauthorized
+	KindUnavailable
+)
+
+// String returns the kind name
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindInvalid:
+		return "invalid"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindUnavailable:
+		return "unavailable"
+	default:
+		return "unknown"
+	}
+}
+
+// Sentinels to be used with errors.Is, they match any Error of the same kind
+var (
+	ErrNotFound     = &Error{Kind: KindNotFound}
+	ErrConflict     = &Error{Kind: KindConflict}
+	ErrInvalid      = &Error{Kind: KindInvalid}
+	ErrUnauthorized = &Error{Kind: KindUnauthorized}
+	ErrUnavailable  = &Error{Kind: KindUnavailable}
+)
+
+// FieldError describes an invalid field of an Invalid error
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+// Error is a domain error with a kind and optional metadata
+type Error struct {
+	Kind    Kind
+	Message string
+	Entity  string
+	ID      string
+	Fields  []FieldError
+	Err     error
+}
+
+// Error returns the message followed by the wrapped error, if any
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.String()
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel of the same kind
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return isSentinel(t) && t.Kind == e.Kind
+}
+
+func isSentinel(e *Error) bool {
+	return e.Message == "" && e.Entity == "" && e.ID == "" && e.Fields == nil && e.Err == nil
+}
+
+// WithEntity returns a copy of the error carrying the entity type and ID
+func (e *Error) WithEntity(entity string, id any) *Error {
+	c := *e
+	c.Entity = entity
+	c.ID = fmt.Sprint(id)
+	return &c
+}
+
+// WithField returns a copy of the error with an additional invalid field
+func (e *Error) WithField(path, message string) *Error {
+	c := *e
+	c.Fields = append(append([]FieldError(nil), e.Fields...), FieldError{Path: path, Message: message})
+	return &c
+}
+
+// WithCause returns a copy of the error wrapping err
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// New creates an error of the given kind
+func New(kind Kind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// NotFound creates a not found error for the entity
+func NotFound(entity string, id any) *Error {
+	return New(KindNotFound, "%s %v not found", entity, id).WithEntity(entity, id)
+}
+
+// Conflict creates a conflict error
+func Conflict(format string, args ...any) *Error {
+	return New(KindConflict, format, args...)
+}
+
+// Invalid creates an invalid input error
+func Invalid(format string, args ...any) *Error {
+	return New(KindInvalid, format, args...)
+}
+
+// Unauthorized creates an authorization error
+func Unauthorized(format string, args ...any) *Error {
+	return New(KindUnauthorized, format, args...)
+}
+
+// Unavailable creates an error for a dependency that cannot be reached
+func Unavailable(format string, args ...any) *Error {
+	return New(KindUnavailable, format, args...)
+}
+
+// Wrap wraps err into an error of the given kind, returning nil if err is nil
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err, Message: kind.String()}
+}
+
+// KindOf returns the kind of the first Error in the chain, KindUnknown if none
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindUnknown
+}
+
+// FieldsOf collects the field errors found in the chain
+func FieldsOf(err error) []FieldError {
+	var fields []FieldError
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			fields = append(fields, e.Fields...)
+		}
+		err = errors.Unwrap(err)
+	}
+	return fields
+}
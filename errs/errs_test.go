@@ -0,0 +1,124 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKind_String(t *testing.T) {
+	assert.Equal(t, "not found", KindNotFound.String())
+	assert.Equal(t, "conflict", KindConflict.String())
+	assert.Equal(t, "invalid", KindInvalid.String())
+	assert.Equal(t, "unauthorized", KindUnauthorized.String())
+	assert.Equal(t, "unavailable", KindUnavailable.String())
+	assert.Equal(t, "unknown", KindUnknown.String())
+}
+
+func TestConstructors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          *Error
+		expectedKind Kind
+		expectedMsg  string
+		sentinel     error
+	}{
+		{
+			name:         "NotFound",
+			err:          NotFound("service", 42),
+			expectedKind: KindNotFound,
+			expectedMsg:  "service 42 not found",
+			sentinel:     ErrNotFound,
+		},
+		{
+			name:         "Conflict",
+			err:          Conflict("name %q already used", "db"),
+			expectedKind: KindConflict,
+			expectedMsg:  `name "db" already used`,
+			sentinel:     ErrConflict,
+		},
+		{
+			name:         "Invalid",
+			err:          Invalid("bad input"),
+			expectedKind: KindInvalid,
+			expectedMsg:  "bad input",
+			sentinel:     ErrInvalid,
+		},
+		{
+			name:         "Unauthorized",
+			err:          Unauthorized("not allowed"),
+			expectedKind: KindUnauthorized,
+			expectedMsg:  "not allowed",
+			sentinel:     ErrUnauthorized,
+		},
+		{
+			name:         "Unavailable",
+			err:          Unavailable("db down"),
+			expectedKind: KindUnavailable,
+			expectedMsg:  "db down",
+			sentinel:     ErrUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedKind, tt.err.Kind)
+			assert.EqualError(t, tt.err, tt.expectedMsg)
+			assert.ErrorIs(t, tt.err, tt.sentinel)
+			assert.ErrorIs(t, fmt.Errorf("wrapped: %w", tt.err), tt.sentinel)
+			assert.Equal(t, tt.expectedKind, KindOf(fmt.Errorf("wrapped: %w", tt.err)))
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	err := NotFound("service", 1)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrConflict)
+	// Non sentinel errors are compared by identity only
+	assert.NotErrorIs(t, err, NotFound("service", 1))
+	assert.ErrorIs(t, err, err)
+}
+
+func TestError_Metadata(t *testing.T) {
+	cause := errors.New("record not found")
+	err := NotFound("service", "abc").WithCause(cause)
+
+	var target *Error
+	require.ErrorAs(t, fmt.Errorf("get: %w", err), &target)
+	assert.Equal(t, "service", target.Entity)
+	assert.Equal(t, "abc", target.ID)
+	assert.ErrorIs(t, err, cause)
+	assert.EqualError(t, err, "service abc not found: record not found")
+
+	// With* methods do not modify the original
+	base := Invalid("invalid")
+	withField := base.WithField("name", "required").WithField("port", "too low")
+	assert.Empty(t, base.Fields)
+	assert.Equal(t, []FieldError{{"name", "required"}, {"port", "too low"}}, withField.Fields)
+	assert.Equal(t, withField.Fields, FieldsOf(fmt.Errorf("wrapped: %w", withField)))
+
+	withEntity := base.WithEntity("agent", 7)
+	assert.Empty(t, base.Entity)
+	assert.Equal(t, "7", withEntity.ID)
+}
+
+func TestWrap(t *testing.T) {
+	assert.Nil(t, Wrap(KindUnavailable, nil))
+
+	cause := errors.New("connection refused")
+	err := Wrap(KindUnavailable, cause)
+	assert.EqualError(t, err, "unavailable: connection refused")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, KindUnknown, KindOf(errors.New("plain")))
+	assert.Equal(t, KindUnknown, KindOf(nil))
+	assert.Equal(t, KindConflict, KindOf(Conflict("dup")))
+}
@@ -0,0 +1,38 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/fulcrumproject/commons/properties"
+)
+
+// Event represents a domain event to be written in the outbox
+type Event struct {
+	Topic   string
+	Key     string
+	Payload properties.JSON
+}
+
+// Message represents an event delivered to the broker by the relay
+type Message struct {
+	ID         properties.UUID
+	Topic      string
+	Key        string
+	Payload    properties.JSON
+	OccurredAt time.Time
+}
+
+// Broker publishes messages to the underlying transport (bus, queue, webhook...)
+// Implementations must be idempotent-friendly: the relay may deliver the same message more than once
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// BrokerFunc adapts a function to the Broker interface
+type BrokerFunc func(ctx context.Context, msg Message) error
+
+// Publish calls the underlying function
+func (f BrokerFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
@@ -0,0 +1,154 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxEntry is the persisted form of an event waiting to be relayed
+type OutboxEntry struct {
+	ID          properties.UUID `gorm:"type:uuid;primaryKey"`
+	Topic       string          `gorm:"not null;index"`
+	Key         string
+	Payload     properties.JSON
+	CreatedAt   time.Time  `gorm:"not null;index"`
+	PublishedAt *time.Time `gorm:"index"`
+	Attempts    int        `gorm:"not null;default:0"`
+	LastError   string
+	// NextAttemptAt delays the retry of a failed entry, nil until the first failure
+	NextAttemptAt *time.Time `gorm:"index"`
+	// ClaimedUntil is the end of the lease of the relay publishing the entry, nil when not claimed
+	ClaimedUntil *time.Time `gorm:"index"`
+}
+
+// TableName returns the outbox table name
+func (OutboxEntry) TableName() string {
+	return "outbox_events"
+}
+
+// Message converts the entry into the message handed to the broker
+func (e *OutboxEntry) Message() Message {
+	return Message{
+		ID:         e.ID,
+		Topic:      e.Topic,
+		Key:        e.Key,
+		Payload:    e.Payload,
+		OccurredAt: e.CreatedAt,
+	}
+}
+
+// Enqueue writes the events in the outbox using the given transaction
+// It must be called with the same transaction used for the domain changes so both commit or rollback together
+func Enqueue(tx *gorm.DB, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	entries := make([]OutboxEntry, 0, len(events))
+	for _, e := range events {
+		entries = append(entries, OutboxEntry{
+			ID:      properties.NewUUID(),
+			Topic:   e.Topic,
+			Key:     e.Key,
+			Payload: e.Payload,
+		})
+	}
+	return tx.Create(&entries).Error
+}
+
+// GormStore implements Store on top of the outbox table
+type GormStore struct {
+	db           *gorm.DB
+	clock        clock.Clock
+	claimTimeout time.Duration
+}
+
+// GormStoreOption configures a GormStore
+type GormStoreOption func(*GormStore)
+
+// WithStoreClock sets the clock used for the publication and retry times
+func WithStoreClock(c clock.Clock) GormStoreOption {
+	return func(s *GormStore) {
+		s.clock = c
+	}
+}
+
+// WithClaimTimeout sets how long fetched entries are reserved to the relay, defaults to 5m
+// It must exceed the time needed to publish a batch, expired claims are fetched again by any relay
+func WithClaimTimeout(d time.Duration) GormStoreOption {
+	return func(s *GormStore) {
+		if d > 0 {
+			s.claimTimeout = d
+		}
+	}
+}
+
+// NewGormStore creates a new outbox store
+func NewGormStore(db *gorm.DB, opts ...GormStoreOption) *GormStore {
+	s := &GormStore{db: db, clock: clock.New(), claimTimeout: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// FetchPending claims and returns up to limit unpublished entries due for an attempt, oldest first
+// Entries that already failed maxAttempts times are skipped, zero means no limit
+// Claimed entries are not returned to other relays until they are marked or the claim timeout elapses
+func (s *GormStore) FetchPending(ctx context.Context, limit int, maxAttempts int) ([]OutboxEntry, error) {
+	now := s.clock.Now()
+	db := s.db.WithContext(ctx)
+	unclaimed := "published_at IS NULL AND (claimed_until IS NULL OR claimed_until <= ?)"
+
+	due := db.Model(&OutboxEntry{}).Select("id").
+		Where(unclaimed, now).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now)
+	if maxAttempts > 0 {
+		due = due.Where("attempts < ?", maxAttempts)
+	}
+	due = due.Order("created_at").Limit(limit)
+
+	// The claim condition is checked again by the update, a concurrent relay claiming the same rows first wins
+	var entries []OutboxEntry
+	err := db.Model(&entries).Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Where(unclaimed, now).
+		Update("claimed_until", now.Add(s.claimTimeout)).Error
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+// MarkPublished flags the entry as delivered
+func (s *GormStore) MarkPublished(ctx context.Context, id properties.UUID) error {
+	return s.db.WithContext(ctx).Model(&OutboxEntry{}).
+		Where("id = ?", id).
+		Update("published_at", s.clock.Now()).Error
+}
+
+// MarkFailed records a failed delivery attempt, the entry isn't fetched again before retryAt
+func (s *GormStore) MarkFailed(ctx context.Context, id properties.UUID, cause error, retryAt time.Time) error {
+	return s.db.WithContext(ctx).Model(&OutboxEntry{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      cause.Error(),
+			"next_attempt_at": retryAt,
+			"claimed_until":   nil,
+		}).Error
+}
+
+// DeletePublished removes entries published before the given time
+func (s *GormStore) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).
+		Where("published_at IS NOT NULL AND published_at < ?", before).
+		Delete(&OutboxEntry{})
+	return res.RowsAffected, res.Error
+}
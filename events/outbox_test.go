@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	// Each in-memory connection is a separate database, keep a single one
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&OutboxEntry{}))
+	return db
+}
+
+func TestEnqueue(t *testing.T) {
+	t.Run("Commit writes events", func(t *testing.T) {
+		db := newTestDB(t)
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return Enqueue(tx,
+				Event{Topic: "service.created", Key: "a", Payload: properties.JSON{"name": "a"}},
+				Event{Topic: "service.deleted", Key: "b"},
+			)
+		})
+		require.NoError(t, err)
+
+		var entries []OutboxEntry
+		require.NoError(t, db.Order("topic").Find(&entries).Error)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "service.created", entries[0].Topic)
+		assert.Equal(t, "a", entries[0].Payload["name"])
+		assert.Nil(t, entries[0].PublishedAt)
+		assert.False(t, entries[0].CreatedAt.IsZero())
+	})
+
+	t.Run("Rollback discards events", func(t *testing.T) {
+		db := newTestDB(t)
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := Enqueue(tx, Event{Topic: "service.created"}); err != nil {
+				return err
+			}
+			return errors.New("domain failure")
+		})
+		require.Error(t, err)
+
+		var count int64
+		require.NoError(t, db.Model(&OutboxEntry{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("No events", func(t *testing.T) {
+		db := newTestDB(t)
+		assert.NoError(t, Enqueue(db))
+	})
+}
+
+func TestGormStore(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	clk := clock.NewFake(time.Now())
+	store := NewGormStore(db, WithStoreClock(clk), WithClaimTimeout(time.Second))
+
+	require.NoError(t, Enqueue(db, Event{Topic: "first"}))
+	require.NoError(t, Enqueue(db, Event{Topic: "second"}))
+	require.NoError(t, Enqueue(db, Event{Topic: "third"}))
+
+	pending, err := store.FetchPending(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+
+	// Claimed entries are not fetched again
+	claimed, err := store.FetchPending(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// Publish the first, fail the second twice
+	require.NoError(t, store.MarkPublished(ctx, pending[0].ID))
+	require.NoError(t, store.MarkFailed(ctx, pending[1].ID, errors.New("boom"), clk.Now()))
+	require.NoError(t, store.MarkFailed(ctx, pending[1].ID, errors.New("boom again"), clk.Now().Add(time.Minute)))
+
+	var published OutboxEntry
+	require.NoError(t, db.First(&published, "id = ?", pending[0].ID).Error)
+	require.NotNil(t, published.PublishedAt)
+	assert.True(t, clk.Now().Equal(*published.PublishedAt), "publication time comes from the clock")
+
+	// The failed entry waits for its retry time without blocking the next ones, whose claim expired
+	clk.Advance(time.Second)
+	pending, err = store.FetchPending(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "third", pending[0].Topic)
+
+	clk.Advance(time.Minute)
+	pending, err = store.FetchPending(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "second", pending[0].Topic)
+	assert.Equal(t, 2, pending[0].Attempts)
+	assert.Equal(t, "boom again", pending[0].LastError)
+
+	// Max attempts excludes the failing entry
+	clk.Advance(time.Second)
+	pending, err = store.FetchPending(ctx, 10, 2)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "third", pending[0].Topic)
+
+	// Published entries can be purged
+	deleted, err := store.DeletePublished(ctx, clk.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
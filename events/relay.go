@@ -0,0 +1,174 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/fulcrumproject/commons/retry"
+)
+
+// Store defines the outbox operations needed by the relay
+type Store interface {
+	FetchPending(ctx context.Context, limit int, maxAttempts int) ([]OutboxEntry, error)
+	MarkPublished(ctx context.Context, id properties.UUID) error
+	MarkFailed(ctx context.Context, id properties.UUID, cause error, retryAt time.Time) error
+}
+
+// Metrics receives the relay instrumentation
+type Metrics interface {
+	// ObservePublished is called after a message is accepted by the broker, lag is the time spent in the outbox
+	ObservePublished(topic string, lag time.Duration)
+	// ObserveFailed is called after the broker rejects a message
+	ObserveFailed(topic string)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObservePublished(string, time.Duration) {}
+func (noopMetrics) ObserveFailed(string)                   {}
+
+// RelayOption configures a Relay
+type RelayOption func(*Relay)
+
+// WithPollInterval sets the interval between outbox polls
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.pollInterval = d
+	}
+}
+
+// WithBatchSize sets the maximum number of entries fetched per poll, values below 1 keep the default of 100
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithMaxAttempts sets the number of failed attempts after which an entry is no longer relayed
+func WithMaxAttempts(n int) RelayOption {
+	return func(r *Relay) {
+		r.maxAttempts = n
+	}
+}
+
+// WithBackoff sets the delay before a failed entry is relayed again, only the delay settings of the policy are used
+// Defaults to a jittered exponential backoff from 1s to 5m
+func WithBackoff(p retry.Policy) RelayOption {
+	return func(r *Relay) {
+		r.backoff = p
+	}
+}
+
+// WithMetrics sets the metrics receiver
+func WithMetrics(m Metrics) RelayOption {
+	return func(r *Relay) {
+		r.metrics = m
+	}
+}
+
+// WithClock sets the clock used for polling and lag measurement
+func WithClock(c clock.Clock) RelayOption {
+	return func(r *Relay) {
+		r.clock = c
+	}
+}
+
+// Relay moves outbox entries to the broker with at-least-once delivery:
+// an entry is marked as published only after the broker accepted it, so a crash
+// in between results in a redelivery and consumers must be idempotent
+type Relay struct {
+	store        Store
+	broker       Broker
+	metrics      Metrics
+	clock        clock.Clock
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	backoff      retry.Policy
+}
+
+// NewRelay creates a new relay worker
+func NewRelay(store Store, broker Broker, opts ...RelayOption) *Relay {
+	r := &Relay{
+		store:        store,
+		broker:       broker,
+		metrics:      noopMetrics{},
+		clock:        clock.New(),
+		pollInterval: time.Second,
+		batchSize:    100,
+		backoff: retry.Policy{
+			InitialDelay: time.Second,
+			MaxDelay:     5 * time.Minute,
+			Multiplier:   2,
+			Jitter:       0.2,
+		},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run polls the outbox until the context is cancelled
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := r.clock.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain full batches without waiting for the next tick, unless the broker is failing
+		for {
+			n, failed, err := r.relayBatch(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "outbox relay failed", "error", err)
+				break
+			}
+			if n < r.batchSize || failed > 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+		}
+	}
+}
+
+// RelayBatch publishes a single batch of pending entries and returns how many were fetched
+// Failed entries are retried after the backoff delay
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	n, _, err := r.relayBatch(ctx)
+	return n, err
+}
+
+func (r *Relay) relayBatch(ctx context.Context) (n int, failed int, err error) {
+	entries, err := r.store.FetchPending(ctx, r.batchSize, r.maxAttempts)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if err := r.broker.Publish(ctx, entry.Message()); err != nil {
+			failed++
+			r.metrics.ObserveFailed(entry.Topic)
+			retryAt := r.clock.Now().Add(r.backoff.Delay(entry.Attempts + 1))
+			if err := r.store.MarkFailed(ctx, entry.ID, err, retryAt); err != nil {
+				return len(entries), failed, err
+			}
+			continue
+		}
+		if err := r.store.MarkPublished(ctx, entry.ID); err != nil {
+			return len(entries), failed, err
+		}
+		r.metrics.ObservePublished(entry.Topic, r.clock.Since(entry.CreatedAt))
+	}
+
+	return len(entries), failed, nil
+}
@@ -0,0 +1,214 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/fulcrumproject/commons/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingBroker struct {
+	mu       sync.Mutex
+	messages []Message
+	fail     map[string]bool
+	attempts int
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts++
+	if b.fail[msg.Topic] {
+		return errors.New("broker unavailable")
+	}
+	b.messages = append(b.messages, msg)
+	return nil
+}
+
+func (b *recordingBroker) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.messages)
+}
+
+func (b *recordingBroker) attempted() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attempts
+}
+
+type recordingMetrics struct {
+	published []string
+	failed    []string
+}
+
+func (m *recordingMetrics) ObservePublished(topic string, lag time.Duration) {
+	m.published = append(m.published, topic)
+}
+
+func (m *recordingMetrics) ObserveFailed(topic string) {
+	m.failed = append(m.failed, topic)
+}
+
+func TestRelay_RelayBatch(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	clk := clock.NewFake(time.Now())
+	store := NewGormStore(db, WithStoreClock(clk))
+	broker := &recordingBroker{fail: map[string]bool{"broken": true}}
+	metrics := &recordingMetrics{}
+	relay := NewRelay(store, broker, WithBatchSize(10), WithMetrics(metrics), WithClock(clk),
+		WithBackoff(retry.Policy{InitialDelay: time.Second, Multiplier: 2}))
+
+	require.NoError(t, Enqueue(db, Event{Topic: "ok", Key: "k1"}, Event{Topic: "broken"}))
+
+	n, err := relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, broker.messages, 1)
+	assert.Equal(t, "ok", broker.messages[0].Topic)
+	assert.Equal(t, "k1", broker.messages[0].Key)
+	assert.Equal(t, []string{"ok"}, metrics.published)
+	assert.Equal(t, []string{"broken"}, metrics.failed)
+
+	// Failed entry is retried after the backoff, published one is not
+	broker.fail = nil
+	n, err = relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	clk.Advance(time.Second)
+	n, err = relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, broker.messages, 2)
+	assert.Equal(t, "broken", broker.messages[1].Topic)
+}
+
+func TestWithBatchSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		expected int
+	}{
+		{name: "Custom", size: 10, expected: 10},
+		{name: "Zero keeps default", size: 0, expected: 100},
+		{name: "Negative keeps default", size: -1, expected: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relay := NewRelay(nil, nil, WithBatchSize(tt.size))
+			assert.Equal(t, tt.expected, relay.batchSize)
+		})
+	}
+}
+
+func TestRelay_ConcurrentRelays(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	store := NewGormStore(db)
+	broker := &recordingBroker{}
+
+	events := make([]Event, 50)
+	for i := range events {
+		events[i] = Event{Topic: "topic"}
+	}
+	require.NoError(t, Enqueue(db, events...))
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 2 {
+		relay := NewRelay(store, broker, WithBatchSize(5))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for {
+				n, err := relay.RelayBatch(ctx)
+				if !assert.NoError(t, err) || n == 0 {
+					return
+				}
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	ids := map[properties.UUID]bool{}
+	for _, msg := range broker.messages {
+		ids[msg.ID] = true
+	}
+	assert.Len(t, ids, 50)
+	assert.Equal(t, 50, len(broker.messages), "entries are published once")
+}
+
+func TestRelay_MaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	clk := clock.NewFake(time.Now())
+	broker := &recordingBroker{fail: map[string]bool{"broken": true}}
+	relay := NewRelay(NewGormStore(db, WithStoreClock(clk)), broker, WithMaxAttempts(1), WithClock(clk))
+
+	require.NoError(t, Enqueue(db, Event{Topic: "broken"}))
+
+	n, err := relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	clk.Advance(time.Hour)
+	n, err = relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestRelay_Run(t *testing.T) {
+	db := newTestDB(t)
+	broker := &recordingBroker{}
+	relay := NewRelay(NewGormStore(db), broker, WithPollInterval(5*time.Millisecond))
+
+	require.NoError(t, Enqueue(db, Event{Topic: "a"}, Event{Topic: "b"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	assert.Eventually(t, func() bool { return broker.count() == 2 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestRelay_RunStopsDrainingOnFailure(t *testing.T) {
+	db := newTestDB(t)
+	clk := clock.NewFake(time.Now())
+	broker := &recordingBroker{fail: map[string]bool{"broken": true}}
+	relay := NewRelay(NewGormStore(db, WithStoreClock(clk)), broker, WithBatchSize(1), WithClock(clk),
+		WithBackoff(retry.Policy{InitialDelay: time.Hour}))
+
+	require.NoError(t, Enqueue(db, Event{Topic: "broken"}))
+	require.NoError(t, Enqueue(db, Event{Topic: "ok"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	// The full batch failed, the next one waits for the tick
+	assert.Eventually(t, func() bool { return broker.attempted() == 1 }, time.Second, time.Millisecond)
+	assert.Never(t, func() bool { return broker.attempted() > 1 }, 50*time.Millisecond, 5*time.Millisecond)
+
+	// The failed head entry is backing off and doesn't block the next one
+	clk.Advance(time.Second)
+	assert.Eventually(t, func() bool { return broker.count() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, broker.attempted())
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
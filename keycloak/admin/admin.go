@@ -0,0 +1,200 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/fulcrumproject/commons/client"
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/fulcrumproject/commons/keycloak"
+)
+
+// User is a Keycloak user representation
+type User struct {
+	ID            string              `json:"id,omitempty"`
+	Username      string              `json:"username"`
+	Email         string              `json:"email,omitempty"`
+	FirstName     string              `json:"firstName,omitempty"`
+	LastName      string              `json:"lastName,omitempty"`
+	Enabled       bool                `json:"enabled"`
+	EmailVerified bool                `json:"emailVerified"`
+	Attributes    map[string][]string `json:"attributes,omitempty"`
+}
+
+// Role is a Keycloak realm or client role representation
+type Role struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ClientRole  bool   `json:"clientRole,omitempty"`
+	ContainerID string `json:"containerId,omitempty"`
+}
+
+type clientRepresentation struct {
+	ID       string `json:"id"`
+	ClientID string `json:"clientId"`
+}
+
+// Client is a minimal Keycloak Admin REST API client authenticated with client credentials,
+// the service account of the client needs the realm-management roles of the performed operations
+type Client struct {
+	api *client.Client
+}
+
+// Option configures the admin Client
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	tokens     client.TokenSource
+}
+
+// WithHTTPClient sets the HTTP client used for the admin API and the token requests
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithTokenSource replaces the client credentials token manager, e.g. to share one across clients
+func WithTokenSource(ts client.TokenSource) Option {
+	return func(o *options) {
+		o.tokens = ts
+	}
+}
+
+// New creates an admin client for the realm of cfg
+func New(cfg *keycloak.Config, opts ...Option) (*Client, error) {
+	o := options{httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.tokens == nil {
+		tm, err := keycloak.NewTokenManager(cfg, keycloak.WithTokenHTTPClient(o.httpClient))
+		if err != nil {
+			return nil, err
+		}
+		o.tokens = tm
+	}
+	baseURL := fmt.Sprintf("%s/admin/realms/%s", cfg.KeycloakURL, url.PathEscape(cfg.Realm))
+	return &Client{api: client.New(baseURL, client.WithHTTPClient(o.httpClient), client.WithTokenSource(o.tokens))}, nil
+}
+
+// CreateUser creates the user and returns its id
+func (c *Client) CreateUser(ctx context.Context, user User) (string, error) {
+	if err := c.api.Do(ctx, http.MethodPost, "/users", nil, user, nil); err != nil {
+		return "", fmt.Errorf("cannot create user %s: %w", user.Username, err)
+	}
+	// Keycloak only returns the id in the Location header
+	created, err := c.FindUserByUsername(ctx, user.Username)
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// GetUser returns the user with the id
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	user, err := client.Get[User](ctx, c.api, "/users/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get user %s: %w", id, err)
+	}
+	return &user, nil
+}
+
+// FindUserByUsername returns the user with the exact username
+func (c *Client) FindUserByUsername(ctx context.Context, username string) (*User, error) {
+	users, err := client.Get[[]User](ctx, c.api, "/users", url.Values{"username": {username}, "exact": {"true"}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find user %s: %w", username, err)
+	}
+	if len(users) == 0 {
+		return nil, errs.NotFound("user", username)
+	}
+	return &users[0], nil
+}
+
+// UpdateUser replaces the user representation
+func (c *Client) UpdateUser(ctx context.Context, user User) error {
+	if err := c.api.Do(ctx, http.MethodPut, "/users/"+url.PathEscape(user.ID), nil, user, nil); err != nil {
+		return fmt.Errorf("cannot update user %s: %w", user.ID, err)
+	}
+	return nil
+}
+
+// SetUserAttributes sets the custom attributes of the user (e.g. participant_id), other attributes are preserved
+func (c *Client) SetUserAttributes(ctx context.Context, id string, attrs map[string][]string) error {
+	user, err := c.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Attributes == nil {
+		user.Attributes = make(map[string][]string, len(attrs))
+	}
+	for k, v := range attrs {
+		user.Attributes[k] = v
+	}
+	return c.UpdateUser(ctx, *user)
+}
+
+// DeleteUser deletes the user
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	if err := client.Delete(ctx, c.api, "/users/"+url.PathEscape(id)); err != nil {
+		return fmt.Errorf("cannot delete user %s: %w", id, err)
+	}
+	return nil
+}
+
+// AssignRealmRoles grants the realm roles to the user
+func (c *Client) AssignRealmRoles(ctx context.Context, userID string, roleNames ...string) error {
+	roles := make([]Role, 0, len(roleNames))
+	for _, name := range roleNames {
+		role, err := client.Get[Role](ctx, c.api, "/roles/"+url.PathEscape(name), nil)
+		if err != nil {
+			return fmt.Errorf("cannot get realm role %s: %w", name, err)
+		}
+		roles = append(roles, role)
+	}
+	path := "/users/" + url.PathEscape(userID) + "/role-mappings/realm"
+	if err := c.api.Do(ctx, http.MethodPost, path, nil, roles, nil); err != nil {
+		return fmt.Errorf("cannot assign realm roles to user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// AssignClientRoles grants the roles of the client, identified by its client id, to the user
+func (c *Client) AssignClientRoles(ctx context.Context, userID, clientID string, roleNames ...string) error {
+	clients, err := client.Get[[]clientRepresentation](ctx, c.api, "/clients", url.Values{"clientId": {clientID}})
+	if err != nil {
+		return fmt.Errorf("cannot find client %s: %w", clientID, err)
+	}
+	if len(clients) == 0 {
+		return errs.NotFound("client", clientID)
+	}
+	clientPath := "/clients/" + url.PathEscape(clients[0].ID)
+
+	roles := make([]Role, 0, len(roleNames))
+	for _, name := range roleNames {
+		role, err := client.Get[Role](ctx, c.api, clientPath+"/roles/"+url.PathEscape(name), nil)
+		if err != nil {
+			return fmt.Errorf("cannot get client role %s: %w", name, err)
+		}
+		roles = append(roles, role)
+	}
+	path := "/users/" + url.PathEscape(userID) + "/role-mappings" + clientPath
+	if err := c.api.Do(ctx, http.MethodPost, path, nil, roles, nil); err != nil {
+		return fmt.Errorf("cannot assign client roles to user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// RealmRoles returns the realm roles mapped to the user
+func (c *Client) RealmRoles(ctx context.Context, userID string) ([]Role, error) {
+	roles, err := client.Get[[]Role](ctx, c.api, "/users/"+url.PathEscape(userID)+"/role-mappings/realm", nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get realm roles of user %s: %w", userID, err)
+	}
+	return roles, nil
+}
@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/fulcrumproject/commons/client"
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/fulcrumproject/commons/keycloak"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeycloak is an in memory subset of the admin API of the realm "test"
+type fakeKeycloak struct {
+	mu          sync.Mutex
+	users       map[string]User
+	realmRoles  map[string][]Role
+	clientRoles map[string][]Role
+}
+
+func newFakeKeycloak(t *testing.T) *httptest.Server {
+	kc := &fakeKeycloak{users: map[string]User{}, realmRoles: map[string][]Role{}, clientRoles: map[string][]Role{}}
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	r := chi.NewRouter()
+	r.Post("/realms/test/protocol/openid-connect/token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, keycloak.TokenResponse{AccessToken: "admin-token", ExpiresIn: 300})
+	})
+	r.Route("/admin/realms/test", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer admin-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				kc.mu.Lock()
+				defer kc.mu.Unlock()
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+			var u User
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&u))
+			for _, existing := range kc.users {
+				if existing.Username == u.Username {
+					w.WriteHeader(http.StatusConflict)
+					writeJSON(w, map[string]string{"errorMessage": "User exists with same username"})
+					return
+				}
+			}
+			u.ID = "id-" + u.Username
+			kc.users[u.ID] = u
+			w.Header().Set("Location", r.URL.String()+"/"+u.ID)
+			w.WriteHeader(http.StatusCreated)
+		})
+		r.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "true", r.URL.Query().Get("exact"))
+			users := []User{}
+			for _, u := range kc.users {
+				if u.Username == r.URL.Query().Get("username") {
+					users = append(users, u)
+				}
+			}
+			writeJSON(w, users)
+		})
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			u, ok := kc.users[chi.URLParam(r, "id")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeJSON(w, u)
+		})
+		r.Put("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			var u User
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&u))
+			kc.users[chi.URLParam(r, "id")] = u
+			w.WriteHeader(http.StatusNoContent)
+		})
+		r.Delete("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			delete(kc.users, chi.URLParam(r, "id"))
+			w.WriteHeader(http.StatusNoContent)
+		})
+		r.Get("/roles/{name}", func(w http.ResponseWriter, r *http.Request) {
+			name := chi.URLParam(r, "name")
+			if name == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeJSON(w, Role{ID: "role-" + name, Name: name})
+		})
+		r.Get("/clients", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("clientId") != "fulcrum" {
+				writeJSON(w, []clientRepresentation{})
+				return
+			}
+			writeJSON(w, []clientRepresentation{{ID: "client-uuid", ClientID: "fulcrum"}})
+		})
+		r.Get("/clients/client-uuid/roles/{name}", func(w http.ResponseWriter, r *http.Request) {
+			name := chi.URLParam(r, "name")
+			writeJSON(w, Role{ID: "client-role-" + name, Name: name, ClientRole: true, ContainerID: "client-uuid"})
+		})
+		r.Post("/users/{id}/role-mappings/realm", func(w http.ResponseWriter, r *http.Request) {
+			var roles []Role
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&roles))
+			kc.realmRoles[chi.URLParam(r, "id")] = append(kc.realmRoles[chi.URLParam(r, "id")], roles...)
+			w.WriteHeader(http.StatusNoContent)
+		})
+		r.Get("/users/{id}/role-mappings/realm", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, kc.realmRoles[chi.URLParam(r, "id")])
+		})
+		r.Post("/users/{id}/role-mappings/clients/client-uuid", func(w http.ResponseWriter, r *http.Request) {
+			var roles []Role
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&roles))
+			kc.clientRoles[chi.URLParam(r, "id")] = append(kc.clientRoles[chi.URLParam(r, "id")], roles...)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(t *testing.T) *Client {
+	server := newFakeKeycloak(t)
+	c, err := New(&keycloak.Config{KeycloakURL: server.URL, Realm: "test", ClientID: "admin-cli", ClientSecret: "secret"})
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_Users(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	id, err := c.CreateUser(ctx, User{Username: "alice", Email: "alice@example.com", Enabled: true, Attributes: map[string][]string{"locale": {"en"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "id-alice", id)
+
+	_, err = c.CreateUser(ctx, User{Username: "alice"})
+	assert.Equal(t, errs.KindConflict, errs.KindOf(err))
+
+	require.NoError(t, c.SetUserAttributes(ctx, id, map[string][]string{"participant_id": {"b7a3c4f0-0000-0000-0000-000000000001"}}))
+	user, err := c.GetUser(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"locale": {"en"}, "participant_id": {"b7a3c4f0-0000-0000-0000-000000000001"}}, user.Attributes)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	require.NoError(t, c.DeleteUser(ctx, id))
+	_, err = c.GetUser(ctx, id)
+	assert.Equal(t, errs.KindNotFound, errs.KindOf(err))
+	_, err = c.FindUserByUsername(ctx, "alice")
+	assert.Equal(t, errs.KindNotFound, errs.KindOf(err))
+}
+
+func TestClient_AssignRoles(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+	id, err := c.CreateUser(ctx, User{Username: "bob", Enabled: true})
+	require.NoError(t, err)
+
+	require.NoError(t, c.AssignRealmRoles(ctx, id, "participant", "fulcrum-access"))
+	roles, err := c.RealmRoles(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []Role{{ID: "role-participant", Name: "participant"}, {ID: "role-fulcrum-access", Name: "fulcrum-access"}}, roles)
+
+	err = c.AssignRealmRoles(ctx, id, "missing")
+	assert.ErrorContains(t, err, "cannot get realm role missing")
+	assert.Equal(t, errs.KindNotFound, errs.KindOf(err))
+
+	require.NoError(t, c.AssignClientRoles(ctx, id, "fulcrum", "agent"))
+	err = c.AssignClientRoles(ctx, id, "other", "agent")
+	assert.Equal(t, errs.KindNotFound, errs.KindOf(err))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(&keycloak.Config{KeycloakURL: "http://localhost", Realm: "test"})
+	assert.EqualError(t, err, "token manager requires client id and client secret")
+
+	_, err = New(&keycloak.Config{KeycloakURL: "http://localhost", Realm: "test"}, WithTokenSource(client.StaticToken("token")))
+	assert.NoError(t, err)
+}
@@ -2,21 +2,36 @@ package keycloak
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
 	"github.com/fulcrumproject/commons/properties"
+	"github.com/fulcrumproject/commons/retry"
 )
 
 // Claims represents the custom claims structure from Keycloak JWT tokens
 type Claims struct {
-	Role              string `json:"role,omitempty"`
-	ParticipantID     string `json:"participant_id,omitempty"`
-	AgentID           string `json:"agent_id,omitempty"`
-	Name              string `json:"name,omitempty"`
-	PreferredUsername string `json:"preferred_username,omitempty"`
+	Role              string   `json:"role,omitempty"`
+	ParticipantID     string   `json:"participant_id,omitempty"`
+	AgentID           string   `json:"agent_id,omitempty"`
+	OrganizationID    string   `json:"organization_id,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	AuthorizedParty   string   `json:"azp,omitempty"`
+	ClientID          string   `json:"client_id,omitempty"`
+	LegacyClientID    string   `json:"clientId,omitempty"`
+	Scope             string   `json:"scope,omitempty"`
+	Groups            []string `json:"groups,omitempty"`
 	RealmAccess       struct {
 		Roles []string `json:"roles"`
 	} `json:"realm_access,omitempty"`
@@ -25,26 +40,187 @@ type Claims struct {
 	} `json:"resource_access,omitempty"`
 }
 
+// mappedClaims are the claims mapped to identity fields or used by the OIDC protocol, the others become identity attributes
+var mappedClaims = []string{
+	"role", "participant_id", "agent_id", "organization_id", "name", "preferred_username",
+	"realm_access", "resource_access", "azp", "client_id", "clientId", "scope", "groups",
+	"typ", "acr", "sid", "session_state", "auth_time", "nonce", "at_hash", "allowed-origins",
+	"active", "token_type", "username",
+}
+
+var (
+	// ErrInvalidAudience is returned when audience validation is enabled and the token has none of the allowed audiences
+	ErrInvalidAudience = errors.New("token audience not allowed")
+	// ErrInvalidAuthorizedParty is returned when authorized party validation is enabled and the azp claim is not allowed
+	ErrInvalidAuthorizedParty = errors.New("token authorized party not allowed")
+	// ErrTokenNotYetValid is returned when the nbf claim is in the future beyond the clock skew leeway
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	// ErrTokenIssuedInFuture is returned when the iat claim is in the future beyond the clock skew leeway
+	ErrTokenIssuedInFuture = errors.New("token issued in the future")
+	// ErrMissingRequiredRole is returned when the token lacks one of the required realm or client roles
+	ErrMissingRequiredRole = errors.New("token missing required role")
+	// ErrNotReady is returned by a lazy authenticator until the OIDC discovery succeeds
+	ErrNotReady = errors.New("authenticator not ready")
+)
+
+// lazyRetryInterval is the minimum delay between discovery attempts of a lazy authenticator
+const lazyRetryInterval = time.Second
+
 // Authenticator implements domain.Authenticator using OIDC/Keycloak JWT tokens
 type Authenticator struct {
-	config   *Config
-	provider *oidc.Provider
-	verifier *oidc.IDTokenVerifier
+	config *Config
+	opts   options
+
+	initMu        sync.Mutex
+	initialized   atomic.Bool
+	provider      *oidc.Provider
+	verifier      *oidc.IDTokenVerifier
+	keys          *JWKSCache
+	userInfo      *userInfoClient
+	introspection *introspectionClient
+	tokens        *tokenCache
+	subjectID     subjectMapper
+	lastErr       error
+	lastAttempt   time.Time
+
+	refreshCtx context.Context
+	stop       context.CancelFunc
+}
+
+// Option configures the Authenticator
+type Option func(*options)
+
+type options struct {
+	jwksOptions    []JWKSOption
+	clock          clock.Clock
+	discoveryRetry retry.Policy
+	lazy           bool
+	metrics        Metrics
+	failureHooks   []FailureHook
+	httpClient     *http.Client
+}
+
+// WithClock sets the clock used by the authenticator caches and the lazy discovery throttling
+func WithClock(clk clock.Clock) Option {
+	return func(o *options) {
+		o.clock = clk
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the discovery, JWKS, userinfo and introspection calls,
+// by default it is built from the Config HTTP settings
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithKeySetOptions configures the JWKS cache used when Config.JWKSCacheTTL is set
+func WithKeySetOptions(opts ...JWKSOption) Option {
+	return func(o *options) {
+		o.jwksOptions = append(o.jwksOptions, opts...)
+	}
+}
+
+// WithDiscoveryRetry retries the OIDC discovery with the policy, e.g. retry.DefaultPolicy() to survive
+// Keycloak cold starts, by default discovery is attempted once
+func WithDiscoveryRetry(policy retry.Policy) Option {
+	return func(o *options) {
+		o.discoveryRetry = policy
+	}
+}
+
+// WithLazyDiscovery creates the authenticator without contacting Keycloak, the OIDC discovery runs on first use
+// Until it succeeds the calls fail with ErrNotReady, failed discoveries are attempted again at most once per second
+// so the service can start and report unhealthy through HealthCheck instead of crash looping
+func WithLazyDiscovery() Option {
+	return func(o *options) {
+		o.lazy = true
+	}
 }
 
 // NewAuthenticator creates a new OIDC JWT authenticator for Keycloak
-func NewAuthenticator(ctx context.Context, cfg *Config) (*Authenticator, error) {
-	// Create OIDC provider
-	provider, err := oidc.NewProvider(ctx, cfg.GetIssuer())
+// When JWKSCacheTTL is set the signing keys are cached for that many seconds and refreshed in the background until Close
+func NewAuthenticator(ctx context.Context, cfg *Config, opts ...Option) (*Authenticator, error) {
+	o := options{clock: clock.New(), discoveryRetry: retry.Policy{MaxAttempts: 1}, metrics: noopMetrics{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.httpClient == nil {
+		httpClient, err := cfg.HTTPClient()
+		if err != nil {
+			return nil, err
+		}
+		o.httpClient = httpClient
+	}
+	// the key set options given explicitly take precedence over the authenticator ones
+	o.jwksOptions = append([]JWKSOption{WithJWKSMetrics(o.metrics), WithJWKSHTTPClient(o.httpClient)}, o.jwksOptions...)
+
+	for name, role := range cfg.RoleMapping {
+		if err := auth.Role(role).Validate(); err != nil {
+			return nil, fmt.Errorf("invalid role mapping for %s: %w", name, err)
+		}
+	}
+
+	subjectID, err := newSubjectMapper(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authenticator{config: cfg, opts: o, subjectID: subjectID}
+	if cfg.VerifiedTokenCacheSize > 0 {
+		a.tokens = newTokenCache(cfg.VerifiedTokenCacheSize, o.clock)
+	}
+	a.refreshCtx, a.stop = context.WithCancel(context.Background())
+	if o.lazy {
+		return a, nil
+	}
+	if err := a.initialize(ctx); err != nil {
+		a.stop()
+		return nil, err
+	}
+	return a, nil
+}
+
+// initialize runs the OIDC discovery and sets up the verifier once
+func (a *Authenticator) initialize(ctx context.Context) error {
+	if a.initialized.Load() {
+		return nil
+	}
+	if !a.opts.lazy {
+		a.initMu.Lock()
+	} else if !a.initMu.TryLock() {
+		return fmt.Errorf("%w: discovery in progress", ErrNotReady)
+	}
+	defer a.initMu.Unlock()
+	if a.initialized.Load() {
+		return nil
+	}
+	if a.opts.lazy && a.lastErr != nil && a.opts.clock.Since(a.lastAttempt) < lazyRetryInterval {
+		return fmt.Errorf("%w: %w", ErrNotReady, a.lastErr)
+	}
+
+	cfg := a.config
+	err := retry.Do(ctx, a.opts.discoveryRetry, func(ctx context.Context) error {
+		return a.discover(ctx)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create OIDC provider: %w", err)
+		if a.opts.lazy {
+			a.lastErr, a.lastAttempt = err, a.opts.clock.Now()
+			return fmt.Errorf("%w: %w", ErrNotReady, err)
+		}
+		return err
 	}
+	a.lastErr = nil
 
 	// Configure the ID token verifier
 	verifierConfig := &oidc.Config{
 		ClientID: cfg.ClientID,
-		// Skip audience check since Keycloak may use different audiences
+		// Skip the single client id check, audiences are checked against the allowlist when enabled
 		SkipClientIDCheck: true,
+		// The validity window is checked with the configured leeway by checkValidity
+		SkipExpiryCheck: cfg.ClockSkewLeeway > 0,
+		Now:             a.opts.clock.Now,
 	}
 
 	// Skip issuer validation if configured
@@ -52,27 +228,137 @@ func NewAuthenticator(ctx context.Context, cfg *Config) (*Authenticator, error)
 		verifierConfig.SkipIssuerCheck = true
 	}
 
-	verifier := provider.Verifier(verifierConfig)
+	if a.keys != nil {
+		var discovery struct {
+			Algorithms []string `json:"id_token_signing_alg_values_supported"`
+		}
+		_ = a.provider.Claims(&discovery)
+		verifierConfig.SupportedSigningAlgs = discovery.Algorithms
+		a.keys.Start(a.refreshCtx)
+		a.verifier = oidc.NewVerifier(cfg.GetIssuer(), a.keys, verifierConfig)
+	} else {
+		a.verifier = a.provider.Verifier(verifierConfig)
+	}
+
+	a.initialized.Store(true)
+	return nil
+}
+
+// discover fetches the OIDC discovery document and the signing keys when cached explicitly
+func (a *Authenticator) discover(ctx context.Context) error {
+	cfg := a.config
+
+	// Create OIDC provider
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, a.opts.httpClient), cfg.GetIssuer())
+	if err != nil {
+		return fmt.Errorf("failed to create OIDC provider: %w", err)
+	}
+
+	var discovery struct {
+		JWKSURL          string `json:"jwks_uri"`
+		UserInfoURL      string `json:"userinfo_endpoint"`
+		IntrospectionURL string `json:"introspection_endpoint"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return retry.Permanent(fmt.Errorf("failed to read OIDC discovery: %w", err))
+	}
+
+	var userInfo *userInfoClient
+	if cfg.UserInfoEnrichment {
+		if discovery.UserInfoURL == "" {
+			return retry.Permanent(errors.New("userinfo enrichment requires the userinfo endpoint in the OIDC discovery"))
+		}
+		ttl := time.Duration(cfg.UserInfoCacheTTL) * time.Second
+		if ttl <= 0 {
+			ttl = 5 * time.Minute
+		}
+		userInfo = newUserInfoClient(discovery.UserInfoURL, ttl, a.opts.httpClient, a.opts.clock)
+	}
 
-	return &Authenticator{
-		config:   cfg,
-		provider: provider,
-		verifier: verifier,
-	}, nil
+	var introspection *introspectionClient
+	if cfg.IntrospectionFallback {
+		if discovery.IntrospectionURL == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return retry.Permanent(errors.New("introspection fallback requires the client credentials and the introspection endpoint in the OIDC discovery"))
+		}
+		introspection = &introspectionClient{url: discovery.IntrospectionURL, clientID: cfg.ClientID, clientSecret: cfg.ClientSecret, httpClient: a.opts.httpClient}
+	}
+
+	var keys *JWKSCache
+	if cfg.JWKSCacheTTL > 0 {
+		keys = NewJWKSCache(discovery.JWKSURL, time.Duration(cfg.JWKSCacheTTL)*time.Second, a.opts.jwksOptions...)
+		if err := keys.Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to fetch JWKS: %w", err)
+		}
+	}
+
+	a.provider, a.keys, a.userInfo, a.introspection = provider, keys, userInfo, introspection
+	return nil
+}
+
+// Ready reports whether the OIDC discovery succeeded
+func (a *Authenticator) Ready() bool {
+	return a.initialized.Load()
+}
+
+// HealthCheck attempts the pending discovery of a lazy authenticator and fails with ErrNotReady until it succeeds,
+// e.g. app.AddHealthCheck("keycloak", authenticator.HealthCheck)
+func (a *Authenticator) HealthCheck(ctx context.Context) error {
+	return a.initialize(ctx)
+}
+
+// Close stops the background key refresh
+func (a *Authenticator) Close() {
+	a.stop()
+}
+
+// rejections are the failure reasons definitively rejecting the token, the others like ErrNotReady or JWKS failures are transient
+var rejections = map[FailureReason]bool{
+	ReasonMalformed:              true,
+	ReasonExpired:                true,
+	ReasonInactive:               true,
+	ReasonInvalidSignature:       true,
+	ReasonInvalidIssuer:          true,
+	ReasonInvalidAudience:        true,
+	ReasonInvalidAuthorizedParty: true,
+	ReasonMissingRequiredRole:    true,
 }
 
 // Authenticate extracts and validates the JWT token against Keycloak
-// Returns nil if authentication fails
+// Returns nil if authentication fails, definitive rejections wrap auth.ErrInvalidToken
 func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*auth.Identity, error) {
-	// Verify the ID token
-	idToken, err := a.verifier.Verify(ctx, tokenString)
+	start := a.opts.clock.Now()
+	identity, err := a.authenticate(ctx, tokenString)
+	var reason FailureReason
 	if err != nil {
+		reason = classifyFailure(err)
+		if rejections[reason] {
+			err = fmt.Errorf("%w: %w", auth.ErrInvalidToken, err)
+		}
+		for _, hook := range a.opts.failureHooks {
+			hook(ctx, reason, err)
+		}
+	}
+	a.opts.metrics.ObserveVerification(reason, a.opts.clock.Since(start))
+	return identity, err
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, tokenString string) (*auth.Identity, error) {
+	if err := a.initialize(ctx); err != nil {
 		return nil, err
 	}
 
-	// Parse and validate the subject as UUID (identity ID)
-	id, err := properties.ParseUUID(idToken.Subject)
+	if a.tokens != nil {
+		if identity, ok := a.tokens.get(tokenString); ok {
+			return identity, nil
+		}
+	}
+
+	// Verify the ID token
+	idToken, err := a.verifier.Verify(ctx, tokenString)
 	if err != nil {
+		if a.introspection != nil && classifyFailure(err) == ReasonMalformed {
+			return a.authenticateOpaque(ctx, tokenString)
+		}
 		return nil, err
 	}
 
@@ -81,9 +367,59 @@ func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*
 	if err := idToken.Claims(&claims); err != nil {
 		return nil, err
 	}
+	var raw map[string]any
+	if err := idToken.Claims(&raw); err != nil {
+		return nil, err
+	}
+	if err := a.checkValidity(idToken, raw); err != nil {
+		return nil, err
+	}
+	identity, err := a.identity(ctx, tokenString, idToken.Subject, idToken.Audience, &claims, raw)
+	if err == nil && a.tokens != nil {
+		a.tokens.put(tokenString, identity, idToken.Expiry)
+	}
+	return identity, err
+}
+
+// authenticateOpaque validates a token that is not a JWT with the introspection endpoint
+func (a *Authenticator) authenticateOpaque(ctx context.Context, tokenString string) (*auth.Identity, error) {
+	body, err := a.introspection.introspect(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return nil, fmt.Errorf("cannot decode introspection claims: %w", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("cannot decode introspection claims: %w", err)
+	}
+	if iss, _ := raw["iss"].(string); a.config.ValidateIssuer && iss != a.config.GetIssuer() {
+		return nil, fmt.Errorf("introspected token issued by a different provider, expected %q got %q", a.config.GetIssuer(), iss)
+	}
+	subject, _ := raw["sub"].(string)
+	return a.identity(ctx, tokenString, subject, audienceClaim(raw), &claims, raw)
+}
+
+// identity maps the verified claims of the token to the identity
+func (a *Authenticator) identity(ctx context.Context, tokenString, subject string, audience []string, claims *Claims, raw map[string]any) (*auth.Identity, error) {
+	// Map the subject to the identity ID
+	id, err := a.subjectID(subject, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.checkAudience(audience, claims.AuthorizedParty); err != nil {
+		return nil, err
+	}
+
+	if err := a.checkRequiredRoles(claims); err != nil {
+		return nil, err
+	}
 
 	// Extract role from custom claim or realm roles
-	role, err := a.extractRole(&claims)
+	role, err := a.extractRole(claims)
 	if err != nil {
 		return nil, err
 	}
@@ -108,13 +444,40 @@ func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*
 		agentID = &aid
 	}
 
+	// Parse optional organization ID
+	var organizationID *properties.UUID
+	if claims.OrganizationID != "" {
+		oid, err := properties.ParseUUID(claims.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		organizationID = &oid
+	}
+
+	// Fall back to the scope encoded in the group paths
+	if participantID == nil {
+		if participantID, err = groupID(claims.Groups, a.config.ParticipantGroupPrefix); err != nil {
+			return nil, err
+		}
+	}
+	if agentID == nil {
+		if agentID, err = groupID(claims.Groups, a.config.AgentGroupPrefix); err != nil {
+			return nil, err
+		}
+	}
+	if organizationID == nil {
+		if organizationID, err = groupID(claims.Groups, a.config.OrganizationGroupPrefix); err != nil {
+			return nil, err
+		}
+	}
+
 	// Use preferred name or fallback to preferred_username
 	name := claims.Name
 	if name == "" {
 		name = claims.PreferredUsername
 	}
 	if name == "" {
-		name = idToken.Subject // Fallback to subject if no name available
+		name = subject // Fallback to subject if no name available
 	}
 
 	// Create the identity
@@ -122,10 +485,15 @@ func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*
 		ID:   id,
 		Name: name,
 		Role: role,
+		Kind: extractKind(claims),
 		Scope: auth.IdentityScope{
-			ParticipantID: participantID,
-			AgentID:       agentID,
+			ParticipantID:  participantID,
+			AgentID:        agentID,
+			OrganizationID: organizationID,
 		},
+		OAuthScopes: auth.ParseOAuthScopes(claims.Scope),
+		Attributes:  auth.ExtraClaims(raw, mappedClaims...),
+		Groups:      claims.Groups,
 	}
 
 	// Validate the identity to ensure it meets role-specific requirements
@@ -133,23 +501,63 @@ func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*
 		return nil, fmt.Errorf("invalid identity: %w", err)
 	}
 
+	// Best effort enrichment, a userinfo outage must not block authentication
+	if a.userInfo != nil {
+		if claims, err := a.userInfo.claims(ctx, subject, tokenString); err == nil {
+			enrich(identity, claims)
+		}
+	}
+
 	return identity, nil
 }
 
+// checkValidity enforces the exp, nbf and iat claims tolerating the clock skew leeway,
+// without leeway the expiry is checked by the verifier
+func (a *Authenticator) checkValidity(idToken *oidc.IDToken, raw map[string]any) error {
+	if a.config.ClockSkewLeeway <= 0 {
+		return nil
+	}
+	leeway := time.Duration(a.config.ClockSkewLeeway) * time.Second
+	now := a.opts.clock.Now()
+	if now.Add(-leeway).After(idToken.Expiry) {
+		return &oidc.TokenExpiredError{Expiry: idToken.Expiry}
+	}
+	if nbf, ok := raw["nbf"].(float64); ok {
+		if notBefore := time.Unix(int64(nbf), 0); now.Add(leeway).Before(notBefore) {
+			return fmt.Errorf("%w: nbf %s", ErrTokenNotYetValid, notBefore)
+		}
+	}
+	if !idToken.IssuedAt.IsZero() && now.Add(leeway).Before(idToken.IssuedAt) {
+		return fmt.Errorf("%w: iat %s", ErrTokenIssuedInFuture, idToken.IssuedAt)
+	}
+	return nil
+}
+
+// checkAudience enforces the audience and authorized party allowlists when enabled
+func (a *Authenticator) checkAudience(audience []string, authorizedParty string) error {
+	if a.config.ValidateAudience && !slices.ContainsFunc(audience, func(aud string) bool {
+		return slices.Contains(a.config.audiences(), aud)
+	}) {
+		return fmt.Errorf("%w: %s", ErrInvalidAudience, strings.Join(audience, ","))
+	}
+	if a.config.ValidateAuthorizedParty && !slices.Contains(a.config.authorizedParties(), authorizedParty) {
+		return fmt.Errorf("%w: %q", ErrInvalidAuthorizedParty, authorizedParty)
+	}
+	return nil
+}
+
 // extractRole extracts the role from Keycloak claims
 func (a *Authenticator) extractRole(claims *Claims) (auth.Role, error) {
 	// First check if there's a direct role claim
 	if claims.Role != "" {
-		role := auth.Role(claims.Role)
-		if err := role.Validate(); err == nil {
+		if role, ok := a.mapRole(claims.Role); ok {
 			return role, nil
 		}
 	}
 
 	// Check realm roles
 	for _, realmRole := range claims.RealmAccess.Roles {
-		role := auth.Role(realmRole)
-		if err := role.Validate(); err == nil {
+		if role, ok := a.mapRole(realmRole); ok {
 			return role, nil
 		}
 	}
@@ -157,8 +565,7 @@ func (a *Authenticator) extractRole(claims *Claims) (auth.Role, error) {
 	// Check client-specific roles
 	if clientRoles, exists := claims.ResourceAccess[a.config.ClientID]; exists {
 		for _, clientRole := range clientRoles.Roles {
-			role := auth.Role(clientRole)
-			if err := role.Validate(); err == nil {
+			if role, ok := a.mapRole(clientRole); ok {
 				return role, nil
 			}
 		}
@@ -166,3 +573,63 @@ func (a *Authenticator) extractRole(claims *Claims) (auth.Role, error) {
 
 	return "", errors.New("no valid role found in token")
 }
+
+// checkRequiredRoles requires each of the required roles among the realm roles or the client roles
+func (a *Authenticator) checkRequiredRoles(claims *Claims) error {
+	clientRoles := claims.ResourceAccess[a.config.ClientID].Roles
+	for _, required := range a.config.RequiredRoles {
+		if !slices.Contains(claims.RealmAccess.Roles, required) && !slices.Contains(clientRoles, required) {
+			return fmt.Errorf("%w: %s", ErrMissingRequiredRole, required)
+		}
+	}
+	return nil
+}
+
+// mapRole translates a Keycloak role name through the role mapping and reports whether it is a valid role
+func (a *Authenticator) mapRole(name string) (auth.Role, bool) {
+	if mapped, ok := a.config.RoleMapping[name]; ok {
+		name = mapped
+	}
+	role := auth.Role(name)
+	return role, role.Validate() == nil
+}
+
+// groupID extracts the id following the prefix in the group paths, e.g. /participants/<uuid> or /participants/<uuid>/admins
+// It fails when the groups carry different ids, an empty prefix disables the mapping
+func groupID(groups []string, prefix string) (*properties.UUID, error) {
+	if prefix == "" {
+		return nil, nil
+	}
+	var found *properties.UUID
+	for _, group := range groups {
+		rest, ok := strings.CutPrefix(group, prefix)
+		if !ok {
+			continue
+		}
+		segment, _, _ := strings.Cut(rest, "/")
+		id, err := properties.ParseUUID(segment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id in group %s: %w", group, err)
+		}
+		if found != nil && *found != id {
+			return nil, fmt.Errorf("ambiguous groups with prefix %s", prefix)
+		}
+		found = &id
+	}
+	return found, nil
+}
+
+// serviceAccountPrefix is the username prefix Keycloak gives to client service accounts
+const serviceAccountPrefix = "service-account-"
+
+// extractKind detects client credentials tokens, Keycloak adds the client id claim and a service account username to them
+// The azp claim alone is not enough since it is set on user tokens as well
+func extractKind(claims *Claims) auth.IdentityKind {
+	if claims.ClientID != "" || claims.LegacyClientID != "" {
+		return auth.KindServiceAccount
+	}
+	if claims.AuthorizedParty != "" && claims.PreferredUsername == serviceAccountPrefix+claims.AuthorizedParty {
+		return auth.KindServiceAccount
+	}
+	return auth.KindUser
+}
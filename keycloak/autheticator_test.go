@@ -1,10 +1,23 @@
 package keycloak
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/fulcrumproject/commons/retry"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthenticator_extractRole(t *testing.T) {
@@ -188,8 +201,22 @@ func TestAuthenticator_extractRole(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "Registered custom realm role",
+			claims: &Claims{
+				RealmAccess: struct {
+					Roles []string `json:"roles"`
+				}{
+					Roles: []string{"user", "keycloak-test-operator"},
+				},
+			},
+			expectedRole: auth.Role("keycloak-test-operator"),
+			expectError:  false,
+		},
 	}
 
+	auth.RegisterRoles("keycloak-test-operator")
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			role, err := authenticator.extractRole(tt.claims)
@@ -203,3 +230,362 @@ func TestAuthenticator_extractRole(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticator_extractRole_Mapping(t *testing.T) {
+	authenticator := &Authenticator{config: &Config{
+		ClientID:    "test-client",
+		RoleMapping: map[string]string{"fulcrum-operator": "admin", "tenant-user": "participant"},
+	}}
+
+	tests := []struct {
+		name         string
+		claims       *Claims
+		expectedRole auth.Role
+		expectError  bool
+	}{
+		{name: "Mapped direct role", claims: &Claims{Role: "fulcrum-operator"}, expectedRole: auth.RoleAdmin},
+		{name: "Mapped realm role", claims: &Claims{RealmAccess: struct {
+			Roles []string `json:"roles"`
+		}{Roles: []string{"offline_access", "tenant-user"}}}, expectedRole: auth.RoleParticipant},
+		{name: "Mapped client role", claims: &Claims{ResourceAccess: map[string]struct {
+			Roles []string `json:"roles"`
+		}{"test-client": {Roles: []string{"fulcrum-operator"}}}}, expectedRole: auth.RoleAdmin},
+		{name: "Unmapped role used as is", claims: &Claims{Role: "agent"}, expectedRole: auth.RoleAgent},
+		{name: "Unknown role", claims: &Claims{Role: "tenant-guest"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, err := authenticator.extractRole(tt.claims)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedRole, role)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_checkRequiredRoles(t *testing.T) {
+	authenticator := &Authenticator{config: &Config{ClientID: "test-client", RequiredRoles: []string{"fulcrum-access"}}}
+
+	tests := []struct {
+		name        string
+		realmRoles  []string
+		clientRoles map[string][]string
+		expectError bool
+	}{
+		{name: "Realm role", realmRoles: []string{"admin", "fulcrum-access"}},
+		{name: "Client role", clientRoles: map[string][]string{"test-client": {"fulcrum-access"}}},
+		{name: "Other client role", clientRoles: map[string][]string{"other-client": {"fulcrum-access"}}, expectError: true},
+		{name: "Missing role", realmRoles: []string{"admin"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{ResourceAccess: map[string]struct {
+				Roles []string `json:"roles"`
+			}{}}
+			claims.RealmAccess.Roles = tt.realmRoles
+			for client, roles := range tt.clientRoles {
+				claims.ResourceAccess[client] = struct {
+					Roles []string `json:"roles"`
+				}{Roles: roles}
+			}
+
+			err := authenticator.checkRequiredRoles(claims)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrMissingRequiredRole)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticator_InvalidRoleMapping(t *testing.T) {
+	_, err := NewAuthenticator(context.Background(), &Config{RoleMapping: map[string]string{"fulcrum-operator": "superuser"}}, WithLazyDiscovery())
+	assert.ErrorContains(t, err, "invalid role mapping for fulcrum-operator")
+}
+
+func TestExtractKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   *Claims
+		expected auth.IdentityKind
+	}{
+		{
+			name:     "User token",
+			claims:   &Claims{AuthorizedParty: "fulcrum-ui", PreferredUsername: "alice"},
+			expected: auth.KindUser,
+		},
+		{
+			name:     "Client credentials token",
+			claims:   &Claims{AuthorizedParty: "billing", PreferredUsername: "service-account-billing", ClientID: "billing"},
+			expected: auth.KindServiceAccount,
+		},
+		{
+			name:     "Legacy client id claim",
+			claims:   &Claims{LegacyClientID: "billing"},
+			expected: auth.KindServiceAccount,
+		},
+		{
+			name:     "Service account username matching azp",
+			claims:   &Claims{AuthorizedParty: "billing", PreferredUsername: "service-account-billing"},
+			expected: auth.KindServiceAccount,
+		},
+		{
+			name:     "Service account username of another client",
+			claims:   &Claims{AuthorizedParty: "fulcrum-ui", PreferredUsername: "service-account-billing"},
+			expected: auth.KindUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, extractKind(tt.claims))
+		})
+	}
+}
+
+func TestMappedClaims_Attributes(t *testing.T) {
+	raw := map[string]any{
+		"iss":                "https://keycloak/realms/fulcrum",
+		"sub":                "0193f6a2-0000-7000-8000-000000000000",
+		"typ":                "Bearer",
+		"azp":                "fulcrum-ui",
+		"sid":                "session",
+		"scope":              "openid profile",
+		"preferred_username": "alice",
+		"realm_access":       map[string]any{"roles": []any{"participant"}},
+		"participant_id":     "0193f6a2-0000-7000-8000-000000000001",
+		"allowed-origins":    []any{"*"},
+		"region":             "eu-west",
+		"cost_center":        "cc-42",
+	}
+
+	assert.Equal(t, map[string]any{"region": "eu-west", "cost_center": "cc-42"}, auth.ExtraClaims(raw, mappedClaims...))
+}
+
+func TestAuthenticator_checkAudience(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          Config
+		audience        []string
+		authorizedParty string
+		expectedErr     error
+	}{
+		{name: "Validation disabled", audience: []string{"other"}, authorizedParty: "other"},
+		{name: "Audience defaults to client id", config: Config{ClientID: "fulcrum", ValidateAudience: true}, audience: []string{"account", "fulcrum"}},
+		{name: "Audience not client id", config: Config{ClientID: "fulcrum", ValidateAudience: true}, audience: []string{"account"}, expectedErr: ErrInvalidAudience},
+		{name: "Audience in allowlist", config: Config{ClientID: "fulcrum", ValidateAudience: true, AllowedAudiences: []string{"core", "agents"}}, audience: []string{"agents"}},
+		{name: "Audience not in allowlist", config: Config{ClientID: "fulcrum", ValidateAudience: true, AllowedAudiences: []string{"core"}}, audience: []string{"fulcrum"}, expectedErr: ErrInvalidAudience},
+		{name: "Missing audience", config: Config{ClientID: "fulcrum", ValidateAudience: true}, expectedErr: ErrInvalidAudience},
+		{name: "Authorized party defaults to client id", config: Config{ClientID: "fulcrum", ValidateAuthorizedParty: true}, authorizedParty: "fulcrum"},
+		{name: "Authorized party in allowlist", config: Config{ValidateAuthorizedParty: true, AllowedAuthorizedParties: []string{"cli", "ui"}}, authorizedParty: "ui"},
+		{name: "Authorized party not allowed", config: Config{ValidateAuthorizedParty: true, AllowedAuthorizedParties: []string{"cli"}}, authorizedParty: "ui", expectedErr: ErrInvalidAuthorizedParty},
+		{name: "Missing authorized party", config: Config{ClientID: "fulcrum", ValidateAuthorizedParty: true}, expectedErr: ErrInvalidAuthorizedParty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := &Authenticator{config: &tt.config}
+			err := authenticator.checkAudience(tt.audience, tt.authorizedParty)
+			if tt.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestGroupID(t *testing.T) {
+	id := properties.NewUUID()
+	otherID := properties.NewUUID()
+
+	tests := []struct {
+		name          string
+		groups        []string
+		prefix        string
+		expected      *properties.UUID
+		errorContains string
+	}{
+		{name: "Disabled", groups: []string{"/participants/" + id.String()}},
+		{name: "No matching group", groups: []string{"/operators"}, prefix: "/participants/"},
+		{name: "Matching group", groups: []string{"/operators", "/participants/" + id.String()}, prefix: "/participants/", expected: &id},
+		{name: "Subgroup", groups: []string{"/participants/" + id.String() + "/admins"}, prefix: "/participants/", expected: &id},
+		{name: "Same id twice", groups: []string{"/participants/" + id.String(), "/participants/" + id.String() + "/admins"}, prefix: "/participants/", expected: &id},
+		{name: "Invalid id", groups: []string{"/participants/acme"}, prefix: "/participants/", errorContains: "invalid id in group /participants/acme"},
+		{name: "Ambiguous", groups: []string{"/participants/" + id.String(), "/participants/" + otherID.String()}, prefix: "/participants/", errorContains: "ambiguous groups with prefix /participants/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := groupID(tt.groups, tt.prefix)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNewAuthenticator_DiscoveryRetry(t *testing.T) {
+	srv := &jwksServer{}
+	srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+	cfg := &Config{KeycloakURL: server.URL, Realm: "test"}
+
+	server.discoveryFailures.Store(2)
+	_, err := NewAuthenticator(context.Background(), cfg)
+	assert.ErrorContains(t, err, "failed to create OIDC provider")
+
+	authenticator, err := NewAuthenticator(context.Background(), cfg, WithDiscoveryRetry(retry.Policy{MaxAttempts: 3}))
+	require.NoError(t, err)
+	authenticator.Close()
+
+	server.discoveryFailures.Store(5)
+	_, err = NewAuthenticator(context.Background(), cfg, WithDiscoveryRetry(retry.Policy{MaxAttempts: 3}))
+	assert.ErrorContains(t, err, "503 Service Unavailable")
+}
+
+func TestNewAuthenticator_LazyDiscovery(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+	server.discoveryFailures.Store(1)
+	clk := clock.NewFake(time.Now())
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: 60}, WithLazyDiscovery(), WithClock(clk))
+	require.NoError(t, err)
+	defer authenticator.Close()
+	assert.Equal(t, int32(1), server.discoveryFailures.Load(), "discovery is deferred")
+	assert.False(t, authenticator.Ready())
+
+	id := properties.NewUUID()
+	token := signToken(t, signer, map[string]any{"sub": id.String(), "exp": time.Now().Add(time.Minute).Unix(), "role": "admin"})
+	_, err = authenticator.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrNotReady)
+	assert.NotErrorIs(t, err, auth.ErrInvalidToken, "not ready is transient")
+	assert.ErrorContains(t, err, "failed to create OIDC provider")
+
+	// failed discoveries are not attempted again right away
+	assert.ErrorIs(t, authenticator.HealthCheck(context.Background()), ErrNotReady)
+	assert.False(t, authenticator.Ready())
+
+	clk.Advance(time.Second)
+	require.NoError(t, authenticator.HealthCheck(context.Background()))
+	assert.True(t, authenticator.Ready())
+
+	identity, err := authenticator.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, id, identity.ID)
+}
+
+func TestAuthenticator_ClockSkewLeeway(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+	now := time.Now().Truncate(time.Second)
+	sub := properties.NewUUID().String()
+
+	tests := []struct {
+		name     string
+		leeway   int
+		claims   map[string]any
+		expected error
+	}{
+		{name: "Expired within leeway", leeway: 30, claims: map[string]any{"exp": now.Add(-10 * time.Second).Unix()}},
+		{name: "Expired beyond leeway", leeway: 30, claims: map[string]any{"exp": now.Add(-time.Minute).Unix()}, expected: &oidc.TokenExpiredError{}},
+		{name: "Expired without leeway", claims: map[string]any{"exp": now.Add(-10 * time.Second).Unix()}, expected: &oidc.TokenExpiredError{}},
+		{name: "Not before within leeway", leeway: 30, claims: map[string]any{"exp": now.Add(time.Minute).Unix(), "nbf": now.Add(10 * time.Second).Unix()}},
+		{name: "Not before beyond leeway", leeway: 30, claims: map[string]any{"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Minute).Unix()}, expected: ErrTokenNotYetValid},
+		{name: "Issued within leeway", leeway: 30, claims: map[string]any{"exp": now.Add(time.Minute).Unix(), "iat": now.Add(10 * time.Second).Unix()}},
+		{name: "Issued beyond leeway", leeway: 30, claims: map[string]any{"exp": now.Add(time.Hour).Unix(), "iat": now.Add(time.Minute).Unix()}, expected: ErrTokenIssuedInFuture},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: 60, ClockSkewLeeway: tt.leeway},
+				WithClock(clock.NewFake(now)))
+			require.NoError(t, err)
+			defer authenticator.Close()
+
+			claims := map[string]any{"sub": sub, "role": "admin"}
+			maps.Copy(claims, tt.claims)
+			_, err = authenticator.Authenticate(context.Background(), signToken(t, signer, claims))
+			var expired *oidc.TokenExpiredError
+			switch {
+			case tt.expected == nil:
+				assert.NoError(t, err)
+			case errors.As(tt.expected, &expired):
+				assert.ErrorAs(t, err, &expired)
+			default:
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticator_GenericIssuer(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	issuer := server.URL + "/dex"
+	mux.HandleFunc("/dex/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+	})
+	mux.Handle("/dex/keys", srv)
+
+	for _, ttl := range []int{0, 60} {
+		authenticator, err := NewAuthenticator(context.Background(), &Config{IssuerURL: issuer, ValidateIssuer: true, JWKSCacheTTL: ttl})
+		require.NoError(t, err)
+		defer authenticator.Close()
+
+		id := properties.NewUUID()
+		token := signToken(t, signer, map[string]any{"iss": issuer, "sub": id.String(), "exp": time.Now().Add(time.Minute).Unix(), "role": "admin"})
+		identity, err := authenticator.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, id, identity.ID)
+	}
+}
+
+// countingTransport counts the requests sent through the default transport
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestNewAuthenticator_HTTPClient(t *testing.T) {
+	for _, ttl := range []int{0, 60} {
+		srv := &jwksServer{}
+		signer := srv.addKey(t, "kid-1")
+		server := newOIDCServer(t, srv, nil)
+		transport := &countingTransport{}
+
+		authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: ttl},
+			WithHTTPClient(&http.Client{Transport: transport}))
+		require.NoError(t, err)
+		defer authenticator.Close()
+
+		token := signToken(t, signer, map[string]any{"sub": properties.NewUUID().String(), "exp": time.Now().Add(time.Minute).Unix(), "role": "admin"})
+		_, err = authenticator.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), transport.requests.Load(), "discovery and JWKS go through the client")
+	}
+}
+
+func TestNewAuthenticator_InvalidHTTPConfig(t *testing.T) {
+	_, err := NewAuthenticator(context.Background(), &Config{CAFile: "missing.pem"}, WithLazyDiscovery())
+	assert.ErrorContains(t, err, "cannot read CA file")
+}
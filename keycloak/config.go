@@ -1,22 +1,188 @@
 package keycloak
 
-import "fmt"
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
 
 type Config struct {
-	KeycloakURL    string `json:"keycloakUrl" env:"OAUTH_KEYCLOAK_URL"`
-	Realm          string `json:"realm" env:"OAUTH_REALM"`
-	ClientID       string `json:"clientId" env:"OAUTH_CLIENT_ID"`
-	ClientSecret   string `json:"clientSecret" env:"OAUTH_CLIENT_SECRET"`
-	JWKSCacheTTL   int    `json:"jwksCacheTtl" env:"OAUTH_JWKS_CACHE_TTL"`
-	ValidateIssuer bool   `json:"validateIssuer" env:"OAUTH_VALIDATE_ISSUER"`
+	KeycloakURL string `json:"keycloakUrl" env:"OAUTH_KEYCLOAK_URL"`
+	Realm       string `json:"realm" env:"OAUTH_REALM"`
+	// IssuerURL selects the generic OIDC mode for providers like Auth0, Entra ID or Dex, the authenticator endpoints
+	// are discovered from the issuer instead of the Keycloak realm layout and KeycloakURL and Realm are ignored
+	IssuerURL    string `json:"issuerUrl" env:"OAUTH_ISSUER_URL"`
+	ClientID     string `json:"clientId" env:"OAUTH_CLIENT_ID"`
+	ClientSecret string `json:"clientSecret" env:"OAUTH_CLIENT_SECRET"`
+	// JWKSCacheTTL is how many seconds the signing keys are cached, zero leaves key management to the OIDC library
+	JWKSCacheTTL   int  `json:"jwksCacheTtl" env:"OAUTH_JWKS_CACHE_TTL"`
+	ValidateIssuer bool `json:"validateIssuer" env:"OAUTH_VALIDATE_ISSUER"`
+	// ClockSkewLeeway is how many seconds of clock drift with Keycloak are tolerated on the exp, nbf and iat claims
+	ClockSkewLeeway int `json:"clockSkewLeeway" env:"OAUTH_CLOCK_SKEW_LEEWAY"`
+	// SubjectStrategy selects how the identity id is read, the sub claim as UUID by default,
+	// see SubjectUUIDv5 and SubjectClaim for the subjects of federated users that are not UUIDs
+	SubjectStrategy SubjectStrategy `json:"subjectStrategy" env:"OAUTH_SUBJECT_STRATEGY"`
+	// SubjectClaim is the claim holding the UUID identity id with the SubjectClaim strategy
+	SubjectClaim string `json:"subjectClaim" env:"OAUTH_SUBJECT_CLAIM"`
+	// SubjectNamespace is the UUID namespace of the SubjectUUIDv5 strategy, the URL namespace by default
+	SubjectNamespace string `json:"subjectNamespace" env:"OAUTH_SUBJECT_NAMESPACE"`
+	// ValidateAudience requires the aud claim to contain one of AllowedAudiences, the client id when empty
+	ValidateAudience bool     `json:"validateAudience" env:"OAUTH_VALIDATE_AUDIENCE"`
+	AllowedAudiences []string `json:"allowedAudiences" env:"OAUTH_ALLOWED_AUDIENCES"`
+	// ValidateAuthorizedParty requires the azp claim to be one of AllowedAuthorizedParties, the client id when empty
+	ValidateAuthorizedParty  bool     `json:"validateAuthorizedParty" env:"OAUTH_VALIDATE_AUTHORIZED_PARTY"`
+	AllowedAuthorizedParties []string `json:"allowedAuthorizedParties" env:"OAUTH_ALLOWED_AUTHORIZED_PARTIES"`
+	// RoleMapping translates Keycloak role names to auth roles, e.g. fulcrum-operator to admin, for the role claim
+	// as well as the realm and client roles, the unmapped names are used as they are
+	RoleMapping map[string]string `json:"roleMapping" env:"OAUTH_ROLE_MAPPING"`
+	// RequiredRoles are the realm or client roles every token must carry, e.g. fulcrum-access to gate the service access
+	RequiredRoles []string `json:"requiredRoles" env:"OAUTH_REQUIRED_ROLES"`
+	// Group prefixes map group paths like /participants/<uuid> to the identity scope when the id claims are missing
+	ParticipantGroupPrefix  string `json:"participantGroupPrefix" env:"OAUTH_PARTICIPANT_GROUP_PREFIX"`
+	AgentGroupPrefix        string `json:"agentGroupPrefix" env:"OAUTH_AGENT_GROUP_PREFIX"`
+	OrganizationGroupPrefix string `json:"organizationGroupPrefix" env:"OAUTH_ORGANIZATION_GROUP_PREFIX"`
+	// UserInfoEnrichment adds the userinfo claims (e.g. email, locale) missing from the token to the identity attributes,
+	// the responses are cached by subject for UserInfoCacheTTL seconds, 5 minutes by default
+	UserInfoEnrichment bool `json:"userInfoEnrichment" env:"OAUTH_USERINFO_ENRICHMENT"`
+	UserInfoCacheTTL   int  `json:"userInfoCacheTtl" env:"OAUTH_USERINFO_CACHE_TTL"`
+	// VerifiedTokenCacheSize is how many verified JWTs are cached by hash until they expire, zero disables the cache
+	VerifiedTokenCacheSize int `json:"verifiedTokenCacheSize" env:"OAUTH_VERIFIED_TOKEN_CACHE_SIZE"`
+	// IntrospectionFallback validates the tokens that are not JWTs, e.g. opaque or session tokens, with the
+	// introspection endpoint using the client credentials instead of rejecting them
+	IntrospectionFallback bool `json:"introspectionFallback" env:"OAUTH_INTROSPECTION_FALLBACK"`
+	// HTTP client settings of the discovery, JWKS, userinfo and introspection calls, see HTTPClient
+	HTTPTimeout    int    `json:"httpTimeout" env:"OAUTH_HTTP_TIMEOUT"`
+	CAFile         string `json:"caFile" env:"OAUTH_CA_FILE"`
+	ClientCertFile string `json:"clientCertFile" env:"OAUTH_CLIENT_CERT_FILE"`
+	ClientKeyFile  string `json:"clientKeyFile" env:"OAUTH_CLIENT_KEY_FILE"`
+	ProxyURL       string `json:"proxyUrl" env:"OAUTH_PROXY_URL"`
+}
+
+// Endpoints are the OAuth endpoints of the provider
+type Endpoints struct {
+	JWKSURL  string
+	TokenURL string
+	AuthURL  string
+}
+
+// Endpoints returns the Keycloak realm endpoints, or in generic OIDC mode the jwks_uri, token_endpoint
+// and authorization_endpoint of the issuer discovery document fetched with httpClient
+func (c *Config) Endpoints(ctx context.Context, httpClient *http.Client) (Endpoints, error) {
+	if c.IssuerURL == "" {
+		return Endpoints{JWKSURL: c.GetJWKSURL(), TokenURL: c.GetTokenURL(), AuthURL: c.GetAuthURL()}, nil
+	}
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, httpClient), c.IssuerURL)
+	if err != nil {
+		return Endpoints{}, fmt.Errorf("failed to create OIDC provider: %w", err)
+	}
+	var discovery struct {
+		JWKSURL string `json:"jwks_uri"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return Endpoints{}, fmt.Errorf("failed to read OIDC discovery: %w", err)
+	}
+	endpoint := provider.Endpoint()
+	return Endpoints{JWKSURL: discovery.JWKSURL, TokenURL: endpoint.TokenURL, AuthURL: endpoint.AuthURL}, nil
 }
 
-// GetJWKSURL returns the JWKS endpoint URL for the Keycloak realm
+// tokenEndpoint resolves the token endpoint URL, see Endpoints
+func (c *Config) tokenEndpoint(ctx context.Context, httpClient *http.Client) (string, error) {
+	endpoints, err := c.Endpoints(ctx, httpClient)
+	if err != nil {
+		return "", err
+	}
+	if endpoints.TokenURL == "" {
+		return "", errors.New("the OIDC discovery has no token endpoint")
+	}
+	return endpoints.TokenURL, nil
+}
+
+// GetJWKSURL returns the JWKS endpoint URL for the Keycloak realm, see Endpoints for the generic OIDC mode
 func (c *Config) GetJWKSURL() string {
-	return fmt.Sprintf("%s/realms/%s/protocol/openid_connect/certs", c.KeycloakURL, c.Realm)
+	return fmt.Sprintf("%s/realms/%s/protocol/openid-connect/certs", c.KeycloakURL, c.Realm)
 }
 
-// GetIssuer returns the expected issuer for JWT tokens
+// GetIssuer returns the expected issuer for JWT tokens, IssuerURL in generic OIDC mode
 func (c *Config) GetIssuer() string {
+	if c.IssuerURL != "" {
+		return c.IssuerURL
+	}
 	return fmt.Sprintf("%s/realms/%s", c.KeycloakURL, c.Realm)
 }
+
+// GetTokenURL returns the token endpoint URL for the Keycloak realm, see Endpoints for the generic OIDC mode
+func (c *Config) GetTokenURL() string {
+	return fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", c.KeycloakURL, c.Realm)
+}
+
+// audiences returns the accepted audiences, defaulting to the client id
+func (c *Config) audiences() []string {
+	if len(c.AllowedAudiences) > 0 {
+		return c.AllowedAudiences
+	}
+	return []string{c.ClientID}
+}
+
+// authorizedParties returns the accepted authorized parties, defaulting to the client id
+func (c *Config) authorizedParties() []string {
+	if len(c.AllowedAuthorizedParties) > 0 {
+		return c.AllowedAuthorizedParties
+	}
+	return []string{c.ClientID}
+}
+
+// GetAuthURL returns the authorization endpoint URL for the Keycloak realm, see Endpoints for the generic OIDC mode
+func (c *Config) GetAuthURL() string {
+	return fmt.Sprintf("%s/realms/%s/protocol/openid-connect/auth", c.KeycloakURL, c.Realm)
+}
+
+// HTTPClient builds the HTTP client of the Keycloak calls from the timeout in seconds, the PEM CA bundle trusted
+// besides the system roots, the client certificate for mutual TLS and the proxy, which defaults to the environment proxy
+// It returns http.DefaultClient when none of them is set
+func (c *Config) HTTPClient() (*http.Client, error) {
+	if c.HTTPTimeout <= 0 && c.CAFile == "" && c.ClientCertFile == "" && c.ProxyURL == "" {
+		return http.DefaultClient, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	if c.ClientCertFile != "" || c.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &http.Client{Transport: transport, Timeout: time.Duration(c.HTTPTimeout) * time.Second}, nil
+}
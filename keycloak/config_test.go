@@ -1,9 +1,19 @@
 package keycloak
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfig_GetJWKSURL(t *testing.T) {
@@ -12,7 +22,7 @@ func TestConfig_GetJWKSURL(t *testing.T) {
 		Realm:       "test-realm",
 	}
 
-	expected := "https://keycloak.example.com/realms/test-realm/protocol/openid_connect/certs"
+	expected := "https://keycloak.example.com/realms/test-realm/protocol/openid-connect/certs"
 	actual := config.GetJWKSURL()
 
 	assert.Equal(t, expected, actual, "JWKS URL should match expected value")
@@ -29,3 +39,137 @@ func TestConfig_GetIssuer(t *testing.T) {
 
 	assert.Equal(t, expected, actual, "Issuer should match expected value")
 }
+
+func TestConfig_GetIssuer_Generic(t *testing.T) {
+	config := &Config{
+		KeycloakURL: "https://keycloak.example.com",
+		Realm:       "test-realm",
+		IssuerURL:   "https://tenant.auth0.com/",
+	}
+
+	assert.Equal(t, "https://tenant.auth0.com/", config.GetIssuer(), "Issuer should be the generic issuer URL")
+}
+
+func TestConfig_GetTokenURL(t *testing.T) {
+	config := &Config{
+		KeycloakURL: "https://keycloak.example.com",
+		Realm:       "test-realm",
+	}
+
+	expected := "https://keycloak.example.com/realms/test-realm/protocol/openid-connect/token"
+	assert.Equal(t, expected, config.GetTokenURL(), "Token URL should match expected value")
+}
+
+func TestConfig_GetAuthURL(t *testing.T) {
+	config := &Config{
+		KeycloakURL: "https://keycloak.example.com",
+		Realm:       "test-realm",
+	}
+
+	expected := "https://keycloak.example.com/realms/test-realm/protocol/openid-connect/auth"
+	assert.Equal(t, expected, config.GetAuthURL(), "Authorization URL should match expected value")
+}
+
+// genericIssuer is a non-Keycloak OIDC provider serving its discovery document and token endpoint
+type genericIssuer struct {
+	*httptest.Server
+	discoveries atomic.Int32
+}
+
+func newGenericIssuer(t *testing.T, token http.HandlerFunc) *genericIssuer {
+	mux := http.NewServeMux()
+	issuer := &genericIssuer{Server: httptest.NewServer(mux)}
+	t.Cleanup(issuer.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer.discoveries.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 issuer.URL,
+			"jwks_uri":               issuer.URL + "/keys",
+			"token_endpoint":         issuer.URL + "/oauth/token",
+			"authorization_endpoint": issuer.URL + "/authorize",
+		})
+	})
+	mux.HandleFunc("/oauth/token", token)
+	return issuer
+}
+
+func TestConfig_Endpoints(t *testing.T) {
+	t.Run("Keycloak realm", func(t *testing.T) {
+		config := &Config{KeycloakURL: "https://keycloak.example.com", Realm: "test-realm"}
+		endpoints, err := config.Endpoints(context.Background(), http.DefaultClient)
+		require.NoError(t, err)
+		assert.Equal(t, Endpoints{
+			JWKSURL:  "https://keycloak.example.com/realms/test-realm/protocol/openid-connect/certs",
+			TokenURL: "https://keycloak.example.com/realms/test-realm/protocol/openid-connect/token",
+			AuthURL:  "https://keycloak.example.com/realms/test-realm/protocol/openid-connect/auth",
+		}, endpoints)
+	})
+
+	t.Run("Generic issuer", func(t *testing.T) {
+		issuer := newGenericIssuer(t, http.NotFound)
+		config := &Config{KeycloakURL: "https://keycloak.example.com", Realm: "test-realm", IssuerURL: issuer.URL}
+		endpoints, err := config.Endpoints(context.Background(), http.DefaultClient)
+		require.NoError(t, err)
+		assert.Equal(t, Endpoints{JWKSURL: issuer.URL + "/keys", TokenURL: issuer.URL + "/oauth/token", AuthURL: issuer.URL + "/authorize"}, endpoints)
+	})
+
+	t.Run("Discovery failure", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		_, err := (&Config{IssuerURL: server.URL}).Endpoints(context.Background(), http.DefaultClient)
+		assert.ErrorContains(t, err, "failed to create OIDC provider")
+	})
+}
+
+func TestConfig_HTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("not a certificate"), 0o600))
+
+	t.Run("Default client", func(t *testing.T) {
+		httpClient, err := (&Config{}).HTTPClient()
+		require.NoError(t, err)
+		assert.Same(t, http.DefaultClient, httpClient)
+	})
+
+	t.Run("Private CA", func(t *testing.T) {
+		httpClient, err := (&Config{CAFile: caFile, HTTPTimeout: 5}).HTTPClient()
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, httpClient.Timeout)
+		resp, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		_, err = http.Get(server.URL)
+		assert.Error(t, err, "the CA is not trusted by default")
+	})
+
+	t.Run("Proxy", func(t *testing.T) {
+		httpClient, err := (&Config{ProxyURL: "http://proxy.example.com:3128"}).HTTPClient()
+		require.NoError(t, err)
+		proxy, err := httpClient.Transport.(*http.Transport).Proxy(httptest.NewRequest(http.MethodGet, "https://keycloak.example.com", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "proxy.example.com:3128", proxy.Host)
+	})
+
+	for _, tt := range []struct {
+		name   string
+		config *Config
+		errMsg string
+	}{
+		{name: "Missing CA file", config: &Config{CAFile: filepath.Join(dir, "missing.pem")}, errMsg: "cannot read CA file"},
+		{name: "Invalid CA file", config: &Config{CAFile: invalidFile}, errMsg: "no certificate found"},
+		{name: "Invalid client certificate", config: &Config{ClientCertFile: invalidFile, ClientKeyFile: invalidFile}, errMsg: "cannot load client certificate"},
+		{name: "Invalid proxy", config: &Config{ProxyURL: "://proxy"}, errMsg: "invalid proxy url"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.HTTPClient()
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
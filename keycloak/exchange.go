@@ -0,0 +1,130 @@
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/client"
+	"github.com/fulcrumproject/commons/clock"
+)
+
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	// exchangeMaxEntries bounds the number of cached exchanged tokens
+	exchangeMaxEntries = 10000
+	// exchangeLeeway is how long before expiry a cached exchanged token stops being reused
+	exchangeLeeway = 10 * time.Second
+)
+
+// TokenExchanger exchanges incoming user tokens for tokens of a downstream audience through
+// the Keycloak token exchange (RFC 8693), the exchanged tokens keep the user as subject
+// Exchanged tokens are cached until shortly before their expiry, the least recently used are evicted first
+type TokenExchanger struct {
+	config       *Config
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+	clock        clock.Clock
+	entries      *auth.LRU[string]
+
+	// mu guards the token endpoint discovered in generic OIDC mode
+	mu sync.Mutex
+}
+
+// ExchangeOption configures the TokenExchanger
+type ExchangeOption func(*TokenExchanger)
+
+// WithExchangeHTTPClient sets the HTTP client used to call the token endpoint
+func WithExchangeHTTPClient(c *http.Client) ExchangeOption {
+	return func(e *TokenExchanger) {
+		e.httpClient = c
+	}
+}
+
+// WithExchangeClock sets the clock used to expire the cached tokens
+func WithExchangeClock(clk clock.Clock) ExchangeOption {
+	return func(e *TokenExchanger) {
+		e.clock = clk
+	}
+}
+
+// NewTokenExchanger creates a token exchanger authenticated with the client credentials of cfg,
+// the client needs the token exchange permission on the target audiences
+// In generic OIDC mode the token endpoint is discovered from the issuer on the first exchange
+func NewTokenExchanger(cfg *Config, opts ...ExchangeOption) (*TokenExchanger, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, errors.New("token exchanger requires client id and client secret")
+	}
+	e := &TokenExchanger{
+		config:       cfg,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		httpClient:   http.DefaultClient,
+		clock:        clock.New(),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.entries = auth.NewLRU[string](exchangeMaxEntries, e.clock)
+	return e, nil
+}
+
+// Exchange returns an access token for the audience acting as the subject of subjectToken
+func (e *TokenExchanger) Exchange(ctx context.Context, subjectToken, audience string, scopes ...string) (string, error) {
+	if subjectToken == "" {
+		return "", errors.New("missing subject token")
+	}
+	key := auth.HashToken(subjectToken) + "\x00" + audience + "\x00" + strings.Join(scopes, " ")
+	if token, ok := e.entries.Get(key); ok {
+		return token, nil
+	}
+	e.mu.Lock()
+	tokenURL := e.tokenURL
+	e.mu.Unlock()
+	if tokenURL == "" {
+		var err error
+		if tokenURL, err = e.config.tokenEndpoint(ctx, e.httpClient); err != nil {
+			return "", err
+		}
+		e.mu.Lock()
+		e.tokenURL = tokenURL
+		e.mu.Unlock()
+	}
+
+	form := url.Values{
+		"grant_type":           {grantTypeTokenExchange},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {tokenTypeAccessToken},
+		"requested_token_type": {tokenTypeAccessToken},
+		"client_id":            {e.clientID},
+		"client_secret":        {e.clientSecret},
+	}
+	if audience != "" {
+		form.Set("audience", audience)
+	}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	resp, err := postToken(ctx, e.httpClient, tokenURL, form)
+	if err != nil {
+		return "", err
+	}
+
+	e.entries.Put(key, resp.AccessToken, time.Duration(resp.ExpiresIn)*time.Second-exchangeLeeway)
+	return resp.AccessToken, nil
+}
+
+// TokenSource returns a client.TokenSource calling downstream services with the exchanged token of subjectToken
+func (e *TokenExchanger) TokenSource(subjectToken, audience string, scopes ...string) client.TokenSource {
+	return client.TokenSourceFunc(func(ctx context.Context) (string, error) {
+		return e.Exchange(ctx, subjectToken, audience, scopes...)
+	})
+}
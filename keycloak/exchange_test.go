@@ -0,0 +1,100 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExchangeServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, grantTypeTokenExchange, r.PostForm.Get("grant_type"))
+		assert.Equal(t, tokenTypeAccessToken, r.PostForm.Get("subject_token_type"))
+		assert.Equal(t, "gateway", r.PostForm.Get("client_id"))
+		if r.PostForm.Get("subject_token") != "user-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_token", "error_description": "subject token invalid"})
+			return
+		}
+		token := "exchanged-" + r.PostForm.Get("audience")
+		if scope := r.PostForm.Get("scope"); scope != "" {
+			token += "-" + scope
+		}
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: token, ExpiresIn: 60})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTokenExchanger_Exchange(t *testing.T) {
+	var calls atomic.Int32
+	server := newExchangeServer(t, &calls)
+	clk := clock.NewFake(time.Now())
+	exchanger, err := NewTokenExchanger(&Config{KeycloakURL: server.URL, Realm: "test", ClientID: "gateway", ClientSecret: "secret"}, WithExchangeClock(clk))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for range 2 {
+		token, err := exchanger.Exchange(ctx, "user-token", "backend")
+		require.NoError(t, err)
+		assert.Equal(t, "exchanged-backend", token)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	token, err := exchanger.Exchange(ctx, "user-token", "billing", "invoice:read")
+	require.NoError(t, err)
+	assert.Equal(t, "exchanged-billing-invoice:read", token)
+	assert.Equal(t, int32(2), calls.Load())
+
+	clk.Advance(50 * time.Second)
+	_, err = exchanger.Exchange(ctx, "user-token", "backend")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load(), "tokens close to expiry are exchanged again")
+
+	_, err = exchanger.Exchange(ctx, "other-token", "backend")
+	var oauthErr *OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_token", oauthErr.Code)
+
+	_, err = exchanger.Exchange(ctx, "", "backend")
+	assert.EqualError(t, err, "missing subject token")
+}
+
+func TestTokenExchanger_TokenSource(t *testing.T) {
+	var calls atomic.Int32
+	server := newExchangeServer(t, &calls)
+	exchanger, err := NewTokenExchanger(&Config{KeycloakURL: server.URL, Realm: "test", ClientID: "gateway", ClientSecret: "secret"})
+	require.NoError(t, err)
+
+	token, err := exchanger.TokenSource("user-token", "backend").Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "exchanged-backend", token)
+
+	_, err = NewTokenExchanger(&Config{KeycloakURL: server.URL, Realm: "test", ClientID: "gateway"})
+	assert.EqualError(t, err, "token exchanger requires client id and client secret")
+}
+
+func TestTokenExchanger_GenericIssuer(t *testing.T) {
+	issuer := newGenericIssuer(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "exchanged", ExpiresIn: 60})
+	})
+	exchanger, err := NewTokenExchanger(&Config{IssuerURL: issuer.URL, ClientID: "gateway", ClientSecret: "secret"})
+	require.NoError(t, err)
+
+	for _, audience := range []string{"backend", "billing"} {
+		token, err := exchanger.Exchange(context.Background(), "user-token", audience)
+		require.NoError(t, err)
+		assert.Equal(t, "exchanged", token)
+	}
+	assert.Equal(t, int32(1), issuer.discoveries.Load(), "the token endpoint is discovered once")
+}
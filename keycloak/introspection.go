@@ -0,0 +1,76 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrInactiveToken is returned when the introspection endpoint reports the token as not active
+var ErrInactiveToken = errors.New("token not active")
+
+// introspectionClient validates opaque tokens with the RFC 7662 introspection endpoint
+type introspectionClient struct {
+	url          string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+// introspect returns the raw claims of an active token
+func (c *introspectionClient) introspect(ctx context.Context, token string) ([]byte, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create introspection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("introspection request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection endpoint returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read introspection response: %w", err)
+	}
+	var status struct {
+		Active bool `json:"active"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, fmt.Errorf("cannot decode introspection response: %w", err)
+	}
+	if !status.Active {
+		return nil, ErrInactiveToken
+	}
+	return body, nil
+}
+
+// audienceClaim reads the aud claim which is either a string or an array of strings
+func audienceClaim(raw map[string]any) []string {
+	switch aud := raw["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		audience := make([]string, 0, len(aud))
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+		return audience
+	default:
+		return nil
+	}
+}
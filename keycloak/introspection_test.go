@@ -0,0 +1,76 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_IntrospectionFallback(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+	id := properties.NewUUID()
+	var introspections int
+	server.mux.HandleFunc("/realms/test/protocol/openid-connect/token/introspect", func(w http.ResponseWriter, r *http.Request) {
+		introspections++
+		clientID, secret, _ := r.BasicAuth()
+		assert.Equal(t, "fulcrum", clientID)
+		assert.Equal(t, "secret", secret)
+		if r.PostFormValue("token") != "opaque-token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"active": false})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"active":       true,
+			"iss":          server.URL + "/realms/test",
+			"sub":          id.String(),
+			"aud":          "fulcrum",
+			"username":     "alice",
+			"token_type":   "Bearer",
+			"realm_access": map[string]any{"roles": []string{"admin"}},
+			"region":       "eu-west",
+		})
+	})
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{
+		KeycloakURL: server.URL, Realm: "test", ClientID: "fulcrum", ClientSecret: "secret",
+		ValidateIssuer: true, ValidateAudience: true, IntrospectionFallback: true,
+	})
+	require.NoError(t, err)
+
+	identity, err := authenticator.Authenticate(context.Background(), "opaque-token")
+	require.NoError(t, err)
+	assert.Equal(t, id, identity.ID)
+	assert.Equal(t, auth.RoleAdmin, identity.Role)
+	assert.Equal(t, map[string]any{"region": "eu-west"}, identity.Attributes)
+
+	_, err = authenticator.Authenticate(context.Background(), "revoked-token")
+	assert.ErrorIs(t, err, ErrInactiveToken)
+
+	// JWTs are never introspected, even when their verification fails
+	expired := signToken(t, signer, map[string]any{"iss": server.URL + "/realms/test", "aud": "fulcrum", "sub": id.String(), "exp": time.Now().Add(-time.Minute).Unix(), "role": "admin"})
+	_, err = authenticator.Authenticate(context.Background(), expired)
+	assert.Error(t, err)
+	assert.Equal(t, 2, introspections)
+}
+
+func TestNewAuthenticator_IntrospectionFallbackRequiresCredentials(t *testing.T) {
+	server := newOIDCServer(t, &jwksServer{}, nil)
+
+	_, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", ClientID: "fulcrum", IntrospectionFallback: true})
+	assert.ErrorContains(t, err, "introspection fallback requires the client credentials")
+}
+
+func TestAudienceClaim(t *testing.T) {
+	assert.Equal(t, []string{"fulcrum"}, audienceClaim(map[string]any{"aud": "fulcrum"}))
+	assert.Equal(t, []string{"fulcrum", "account"}, audienceClaim(map[string]any{"aud": []any{"fulcrum", "account"}}))
+	assert.Nil(t, audienceClaim(map[string]any{}))
+}
@@ -0,0 +1,209 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/go-jose/go-jose/v4"
+)
+
+// ErrUnknownKey is returned when no key of the set matches the token kid, even after a refetch
+var ErrUnknownKey = errors.New("no matching signing key")
+
+// supportedAlgorithms are the signature algorithms accepted by the JWKSCache
+var supportedAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512, jose.ES256, jose.ES384, jose.ES512, jose.EdDSA,
+}
+
+// JWKSMetrics receives the key set instrumentation
+type JWKSMetrics interface {
+	// ObserveRefresh is called after each key set fetch with the fetch error, nil on success
+	ObserveRefresh(err error)
+}
+
+type noopJWKSMetrics struct{}
+
+func (noopJWKSMetrics) ObserveRefresh(error) {}
+
+// JWKSOption configures the JWKSCache
+type JWKSOption func(*JWKSCache)
+
+// WithJWKSHTTPClient sets the HTTP client used to fetch the key set
+func WithJWKSHTTPClient(c *http.Client) JWKSOption {
+	return func(k *JWKSCache) {
+		k.httpClient = c
+	}
+}
+
+// WithJWKSClock sets the clock used to expire the key set
+func WithJWKSClock(clk clock.Clock) JWKSOption {
+	return func(k *JWKSCache) {
+		k.clock = clk
+	}
+}
+
+// WithJWKSMetrics sets the receiver of the refresh events
+func WithJWKSMetrics(m JWKSMetrics) JWKSOption {
+	return func(k *JWKSCache) {
+		k.metrics = m
+	}
+}
+
+// WithMinRefreshInterval sets the minimum delay between refetches triggered by unknown key ids, defaults to 10 seconds
+func WithMinRefreshInterval(d time.Duration) JWKSOption {
+	return func(k *JWKSCache) {
+		k.minRefreshInterval = d
+	}
+}
+
+// JWKSCache keeps the signing keys of the realm for a TTL, it implements oidc.KeySet
+// Tokens signed by an unknown key id trigger a refetch, rate limited to survive garbage tokens
+type JWKSCache struct {
+	url                string
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+	httpClient         *http.Client
+	clock              clock.Clock
+	metrics            JWKSMetrics
+
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+// NewJWKSCache creates a key set cache for the JWKS endpoint at url
+func NewJWKSCache(url string, ttl time.Duration, opts ...JWKSOption) *JWKSCache {
+	c := &JWKSCache{
+		url:                url,
+		ttl:                ttl,
+		minRefreshInterval: 10 * time.Second,
+		httpClient:         http.DefaultClient,
+		clock:              clock.New(),
+		metrics:            noopJWKSMetrics{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// VerifySignature verifies the token signature with the cached keys and returns the payload
+func (c *JWKSCache) VerifySignature(ctx context.Context, token string) ([]byte, error) {
+	jws, err := jose.ParseSigned(token, supportedAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt: %w", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, errors.New("jwt must have exactly one signature")
+	}
+	kid := jws.Signatures[0].Header.KeyID
+
+	keys, fetchedAt := c.snapshot()
+	expired := fetchedAt.IsZero() || c.clock.Since(fetchedAt) >= c.ttl
+	candidates := matchingKeys(keys, kid)
+	if expired || (len(candidates) == 0 && c.clock.Since(fetchedAt) >= c.minRefreshInterval) {
+		if err := c.refresh(ctx, fetchedAt); err != nil && len(candidates) == 0 {
+			return nil, err
+		}
+		keys, _ = c.snapshot()
+		candidates = matchingKeys(keys, kid)
+	}
+
+	for _, key := range candidates {
+		if payload, err := jws.Verify(key.Key); err == nil {
+			return payload, nil
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return nil, errors.New("failed to verify signature")
+}
+
+// Refresh fetches the key set now
+func (c *JWKSCache) Refresh(ctx context.Context) error {
+	_, fetchedAt := c.snapshot()
+	return c.refresh(ctx, fetchedAt)
+}
+
+// Start refreshes the key set every TTL in the background until ctx is done
+func (c *JWKSCache) Start(ctx context.Context) {
+	go func() {
+		ticker := c.clock.NewTicker(c.ttl)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				_ = c.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+func (c *JWKSCache) snapshot() (jose.JSONWebKeySet, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keys, c.fetchedAt
+}
+
+// refresh fetches the key set unless another caller refreshed it since seen
+func (c *JWKSCache) refresh(ctx context.Context, seen time.Time) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if _, fetchedAt := c.snapshot(); fetchedAt.After(seen) {
+		return nil
+	}
+
+	keys, err := c.fetch(ctx)
+	c.metrics.ObserveRefresh(err)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys, c.fetchedAt = keys, c.clock.Now()
+	return nil
+}
+
+func (c *JWKSCache) fetch(ctx context.Context) (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return keys, fmt.Errorf("cannot create JWKS request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return keys, fmt.Errorf("JWKS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return keys, fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return keys, fmt.Errorf("cannot decode JWKS: %w", err)
+	}
+	return keys, nil
+}
+
+// matchingKeys returns the signing keys with the kid, every signing key when the token has no kid
+func matchingKeys(keys jose.JSONWebKeySet, kid string) []jose.JSONWebKey {
+	var out []jose.JSONWebKey
+	for _, key := range keys.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if kid == "" || key.KeyID == kid {
+			out = append(out, key)
+		}
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package keycloak
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves the public keys of the current signing keys
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    []jose.JSONWebKey
+	fetches atomic.Int32
+	fail    atomic.Bool
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.fetches.Add(1)
+	if s.fail.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: s.keys})
+}
+
+// addKey generates a signing key with the kid and publishes its public key
+func (s *jwksServer) addKey(t *testing.T, kid string) jose.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	s.mu.Lock()
+	s.keys = append(s.keys, jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"})
+	s.mu.Unlock()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: kid}}, (&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+	return signer
+}
+
+func signToken(t *testing.T, signer jose.Signer, claims any) string {
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+	return token
+}
+
+type recordingJWKSMetrics struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (m *recordingJWKSMetrics) ObserveRefresh(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs = append(m.errs, err)
+}
+
+func TestJWKSCache_VerifySignature(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	clk := clock.NewFake(time.Now())
+	cache := NewJWKSCache(server.URL, time.Hour, WithJWKSClock(clk))
+	token := signToken(t, signer, map[string]any{"sub": "alice"})
+
+	for range 3 {
+		payload, err := cache.VerifySignature(context.Background(), token)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"sub":"alice"}`, string(payload))
+	}
+	assert.Equal(t, int32(1), srv.fetches.Load())
+
+	clk.Advance(time.Hour)
+	_, err := cache.VerifySignature(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), srv.fetches.Load(), "expired key set is refetched")
+
+	_, err = cache.VerifySignature(context.Background(), "not-a-jwt")
+	assert.ErrorContains(t, err, "malformed jwt")
+}
+
+func TestJWKSCache_UnknownKid(t *testing.T) {
+	srv := &jwksServer{}
+	srv.addKey(t, "kid-1")
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	clk := clock.NewFake(time.Now())
+	cache := NewJWKSCache(server.URL, time.Hour, WithJWKSClock(clk), WithMinRefreshInterval(time.Minute))
+	require.NoError(t, cache.Refresh(context.Background()))
+
+	rotated := signToken(t, srv.addKey(t, "kid-2"), map[string]any{"sub": "bob"})
+	_, err := cache.VerifySignature(context.Background(), rotated)
+	assert.ErrorIs(t, err, ErrUnknownKey, "refetch is rate limited")
+	assert.Equal(t, int32(1), srv.fetches.Load())
+
+	clk.Advance(time.Minute)
+	_, err = cache.VerifySignature(context.Background(), rotated)
+	require.NoError(t, err, "unknown kid forces a refetch")
+	assert.Equal(t, int32(2), srv.fetches.Load())
+
+	other := &jwksServer{}
+	forged := signToken(t, other.addKey(t, "kid-1"), map[string]any{"sub": "eve"})
+	_, err = cache.VerifySignature(context.Background(), forged)
+	assert.EqualError(t, err, "failed to verify signature")
+}
+
+func TestJWKSCache_RefreshFailure(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	clk := clock.NewFake(time.Now())
+	metrics := &recordingJWKSMetrics{}
+	cache := NewJWKSCache(server.URL, time.Minute, WithJWKSClock(clk), WithJWKSMetrics(metrics))
+	token := signToken(t, signer, map[string]any{"sub": "alice"})
+	_, err := cache.VerifySignature(context.Background(), token)
+	require.NoError(t, err)
+
+	srv.fail.Store(true)
+	clk.Advance(time.Minute)
+	_, err = cache.VerifySignature(context.Background(), token)
+	assert.NoError(t, err, "stale keys are used when the refresh fails")
+
+	require.Len(t, metrics.errs, 2)
+	assert.NoError(t, metrics.errs[0])
+	assert.EqualError(t, metrics.errs[1], "JWKS endpoint returned status 503")
+
+	empty := NewJWKSCache(server.URL, time.Minute)
+	_, err = empty.VerifySignature(context.Background(), token)
+	assert.EqualError(t, err, "JWKS endpoint returned status 503")
+}
+
+func TestJWKSCache_Start(t *testing.T) {
+	srv := &jwksServer{}
+	srv.addKey(t, "kid-1")
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cache.Start(ctx)
+	assert.Eventually(t, func() bool { return srv.fetches.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+}
+
+// oidcServer fakes the OIDC endpoints of the realm "test"
+type oidcServer struct {
+	*httptest.Server
+	// mux registers additional realm endpoints
+	mux *http.ServeMux
+	// discoveryFailures is the number of discovery requests to fail before serving the document
+	discoveryFailures atomic.Int32
+}
+
+// newOIDCServer serves the discovery document, the key set and optionally the userinfo endpoint of the realm "test"
+func newOIDCServer(t *testing.T, keys, userInfo http.Handler) *oidcServer {
+	mux := http.NewServeMux()
+	server := &oidcServer{Server: httptest.NewServer(mux), mux: mux}
+	t.Cleanup(server.Close)
+	issuer := server.URL + "/realms/test"
+	mux.HandleFunc("/realms/test/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		if server.discoveryFailures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer,
+			"jwks_uri":                              issuer + "/protocol/openid-connect/certs",
+			"authorization_endpoint":                issuer + "/protocol/openid-connect/auth",
+			"token_endpoint":                        issuer + "/protocol/openid-connect/token",
+			"userinfo_endpoint":                     issuer + "/protocol/openid-connect/userinfo",
+			"introspection_endpoint":                issuer + "/protocol/openid-connect/token/introspect",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.Handle("/realms/test/protocol/openid-connect/certs", keys)
+	if userInfo != nil {
+		mux.Handle("/realms/test/protocol/openid-connect/userinfo", userInfo)
+	}
+	return server
+}
+
+func TestNewAuthenticator_JWKSCache(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+	metrics := &recordingJWKSMetrics{}
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: 60, ValidateIssuer: true},
+		WithKeySetOptions(WithJWKSMetrics(metrics)))
+	require.NoError(t, err)
+	defer authenticator.Close()
+
+	id := "3f1c2b9a-6d0e-4c1b-9a57-2f8e6f0b7c11"
+	token := signToken(t, signer, map[string]any{
+		"iss":  server.URL + "/realms/test",
+		"sub":  id,
+		"exp":  time.Now().Add(time.Minute).Unix(),
+		"role": "admin",
+		"name": "ops",
+	})
+	for range 2 {
+		identity, err := authenticator.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, id, identity.ID.String())
+		assert.Equal(t, "ops", identity.Name)
+	}
+	assert.Equal(t, int32(1), srv.fetches.Load())
+	assert.Equal(t, []error{nil}, metrics.errs)
+}
@@ -0,0 +1,336 @@
+// Package keycloaktest provides Keycloak test harnesses: a disposable Keycloak container provisioned with a realm
+// matching the Fulcrum claims layout for integration tests, and a local token signer for unit tests
+package keycloaktest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/client"
+	"github.com/fulcrumproject/commons/keycloak"
+	"github.com/fulcrumproject/commons/keycloak/admin"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// DefaultImage is the Keycloak image started by default
+	DefaultImage = "quay.io/keycloak/keycloak:26.2"
+
+	adminUsername = "admin"
+	adminPassword = "admin"
+)
+
+// claimAttributes are the user attributes mapped to the token claims of the same name
+var claimAttributes = []string{"participant_id", "agent_id", "organization_id"}
+
+// Keycloak is a running Keycloak container with a provisioned realm and confidential client
+type Keycloak struct {
+	URL          string
+	Realm        string
+	ClientID     string
+	ClientSecret string
+
+	container testcontainers.Container
+	api       *client.Client
+	admin     *admin.Client
+}
+
+// Option configures the provisioned Keycloak
+type Option func(*options)
+
+type options struct {
+	image        string
+	realm        string
+	clientID     string
+	clientSecret string
+	roles        []string
+}
+
+// WithImage sets the Keycloak image, defaults to DefaultImage
+func WithImage(image string) Option {
+	return func(o *options) {
+		o.image = image
+	}
+}
+
+// WithRealm sets the name of the provisioned realm, defaults to fulcrum
+func WithRealm(realm string) Option {
+	return func(o *options) {
+		o.realm = realm
+	}
+}
+
+// WithClient sets the id and secret of the provisioned confidential client, defaults to fulcrum and secret
+func WithClient(clientID, clientSecret string) Option {
+	return func(o *options) {
+		o.clientID = clientID
+		o.clientSecret = clientSecret
+	}
+}
+
+// WithRoles adds realm roles besides the built-in auth roles, e.g. the roles registered with auth.RegisterRoles
+func WithRoles(roles ...string) Option {
+	return func(o *options) {
+		o.roles = append(o.roles, roles...)
+	}
+}
+
+// Run starts a provisioned Keycloak for the test and terminates it on cleanup, the test is skipped when Docker is not available
+func Run(t *testing.T, opts ...Option) *Keycloak {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	kc, err := Start(context.Background(), opts...)
+	if kc != nil {
+		testcontainers.CleanupContainer(t, kc.container)
+	}
+	if err != nil {
+		t.Fatalf("cannot start keycloak: %v", err)
+	}
+	return kc
+}
+
+// Start starts a Keycloak container and provisions the realm, the client and the realm roles,
+// the service account of the client gets the admin role
+func Start(ctx context.Context, opts ...Option) (*Keycloak, error) {
+	o := options{image: DefaultImage, realm: "fulcrum", clientID: "fulcrum", clientSecret: "secret"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        o.image,
+			ExposedPorts: []string{"8080/tcp"},
+			Cmd:          []string{"start-dev"},
+			Env: map[string]string{
+				"KC_BOOTSTRAP_ADMIN_USERNAME": adminUsername,
+				"KC_BOOTSTRAP_ADMIN_PASSWORD": adminPassword,
+				// variables of the images before 26
+				"KEYCLOAK_ADMIN":          adminUsername,
+				"KEYCLOAK_ADMIN_PASSWORD": adminPassword,
+			},
+			WaitingFor: wait.ForHTTP("/realms/master").WithPort("8080/tcp").WithStartupTimeout(3 * time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot start keycloak container: %w", err)
+	}
+	kc := &Keycloak{Realm: o.realm, ClientID: o.clientID, ClientSecret: o.clientSecret, container: container}
+	if kc.URL, err = container.PortEndpoint(ctx, "8080/tcp", "http"); err != nil {
+		return kc, fmt.Errorf("cannot get keycloak endpoint: %w", err)
+	}
+
+	// the master admin tokens are short lived, they are fetched again before expiring
+	tokens := client.NewCachedTokenSource(func(ctx context.Context) (string, time.Time, error) {
+		token, err := passwordToken(ctx, kc.URL+"/realms/master/protocol/openid-connect/token", url.Values{
+			"client_id": {"admin-cli"}, "username": {adminUsername}, "password": {adminPassword},
+		})
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return token.AccessToken, time.Now().Add(time.Duration(token.ExpiresIn) * time.Second), nil
+	}, 10*time.Second)
+	kc.api = client.New(kc.URL+"/admin/realms", client.WithTokenSource(tokens))
+	if kc.admin, err = admin.New(kc.Config(), admin.WithTokenSource(tokens)); err != nil {
+		return kc, err
+	}
+	if err := kc.provision(ctx, o); err != nil {
+		return kc, err
+	}
+	return kc, nil
+}
+
+// provision creates the realm with the roles and the client mapping the scope attributes to claims
+func (k *Keycloak) provision(ctx context.Context, o options) error {
+	roles := []map[string]any{}
+	for _, role := range append([]string{string(auth.RoleAdmin), string(auth.RoleParticipant), string(auth.RoleAgent)}, o.roles...) {
+		roles = append(roles, map[string]any{"name": role})
+	}
+	mappers := []map[string]any{{
+		"name":           "audience",
+		"protocol":       "openid-connect",
+		"protocolMapper": "oidc-audience-mapper",
+		"config":         map[string]string{"included.client.audience": o.clientID, "access.token.claim": "true"},
+	}}
+	for _, attr := range claimAttributes {
+		mappers = append(mappers, map[string]any{
+			"name":           attr,
+			"protocol":       "openid-connect",
+			"protocolMapper": "oidc-usermodel-attribute-mapper",
+			"config": map[string]string{
+				"user.attribute": attr, "claim.name": attr, "jsonType.label": "String",
+				"access.token.claim": "true", "id.token.claim": "true", "userinfo.token.claim": "true",
+			},
+		})
+	}
+	realm := map[string]any{
+		"realm":   o.realm,
+		"enabled": true,
+		"roles":   map[string]any{"realm": roles},
+		"clients": []map[string]any{{
+			"clientId":                  o.clientID,
+			"secret":                    o.clientSecret,
+			"enabled":                   true,
+			"publicClient":              false,
+			"serviceAccountsEnabled":    true,
+			"directAccessGrantsEnabled": true,
+			"standardFlowEnabled":       false,
+			"protocolMappers":           mappers,
+		}},
+	}
+	if err := k.api.Do(ctx, http.MethodPost, "", nil, realm, nil); err != nil {
+		return fmt.Errorf("cannot create realm %s: %w", o.realm, err)
+	}
+
+	// the user profile drops the attributes it does not declare unless unmanaged attributes are enabled
+	profilePath := "/" + url.PathEscape(o.realm) + "/users/profile"
+	profile, err := client.Get[map[string]any](ctx, k.api, profilePath, nil)
+	if err != nil {
+		return fmt.Errorf("cannot get user profile: %w", err)
+	}
+	profile["unmanagedAttributePolicy"] = "ENABLED"
+	if err := k.api.Do(ctx, http.MethodPut, profilePath, nil, profile, nil); err != nil {
+		return fmt.Errorf("cannot update user profile: %w", err)
+	}
+
+	serviceAccount, err := k.admin.FindUserByUsername(ctx, "service-account-"+o.clientID)
+	if err != nil {
+		return err
+	}
+	return k.admin.AssignRealmRoles(ctx, serviceAccount.ID, string(auth.RoleAdmin))
+}
+
+// Terminate stops and removes the container
+func (k *Keycloak) Terminate(ctx context.Context) error {
+	return k.container.Terminate(ctx)
+}
+
+// Config returns the configuration of the provisioned realm and client with issuer and audience validation
+func (k *Keycloak) Config() *keycloak.Config {
+	return &keycloak.Config{
+		KeycloakURL:      k.URL,
+		Realm:            k.Realm,
+		ClientID:         k.ClientID,
+		ClientSecret:     k.ClientSecret,
+		ValidateIssuer:   true,
+		ValidateAudience: true,
+	}
+}
+
+// Authenticator creates an authenticator for the provisioned realm
+func (k *Keycloak) Authenticator(ctx context.Context, opts ...keycloak.Option) (*keycloak.Authenticator, error) {
+	return keycloak.NewAuthenticator(ctx, k.Config(), opts...)
+}
+
+// Admin returns an admin client of the provisioned realm authenticated as the master admin
+func (k *Keycloak) Admin() *admin.Client {
+	return k.admin
+}
+
+// User describes a user to create, the scope ids are stored as attributes mapped to the claims of the same name
+type User struct {
+	Username       string
+	Password       string
+	Role           auth.Role
+	ParticipantID  *properties.UUID
+	AgentID        *properties.UUID
+	OrganizationID *properties.UUID
+	Attributes     map[string][]string
+}
+
+// CreateUser creates an enabled user with the password and the realm role and returns its id, the token subject
+func (k *Keycloak) CreateUser(ctx context.Context, user User) (properties.UUID, error) {
+	attrs := make(map[string][]string, len(user.Attributes)+len(claimAttributes))
+	for name, values := range user.Attributes {
+		attrs[name] = values
+	}
+	for name, id := range map[string]*properties.UUID{"participant_id": user.ParticipantID, "agent_id": user.AgentID, "organization_id": user.OrganizationID} {
+		if id != nil {
+			attrs[name] = []string{id.String()}
+		}
+	}
+	id, err := k.admin.CreateUser(ctx, admin.User{
+		Username: user.Username,
+		// the profile fields are set so the account is fully set up for the password grant
+		Email:         user.Username + "@example.com",
+		FirstName:     user.Username,
+		LastName:      "Test",
+		Enabled:       true,
+		EmailVerified: true,
+		Attributes:    attrs,
+	})
+	if err != nil {
+		return properties.UUID{}, err
+	}
+
+	credential := map[string]any{"type": "password", "value": user.Password, "temporary": false}
+	path := "/" + url.PathEscape(k.Realm) + "/users/" + url.PathEscape(id) + "/reset-password"
+	if err := k.api.Do(ctx, http.MethodPut, path, nil, credential, nil); err != nil {
+		return properties.UUID{}, fmt.Errorf("cannot set password of user %s: %w", user.Username, err)
+	}
+	if user.Role != "" {
+		if err := k.admin.AssignRealmRoles(ctx, id, string(user.Role)); err != nil {
+			return properties.UUID{}, err
+		}
+	}
+	return properties.ParseUUID(id)
+}
+
+// Token mints an access token for the user with the password grant
+func (k *Keycloak) Token(ctx context.Context, username, password string) (string, error) {
+	token, err := passwordToken(ctx, k.Config().GetTokenURL(), url.Values{
+		"client_id": {k.ClientID}, "client_secret": {k.ClientSecret},
+		"username": {username}, "password": {password}, "scope": {"openid"},
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// ServiceAccountToken mints an access token for the service account of the client, which has the admin role
+func (k *Keycloak) ServiceAccountToken(ctx context.Context) (string, error) {
+	tm, err := keycloak.NewTokenManager(k.Config())
+	if err != nil {
+		return "", err
+	}
+	return tm.Token(ctx)
+}
+
+// passwordToken requests a token with the resource owner password grant
+func passwordToken(ctx context.Context, tokenURL string, form url.Values) (*keycloak.TokenResponse, error) {
+	form.Set("grant_type", "password")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &keycloak.OAuthError{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(oauthErr); err != nil || oauthErr.Code == "" {
+			oauthErr.Code = "http_error"
+		}
+		return nil, oauthErr
+	}
+	var token keycloak.TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("cannot decode token response: %w", err)
+	}
+	return &token, nil
+}
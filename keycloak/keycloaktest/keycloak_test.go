@@ -0,0 +1,40 @@
+package keycloaktest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeycloak(t *testing.T) {
+	kc := Run(t)
+	ctx := context.Background()
+
+	authenticator, err := kc.Authenticator(ctx)
+	require.NoError(t, err)
+	defer authenticator.Close()
+
+	participantID := properties.NewUUID()
+	id, err := kc.CreateUser(ctx, User{Username: "alice", Password: "password", Role: auth.RoleParticipant, ParticipantID: &participantID})
+	require.NoError(t, err)
+
+	token, err := kc.Token(ctx, "alice", "password")
+	require.NoError(t, err)
+	identity, err := authenticator.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, id, identity.ID)
+	assert.Equal(t, auth.RoleParticipant, identity.Role)
+	assert.Equal(t, &participantID, identity.Scope.ParticipantID)
+	assert.Equal(t, auth.KindUser, identity.Kind)
+
+	token, err = kc.ServiceAccountToken(ctx)
+	require.NoError(t, err)
+	identity, err = authenticator.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, auth.RoleAdmin, identity.Role)
+	assert.Equal(t, auth.KindServiceAccount, identity.Kind)
+}
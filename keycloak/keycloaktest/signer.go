@@ -0,0 +1,137 @@
+package keycloaktest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/keycloak"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// signerKeyID is the key id of the signing key in the served key set
+const signerKeyID = "keycloaktest"
+
+// Signer is a local OIDC issuer faking the discovery and key set endpoints of a Keycloak realm,
+// it mints signed tokens to unit test the authenticator without a Keycloak container
+type Signer struct {
+	URL   string
+	Realm string
+
+	signer jose.Signer
+}
+
+// SignerOption configures the Signer
+type SignerOption func(*signerOptions)
+
+type signerOptions struct {
+	algorithm jose.SignatureAlgorithm
+	realm     string
+}
+
+// WithAlgorithm sets the signing algorithm, RS256 by default or ES256
+func WithAlgorithm(alg jose.SignatureAlgorithm) SignerOption {
+	return func(o *signerOptions) {
+		o.algorithm = alg
+	}
+}
+
+// WithSignerRealm sets the realm of the served endpoints, defaults to fulcrum
+func WithSignerRealm(realm string) SignerOption {
+	return func(o *signerOptions) {
+		o.realm = realm
+	}
+}
+
+// NewSigner generates a key pair and serves the realm endpoints until the end of the test
+func NewSigner(t testing.TB, opts ...SignerOption) *Signer {
+	t.Helper()
+	o := signerOptions{algorithm: jose.RS256, realm: "fulcrum"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var key crypto.Signer
+	var err error
+	switch o.algorithm {
+	case jose.RS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jose.ES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		t.Fatalf("unsupported signing algorithm %s", o.algorithm)
+	}
+	if err != nil {
+		t.Fatalf("cannot generate signing key: %v", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: o.algorithm, Key: jose.JSONWebKey{Key: key, KeyID: signerKeyID}},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatalf("cannot create signer: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	s := &Signer{URL: server.URL, Realm: o.realm, signer: signer}
+
+	issuer := s.Config().GetIssuer()
+	mux.HandleFunc("/realms/"+o.realm+"/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer,
+			"jwks_uri":                              issuer + "/protocol/openid-connect/certs",
+			"token_endpoint":                        issuer + "/protocol/openid-connect/token",
+			"id_token_signing_alg_values_supported": []string{string(o.algorithm)},
+		})
+	})
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: signerKeyID, Algorithm: string(o.algorithm), Use: "sig"}}}
+	mux.HandleFunc("/realms/"+o.realm+"/protocol/openid-connect/certs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(keySet)
+	})
+	return s
+}
+
+// Config returns the configuration of the served realm with issuer validation
+func (s *Signer) Config() *keycloak.Config {
+	return &keycloak.Config{KeycloakURL: s.URL, Realm: s.Realm, ClientID: "fulcrum", ValidateIssuer: true}
+}
+
+// Token mints a token for the subject with the Keycloak claims, issued by the realm now and expiring in an hour
+func (s *Signer) Token(t testing.TB, subject string, claims keycloak.Claims) string {
+	t.Helper()
+	data, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("cannot encode claims: %v", err)
+	}
+	raw := map[string]any{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("cannot decode claims: %v", err)
+	}
+	now := time.Now()
+	raw["iss"] = s.Config().GetIssuer()
+	raw["sub"] = subject
+	raw["aud"] = "fulcrum"
+	raw["iat"] = now.Unix()
+	raw["exp"] = now.Add(time.Hour).Unix()
+	return s.Sign(t, raw)
+}
+
+// Sign mints a token with exactly the given claims, e.g. to test expired tokens or foreign issuers
+func (s *Signer) Sign(t testing.TB, claims any) string {
+	t.Helper()
+	token, err := jwt.Signed(s.signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatalf("cannot sign token: %v", err)
+	}
+	return token
+}
@@ -0,0 +1,48 @@
+package keycloaktest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/keycloak"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	for _, alg := range []jose.SignatureAlgorithm{jose.RS256, jose.ES256} {
+		t.Run(string(alg), func(t *testing.T) {
+			signer := NewSigner(t, WithAlgorithm(alg))
+			authenticator, err := keycloak.NewAuthenticator(context.Background(), signer.Config())
+			require.NoError(t, err)
+			defer authenticator.Close()
+
+			id := properties.NewUUID()
+			claims := keycloak.Claims{Role: "admin", Name: "Alice"}
+			claims.RealmAccess.Roles = []string{"participant"}
+			identity, err := authenticator.Authenticate(context.Background(), signer.Token(t, id.String(), claims))
+			require.NoError(t, err)
+			assert.Equal(t, id, identity.ID)
+			assert.Equal(t, auth.RoleAdmin, identity.Role, "the role claim takes precedence over the realm roles")
+			assert.Equal(t, "Alice", identity.Name)
+
+			_, err = authenticator.Authenticate(context.Background(), signer.Token(t, "alice", claims))
+			assert.Error(t, err, "subjects must be UUIDs")
+
+			expired := signer.Sign(t, map[string]any{
+				"iss": signer.Config().GetIssuer(), "sub": id.String(), "role": "admin", "exp": time.Now().Add(-time.Minute).Unix(),
+			})
+			_, err = authenticator.Authenticate(context.Background(), expired)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSigner_Realm(t *testing.T) {
+	signer := NewSigner(t, WithSignerRealm("tenant"))
+	assert.Equal(t, signer.URL+"/realms/tenant", signer.Config().GetIssuer())
+}
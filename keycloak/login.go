@@ -0,0 +1,180 @@
+package keycloak
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// LoginOption configures Login
+type LoginOption func(*loginConfig)
+
+type loginConfig struct {
+	scopes     []string
+	port       int
+	openURL    func(string) error
+	httpClient *http.Client
+}
+
+// WithLoginScopes sets the requested scopes, defaults to openid
+func WithLoginScopes(scopes ...string) LoginOption {
+	return func(c *loginConfig) {
+		c.scopes = scopes
+	}
+}
+
+// WithRedirectPort sets the localhost port of the redirect listener, defaults to a random free port
+// Keycloak clients with an exact redirect URI need a fixed port
+func WithRedirectPort(port int) LoginOption {
+	return func(c *loginConfig) {
+		c.port = port
+	}
+}
+
+// WithOpenURL sets the function opening the authorization URL, defaults to the system browser
+// e.g. print the URL for headless environments
+func WithOpenURL(open func(url string) error) LoginOption {
+	return func(c *loginConfig) {
+		c.openURL = open
+	}
+}
+
+// WithLoginHTTPClient sets the HTTP client used for the discovery and the code exchange
+func WithLoginHTTPClient(c *http.Client) LoginOption {
+	return func(lc *loginConfig) {
+		lc.httpClient = c
+	}
+}
+
+// Login runs the authorization code flow with PKCE for CLIs and local tools: it listens on a localhost redirect URI,
+// opens the browser on the Keycloak login page and exchanges the returned code, ctx bounds the whole flow
+// The client must be public or the config must have its secret, and allow the http://127.0.0.1 redirect URI
+// In generic OIDC mode the endpoints are discovered from the issuer
+func Login(ctx context.Context, cfg *Config, opts ...LoginOption) (*TokenResponse, error) {
+	lc := loginConfig{scopes: []string{"openid"}, openURL: openBrowser, httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&lc)
+	}
+	endpoints, err := cfg.Endpoints(ctx, lc.httpClient)
+	if err != nil {
+		return nil, err
+	}
+	if endpoints.AuthURL == "" || endpoints.TokenURL == "" {
+		return nil, errors.New("login requires the authorization and token endpoints in the OIDC discovery")
+	}
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", lc.port))
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen for the login redirect: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr().String())
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res result
+		switch {
+		case q.Get("state") != state:
+			res.err = errors.New("login callback with invalid state")
+		case q.Get("error") != "":
+			res.err = &OAuthError{StatusCode: http.StatusBadRequest, Code: q.Get("error"), Description: q.Get("error_description")}
+		case q.Get("code") == "":
+			res.err = errors.New("login callback without code")
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, "Login failed, you can close this window.", http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Login completed, you can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	authURL := endpoints.AuthURL + "?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(lc.scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+	if err := lc.openURL(authURL); err != nil {
+		return nil, fmt.Errorf("cannot open the login page: %w", err)
+	}
+
+	var res result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-results:
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	if cfg.ClientSecret != "" {
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+	return postToken(ctx, lc.httpClient, endpoints.TokenURL, form)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// openBrowser opens the URL with the platform default browser
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
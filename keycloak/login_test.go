@@ -0,0 +1,119 @@
+package keycloak
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLoginServer fakes the Keycloak authorization and token endpoints, the authorization endpoint
+// redirects back with the query returned by callback
+func newLoginServer(t *testing.T, callback func(state string) url.Values) *httptest.Server {
+	var challenge, redirectURI string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realms/test/protocol/openid-connect/auth", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "code", q.Get("response_type"))
+		assert.Equal(t, "cli", q.Get("client_id"))
+		assert.Equal(t, "S256", q.Get("code_challenge_method"))
+		assert.Equal(t, "openid profile", q.Get("scope"))
+		challenge, redirectURI = q.Get("code_challenge"), q.Get("redirect_uri")
+		http.Redirect(w, r, redirectURI+"?"+callback(q.Get("state")).Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/realms/test/protocol/openid-connect/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "authorization_code", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "the-code", r.PostForm.Get("code"))
+		assert.Equal(t, redirectURI, r.PostForm.Get("redirect_uri"))
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 300})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// visit follows the authorization URL like a browser would
+func visit(u string) error {
+	go func() {
+		resp, err := http.Get(u)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	return nil
+}
+
+func TestLogin(t *testing.T) {
+	server := newLoginServer(t, func(state string) url.Values {
+		return url.Values{"code": {"the-code"}, "state": {state}}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := Login(ctx, &Config{KeycloakURL: server.URL, Realm: "test", ClientID: "cli"}, WithOpenURL(visit), WithLoginScopes("openid", "profile"))
+	require.NoError(t, err)
+	assert.Equal(t, &TokenResponse{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 300}, token)
+}
+
+func TestLogin_Failures(t *testing.T) {
+	tests := []struct {
+		name          string
+		callback      func(state string) url.Values
+		errorContains string
+	}{
+		{
+			name: "Access denied",
+			callback: func(state string) url.Values {
+				return url.Values{"error": {"access_denied"}, "error_description": {"user cancelled"}, "state": {state}}
+			},
+			errorContains: "oauth error access_denied (status 400): user cancelled",
+		},
+		{
+			name:          "Invalid state",
+			callback:      func(string) url.Values { return url.Values{"code": {"the-code"}, "state": {"forged"}} },
+			errorContains: "login callback with invalid state",
+		},
+		{
+			name:          "Missing code",
+			callback:      func(state string) url.Values { return url.Values{"state": {state}} },
+			errorContains: "login callback without code",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newLoginServer(t, tt.callback)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := Login(ctx, &Config{KeycloakURL: server.URL, Realm: "test", ClientID: "cli"}, WithOpenURL(visit), WithLoginScopes("openid", "profile"))
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+}
+
+func TestLogin_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var opened string
+	_, err := Login(ctx, &Config{KeycloakURL: "http://keycloak.invalid", Realm: "test", ClientID: "cli"},
+		WithOpenURL(func(u string) error { opened = u; return nil }))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, opened, "http://keycloak.invalid/realms/test/protocol/openid-connect/auth?")
+}
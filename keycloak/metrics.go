@@ -0,0 +1,90 @@
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// FailureReason classifies the token verification failures
+type FailureReason string
+
+const (
+	ReasonNotReady               FailureReason = "not_ready"
+	ReasonMalformed              FailureReason = "malformed"
+	ReasonExpired                FailureReason = "expired"
+	ReasonInactive               FailureReason = "inactive"
+	ReasonNotYetValid            FailureReason = "not_yet_valid"
+	ReasonInvalidSignature       FailureReason = "invalid_signature"
+	ReasonInvalidIssuer          FailureReason = "invalid_issuer"
+	ReasonInvalidAudience        FailureReason = "invalid_audience"
+	ReasonInvalidAuthorizedParty FailureReason = "invalid_authorized_party"
+	ReasonMissingRequiredRole    FailureReason = "missing_required_role"
+	ReasonInvalidClaims          FailureReason = "invalid_claims"
+	ReasonCanceled               FailureReason = "canceled"
+)
+
+// Metrics receives the authenticator instrumentation, the JWKS refreshes are reported when Config.JWKSCacheTTL is set
+type Metrics interface {
+	JWKSMetrics
+	// ObserveVerification is called once per Authenticate call with the failure reason, empty on success
+	ObserveVerification(reason FailureReason, elapsed time.Duration)
+}
+
+type noopMetrics struct {
+	noopJWKSMetrics
+}
+
+func (noopMetrics) ObserveVerification(FailureReason, time.Duration) {}
+
+// FailureHook is called on each failed Authenticate call, e.g. to log or alert on validation error spikes
+type FailureHook func(ctx context.Context, reason FailureReason, err error)
+
+// WithMetrics sets the receiver of the verification and JWKS refresh events
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithFailureHook adds a hook called on each failed authentication
+func WithFailureHook(hook FailureHook) Option {
+	return func(o *options) {
+		o.failureHooks = append(o.failureHooks, hook)
+	}
+}
+
+// classifyFailure maps the verification errors to a reason, go-oidc does not wrap its errors so they are matched by message
+func classifyFailure(err error) FailureReason {
+	var expired *oidc.TokenExpiredError
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrNotReady):
+		return ReasonNotReady
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ReasonCanceled
+	case errors.As(err, &expired):
+		return ReasonExpired
+	case errors.Is(err, ErrInactiveToken):
+		return ReasonInactive
+	case errors.Is(err, ErrInvalidAudience), strings.Contains(msg, "expected audience"):
+		return ReasonInvalidAudience
+	case errors.Is(err, ErrInvalidAuthorizedParty):
+		return ReasonInvalidAuthorizedParty
+	case errors.Is(err, ErrMissingRequiredRole):
+		return ReasonMissingRequiredRole
+	case strings.Contains(msg, "failed to verify signature"), strings.Contains(msg, "not signed"):
+		return ReasonInvalidSignature
+	case strings.Contains(msg, "issued by a different provider"):
+		return ReasonInvalidIssuer
+	case errors.Is(err, ErrTokenNotYetValid), errors.Is(err, ErrTokenIssuedInFuture), strings.Contains(msg, "before the nbf"):
+		return ReasonNotYetValid
+	case strings.Contains(msg, "malformed jwt"):
+		return ReasonMalformed
+	default:
+		return ReasonInvalidClaims
+	}
+}
@@ -0,0 +1,85 @@
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMetrics struct {
+	recordingJWKSMetrics
+	mu      sync.Mutex
+	reasons []FailureReason
+}
+
+func (m *recordingMetrics) ObserveVerification(reason FailureReason, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reasons = append(m.reasons, reason)
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected FailureReason
+	}{
+		{name: "Not ready", err: fmt.Errorf("%w: discovery in progress", ErrNotReady), expected: ReasonNotReady},
+		{name: "Canceled", err: context.Canceled, expected: ReasonCanceled},
+		{name: "Expired", err: &oidc.TokenExpiredError{Expiry: time.Now()}, expected: ReasonExpired},
+		{name: "Inactive", err: ErrInactiveToken, expected: ReasonInactive},
+		{name: "Audience", err: fmt.Errorf("%w: other", ErrInvalidAudience), expected: ReasonInvalidAudience},
+		{name: "Authorized party", err: fmt.Errorf("%w: other", ErrInvalidAuthorizedParty), expected: ReasonInvalidAuthorizedParty},
+		{name: "Required role", err: fmt.Errorf("%w: fulcrum-access", ErrMissingRequiredRole), expected: ReasonMissingRequiredRole},
+		{name: "Signature", err: errors.New("failed to verify signature: no matching signing key"), expected: ReasonInvalidSignature},
+		{name: "Issuer", err: errors.New("oidc: id token issued by a different provider"), expected: ReasonInvalidIssuer},
+		{name: "Not before", err: errors.New("oidc: current time before the nbf (not before) time"), expected: ReasonNotYetValid},
+		{name: "Malformed", err: errors.New("oidc: malformed jwt, expected 3 parts got 1"), expected: ReasonMalformed},
+		{name: "Claims", err: errors.New("no valid role found in token"), expected: ReasonInvalidClaims},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyFailure(tt.err))
+		})
+	}
+}
+
+func TestAuthenticator_MetricsAndHooks(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+	metrics := &recordingMetrics{}
+	var hooked []FailureReason
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: 60},
+		WithMetrics(metrics), WithFailureHook(func(ctx context.Context, reason FailureReason, err error) {
+			hooked = append(hooked, reason)
+		}))
+	require.NoError(t, err)
+	defer authenticator.Close()
+
+	sub := properties.NewUUID().String()
+	valid := signToken(t, signer, map[string]any{"sub": sub, "exp": time.Now().Add(time.Minute).Unix(), "role": "admin"})
+	expired := signToken(t, signer, map[string]any{"sub": sub, "exp": time.Now().Add(-time.Minute).Unix(), "role": "admin"})
+
+	_, err = authenticator.Authenticate(context.Background(), valid)
+	require.NoError(t, err)
+	_, err = authenticator.Authenticate(context.Background(), expired)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken, "rejections are definitive")
+	_, err = authenticator.Authenticate(context.Background(), "garbage")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken, "rejections are definitive")
+
+	assert.Equal(t, []FailureReason{"", ReasonExpired, ReasonMalformed}, metrics.reasons)
+	assert.Equal(t, []FailureReason{ReasonExpired, ReasonMalformed}, hooked)
+	assert.Equal(t, []error{nil}, metrics.errs, "JWKS refreshes are reported")
+}
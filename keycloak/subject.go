@@ -0,0 +1,61 @@
+package keycloak
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/google/uuid"
+)
+
+// SubjectStrategy selects how the identity id is derived from the token
+type SubjectStrategy string
+
+const (
+	// SubjectUUID parses the sub claim as UUID
+	SubjectUUID SubjectStrategy = "uuid"
+	// SubjectUUIDv5 derives a deterministic UUIDv5 from the sub claim in the configured namespace
+	SubjectUUIDv5 SubjectStrategy = "uuidv5"
+	// SubjectClaim parses the configured claim as UUID
+	SubjectClaim SubjectStrategy = "claim"
+)
+
+// subjectMapper maps the token subject to the identity id
+type subjectMapper func(subject string, raw map[string]any) (properties.UUID, error)
+
+// newSubjectMapper validates the subject strategy of the config
+func newSubjectMapper(cfg *Config) (subjectMapper, error) {
+	switch cfg.SubjectStrategy {
+	case "", SubjectUUID:
+		return func(subject string, _ map[string]any) (properties.UUID, error) {
+			return properties.ParseUUID(subject)
+		}, nil
+	case SubjectUUIDv5:
+		namespace := uuid.NameSpaceURL
+		if cfg.SubjectNamespace != "" {
+			var err error
+			if namespace, err = uuid.Parse(cfg.SubjectNamespace); err != nil {
+				return nil, fmt.Errorf("invalid subject namespace: %w", err)
+			}
+		}
+		return func(subject string, _ map[string]any) (properties.UUID, error) {
+			if subject == "" {
+				return properties.UUID{}, errors.New("missing subject")
+			}
+			return uuid.NewSHA1(namespace, []byte(subject)), nil
+		}, nil
+	case SubjectClaim:
+		if cfg.SubjectClaim == "" {
+			return nil, errors.New("subject claim strategy requires the subject claim name")
+		}
+		return func(_ string, raw map[string]any) (properties.UUID, error) {
+			id, ok := raw[cfg.SubjectClaim].(string)
+			if !ok {
+				return properties.UUID{}, fmt.Errorf("missing subject claim %s", cfg.SubjectClaim)
+			}
+			return properties.ParseUUID(id)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown subject strategy %q", cfg.SubjectStrategy)
+	}
+}
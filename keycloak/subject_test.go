@@ -0,0 +1,87 @@
+package keycloak
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectMapper(t *testing.T) {
+	id := properties.NewUUID()
+	namespace := properties.NewUUID()
+
+	tests := []struct {
+		name     string
+		config   *Config
+		subject  string
+		raw      map[string]any
+		expected properties.UUID
+		errMsg   string
+	}{
+		{name: "Default UUID", config: &Config{}, subject: id.String(), expected: id},
+		{name: "UUID", config: &Config{SubjectStrategy: SubjectUUID}, subject: id.String(), expected: id},
+		{name: "Invalid UUID", config: &Config{SubjectStrategy: SubjectUUID}, subject: "alice@idp", errMsg: "invalid UUID"},
+		{name: "UUIDv5", config: &Config{SubjectStrategy: SubjectUUIDv5}, subject: "alice@idp", expected: uuid.NewSHA1(uuid.NameSpaceURL, []byte("alice@idp"))},
+		{name: "UUIDv5 in namespace", config: &Config{SubjectStrategy: SubjectUUIDv5, SubjectNamespace: namespace.String()}, subject: "alice@idp", expected: uuid.NewSHA1(namespace, []byte("alice@idp"))},
+		{name: "UUIDv5 without subject", config: &Config{SubjectStrategy: SubjectUUIDv5}, errMsg: "missing subject"},
+		{name: "Claim", config: &Config{SubjectStrategy: SubjectClaim, SubjectClaim: "fulcrum_id"}, subject: "alice@idp", raw: map[string]any{"fulcrum_id": id.String()}, expected: id},
+		{name: "Missing claim", config: &Config{SubjectStrategy: SubjectClaim, SubjectClaim: "fulcrum_id"}, subject: "alice@idp", errMsg: "missing subject claim fulcrum_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper, err := newSubjectMapper(tt.config)
+			require.NoError(t, err)
+			id, err := mapper(tt.subject, tt.raw)
+			if tt.errMsg != "" {
+				assert.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, id)
+		})
+	}
+}
+
+func TestNewSubjectMapper_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		errMsg string
+	}{
+		{name: "Unknown strategy", config: &Config{SubjectStrategy: "email"}, errMsg: `unknown subject strategy "email"`},
+		{name: "Claim without name", config: &Config{SubjectStrategy: SubjectClaim}, errMsg: "requires the subject claim name"},
+		{name: "Invalid namespace", config: &Config{SubjectStrategy: SubjectUUIDv5, SubjectNamespace: "fulcrum"}, errMsg: "invalid subject namespace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSubjectMapper(tt.config)
+			assert.ErrorContains(t, err, tt.errMsg)
+
+			_, err = NewAuthenticator(context.Background(), tt.config, WithLazyDiscovery())
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestAuthenticator_FederatedSubject(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", SubjectStrategy: SubjectUUIDv5})
+	require.NoError(t, err)
+	defer authenticator.Close()
+
+	token := signToken(t, signer, map[string]any{"sub": "github|12345", "exp": time.Now().Add(time.Minute).Unix(), "role": "admin"})
+	identity, err := authenticator.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.NewSHA1(uuid.NameSpaceURL, []byte("github|12345")), identity.ID)
+	assert.Equal(t, "github|12345", identity.Name)
+}
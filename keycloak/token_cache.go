@@ -0,0 +1,48 @@
+package keycloak
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
+)
+
+// tokenCacheJitter is the maximum fraction of the remaining token lifetime cut from the cache entries,
+// so the entries of tokens issued together do not expire together
+const tokenCacheJitter = 0.1
+
+// tokenCache keeps the identities of the verified tokens by token hash until the tokens expire,
+// the least recently used entries are evicted first when full
+type tokenCache struct {
+	clock   clock.Clock
+	entries *auth.LRU[*auth.Identity]
+}
+
+func newTokenCache(maxEntries int, clk clock.Clock) *tokenCache {
+	return &tokenCache{clock: clk, entries: auth.NewLRU[*auth.Identity](maxEntries, clk)}
+}
+
+// get returns a copy of the cached identity of the token
+func (c *tokenCache) get(token string) (*auth.Identity, bool) {
+	identity, ok := c.entries.Get(auth.HashToken(token))
+	if !ok {
+		return nil, false
+	}
+	return identity.Clone(), true
+}
+
+// put caches a copy of the identity until a jittered instant before the token expiry
+func (c *tokenCache) put(token string, identity *auth.Identity, expiry time.Time) {
+	lifetime := expiry.Sub(c.clock.Now())
+	if lifetime <= 0 {
+		return
+	}
+	ttl := lifetime - time.Duration(float64(lifetime)*tokenCacheJitter*rand.Float64())
+	c.entries.Put(auth.HashToken(token), identity.Clone(), ttl)
+}
+
+// len returns the number of cached identities
+func (c *tokenCache) len() int {
+	return c.entries.Len()
+}
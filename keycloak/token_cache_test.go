@@ -0,0 +1,79 @@
+package keycloak
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCache(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	cache := newTokenCache(2, clk)
+	identity := &auth.Identity{ID: properties.NewUUID(), Name: "alice", Role: auth.RoleAdmin}
+
+	cache.put("token-1", identity, clk.Now().Add(100*time.Second))
+	cached, ok := cache.get("token-1")
+	require.True(t, ok)
+	assert.Equal(t, identity, cached)
+	cached.Name = "mallory"
+	cached, _ = cache.get("token-1")
+	assert.Equal(t, "alice", cached.Name, "cached identities are copies")
+
+	clk.Advance(89 * time.Second)
+	_, ok = cache.get("token-1")
+	assert.True(t, ok, "jitter cuts at most a tenth of the lifetime")
+	clk.Advance(11 * time.Second)
+	_, ok = cache.get("token-1")
+	assert.False(t, ok, "entries expire with the token")
+
+	cache.put("expired", identity, clk.Now().Add(-time.Second))
+	assert.Equal(t, 0, cache.len())
+
+	cache.put("token-1", identity, clk.Now().Add(time.Minute))
+	cache.put("token-2", identity, clk.Now().Add(time.Minute))
+	_, ok = cache.get("token-1")
+	require.True(t, ok)
+	cache.put("token-3", identity, clk.Now().Add(time.Minute))
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("token-3")
+	assert.True(t, ok)
+	_, ok = cache.get("token-1")
+	assert.True(t, ok, "recently used entries are kept")
+	_, ok = cache.get("token-2")
+	assert.False(t, ok, "the least recently used entry is evicted")
+}
+
+func TestAuthenticator_VerifiedTokenCache(t *testing.T) {
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, nil)
+	clk := clock.NewFake(time.Now().Truncate(time.Second))
+	metrics := &recordingMetrics{}
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: 60, VerifiedTokenCacheSize: 10},
+		WithClock(clk), WithMetrics(metrics))
+	require.NoError(t, err)
+	defer authenticator.Close()
+
+	id := properties.NewUUID()
+	token := signToken(t, signer, map[string]any{"sub": id.String(), "exp": clk.Now().Add(time.Minute).Unix(), "role": "admin"})
+	for range 3 {
+		identity, err := authenticator.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, id, identity.ID)
+	}
+	assert.Equal(t, 1, authenticator.tokens.len())
+	assert.Equal(t, []FailureReason{"", "", ""}, metrics.reasons, "cache hits are reported as verifications")
+
+	clk.Advance(time.Minute + time.Second)
+	_, err = authenticator.Authenticate(context.Background(), token)
+	var expired *oidc.TokenExpiredError
+	assert.ErrorAs(t, err, &expired)
+}
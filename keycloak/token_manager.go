@@ -0,0 +1,198 @@
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/retry"
+)
+
+// TokenManager caches the access token of a confidential client and refreshes it before expiry,
+// it is safe for concurrent use and implements client.TokenSource
+type TokenManager struct {
+	mu            sync.Mutex
+	config        *Config
+	tokenURL      string
+	clientID      string
+	clientSecret  string
+	scopes        []string
+	httpClient    *http.Client
+	clock         clock.Clock
+	refreshBefore time.Duration
+	jitter        float64
+	retry         retry.Policy
+
+	accessToken      string
+	refreshToken     string
+	expiresAt        time.Time
+	refreshExpiresAt time.Time
+	refreshAt        time.Time
+}
+
+// TokenManagerOption configures the TokenManager
+type TokenManagerOption func(*TokenManager)
+
+// WithTokenHTTPClient sets the HTTP client used to call the token endpoint
+func WithTokenHTTPClient(c *http.Client) TokenManagerOption {
+	return func(m *TokenManager) {
+		m.httpClient = c
+	}
+}
+
+// WithTokenScopes sets the OAuth scopes requested with the client credentials grant
+func WithTokenScopes(scopes ...string) TokenManagerOption {
+	return func(m *TokenManager) {
+		m.scopes = scopes
+	}
+}
+
+// WithRefreshBefore sets how long before expiry the token is refreshed, defaults to 30 seconds
+// The margin is capped to half of the token lifetime
+func WithRefreshBefore(d time.Duration) TokenManagerOption {
+	return func(m *TokenManager) {
+		m.refreshBefore = d
+	}
+}
+
+// WithRefreshJitter randomly extends the refresh margin by up to this fraction (0 to 1), defaults to 0.2,
+// so that many clients started together don't refresh at the same instant
+func WithRefreshJitter(jitter float64) TokenManagerOption {
+	return func(m *TokenManager) {
+		m.jitter = jitter
+	}
+}
+
+// WithTokenRetry sets the retry policy of the token requests, defaults to retry.DefaultPolicy
+// Client errors like invalid credentials are never retried
+func WithTokenRetry(policy retry.Policy) TokenManagerOption {
+	return func(m *TokenManager) {
+		m.retry = policy
+	}
+}
+
+// WithTokenManagerClock sets the clock used to track the token expiry
+func WithTokenManagerClock(clk clock.Clock) TokenManagerOption {
+	return func(m *TokenManager) {
+		m.clock = clk
+	}
+}
+
+// NewTokenManager creates a token manager using the client credentials of cfg against the realm token endpoint,
+// in generic OIDC mode the token endpoint is discovered from the issuer on the first request
+func NewTokenManager(cfg *Config, opts ...TokenManagerOption) (*TokenManager, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, errors.New("token manager requires client id and client secret")
+	}
+	m := &TokenManager{
+		config:        cfg,
+		clientID:      cfg.ClientID,
+		clientSecret:  cfg.ClientSecret,
+		httpClient:    http.DefaultClient,
+		clock:         clock.New(),
+		refreshBefore: 30 * time.Second,
+		jitter:        0.2,
+		retry:         retry.DefaultPolicy(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Token returns the cached access token, refreshing it when it is about to expire
+// When the refresh fails the current token is returned as long as it is still valid
+func (m *TokenManager) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	if m.accessToken != "" && now.Before(m.refreshAt) {
+		return m.accessToken, nil
+	}
+
+	token, err := retry.DoValue(ctx, m.retry, m.fetch)
+	if err != nil {
+		if m.accessToken != "" && m.clock.Now().Before(m.expiresAt) {
+			return m.accessToken, nil
+		}
+		return "", err
+	}
+	m.store(token)
+	return m.accessToken, nil
+}
+
+// Invalidate drops the cached token, e.g. after the server rejected it
+func (m *TokenManager) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accessToken, m.refreshToken = "", ""
+	m.expiresAt, m.refreshExpiresAt, m.refreshAt = time.Time{}, time.Time{}, time.Time{}
+}
+
+// fetch uses the refresh token while it is valid and falls back to the client credentials grant
+func (m *TokenManager) fetch(ctx context.Context) (*TokenResponse, error) {
+	if m.refreshToken != "" && m.clock.Now().Before(m.refreshExpiresAt) {
+		token, err := m.request(ctx, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {m.refreshToken}})
+		if err == nil {
+			return token, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		m.refreshToken = ""
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(m.scopes) > 0 {
+		form.Set("scope", strings.Join(m.scopes, " "))
+	}
+	token, err := m.request(ctx, form)
+	var oauthErr *OAuthError
+	if errors.As(err, &oauthErr) && oauthErr.StatusCode < http.StatusInternalServerError {
+		return nil, retry.Permanent(err)
+	}
+	return token, err
+}
+
+func (m *TokenManager) request(ctx context.Context, form url.Values) (*TokenResponse, error) {
+	if m.tokenURL == "" {
+		tokenURL, err := m.config.tokenEndpoint(ctx, m.httpClient)
+		if err != nil {
+			return nil, err
+		}
+		m.tokenURL = tokenURL
+	}
+	form.Set("client_id", m.clientID)
+	form.Set("client_secret", m.clientSecret)
+	return postToken(ctx, m.httpClient, m.tokenURL, form)
+}
+
+func (m *TokenManager) store(token *TokenResponse) {
+	now := m.clock.Now()
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	m.accessToken = token.AccessToken
+	m.expiresAt = now.Add(lifetime)
+	m.refreshAt = now.Add(lifetime - m.refreshMargin(lifetime))
+
+	m.refreshToken = token.RefreshToken
+	m.refreshExpiresAt = time.Time{}
+	if token.RefreshToken != "" && token.RefreshExpiresIn > 0 {
+		m.refreshExpiresAt = now.Add(time.Duration(token.RefreshExpiresIn) * time.Second)
+	}
+}
+
+// refreshMargin returns the jittered time before expiry at which the token is refreshed
+func (m *TokenManager) refreshMargin(lifetime time.Duration) time.Duration {
+	margin := min(m.refreshBefore, lifetime/2)
+	if m.jitter > 0 {
+		margin += time.Duration(float64(margin) * m.jitter * rand.Float64())
+	}
+	return min(margin, lifetime)
+}
@@ -0,0 +1,192 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/client"
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ client.TokenSource = (*TokenManager)(nil)
+
+// tokenServer is a fake Keycloak token endpoint recording the grants
+type tokenServer struct {
+	mu     sync.Mutex
+	grants []string
+	status atomic.Int32
+	issued atomic.Int32
+}
+
+func (s *tokenServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/realms/test/protocol/openid-connect/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "svc", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		s.mu.Lock()
+		s.grants = append(s.grants, r.PostForm.Get("grant_type"))
+		s.mu.Unlock()
+
+		if status := int(s.status.Load()); status != 0 {
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "bad credentials"})
+			return
+		}
+		n := s.issued.Add(1)
+		_ = json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:      "access-" + string(rune('0'+n)),
+			TokenType:        "Bearer",
+			ExpiresIn:        300,
+			RefreshToken:     "refresh",
+			RefreshExpiresIn: 600,
+		})
+	}
+}
+
+func (s *tokenServer) Grants() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.grants...)
+}
+
+func newTestTokenManager(t *testing.T, srv *tokenServer, opts ...TokenManagerOption) *TokenManager {
+	server := httptest.NewServer(srv.handler(t))
+	t.Cleanup(server.Close)
+	opts = append([]TokenManagerOption{WithTokenRetry(retry.Policy{MaxAttempts: 2})}, opts...)
+	m, err := NewTokenManager(&Config{KeycloakURL: server.URL, Realm: "test", ClientID: "svc", ClientSecret: "secret"}, opts...)
+	require.NoError(t, err)
+	return m
+}
+
+func TestTokenManager_CachesAndRefreshes(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	srv := &tokenServer{}
+	m := newTestTokenManager(t, srv, WithTokenManagerClock(clk), WithRefreshBefore(time.Minute), WithRefreshJitter(0))
+
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", token)
+
+	clk.Advance(3 * time.Minute)
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", token, "token is reused until the refresh margin")
+
+	clk.Advance(time.Minute)
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", token)
+
+	clk.Advance(11 * time.Minute)
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-3", token)
+
+	assert.Equal(t, []string{"client_credentials", "refresh_token", "client_credentials"}, srv.Grants())
+}
+
+func TestTokenManager_KeepsValidTokenOnRefreshFailure(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	srv := &tokenServer{}
+	m := newTestTokenManager(t, srv, WithTokenManagerClock(clk), WithRefreshJitter(0))
+
+	_, err := m.Token(context.Background())
+	require.NoError(t, err)
+
+	srv.status.Store(http.StatusServiceUnavailable)
+	clk.Advance(290 * time.Second)
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", token)
+
+	clk.Advance(20 * time.Second)
+	_, err = m.Token(context.Background())
+	var oauthErr *OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, http.StatusServiceUnavailable, oauthErr.StatusCode)
+}
+
+func TestTokenManager_ClientErrorsNotRetried(t *testing.T) {
+	srv := &tokenServer{}
+	srv.status.Store(http.StatusUnauthorized)
+	m := newTestTokenManager(t, srv, WithTokenRetry(retry.Policy{MaxAttempts: 5}))
+
+	_, err := m.Token(context.Background())
+	var oauthErr *OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_client", oauthErr.Code)
+	assert.EqualError(t, err, "oauth error invalid_client (status 401): bad credentials")
+	assert.Equal(t, []string{"client_credentials"}, srv.Grants())
+}
+
+func TestTokenManager_Concurrent(t *testing.T) {
+	srv := &tokenServer{}
+	m := newTestTokenManager(t, srv)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := m.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "access-1", token)
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, srv.Grants(), 1)
+
+	m.Invalidate()
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", token)
+}
+
+func TestNewTokenManager_MissingCredentials(t *testing.T) {
+	_, err := NewTokenManager(&Config{KeycloakURL: "http://localhost", Realm: "test", ClientID: "svc"})
+	assert.EqualError(t, err, "token manager requires client id and client secret")
+}
+
+func TestTokenManager_Scopes(t *testing.T) {
+	var scope string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		scope = r.PostForm.Get("scope")
+		_, _ = w.Write([]byte(`{"access_token":"a","expires_in":60}`))
+	}))
+	defer server.Close()
+
+	m, err := NewTokenManager(&Config{KeycloakURL: server.URL, Realm: "test", ClientID: "svc", ClientSecret: "secret"}, WithTokenScopes("job:read", "job:write"))
+	require.NoError(t, err)
+	_, err = m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "job:read job:write", scope)
+}
+
+func TestTokenManager_GenericIssuer(t *testing.T) {
+	issuer := newGenericIssuer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		_, _ = w.Write([]byte(`{"access_token":"a","expires_in":60}`))
+	})
+
+	m, err := NewTokenManager(&Config{IssuerURL: issuer.URL, ClientID: "svc", ClientSecret: "secret"}, WithRefreshBefore(time.Minute))
+	require.NoError(t, err)
+	for range 2 {
+		m.Invalidate()
+		token, err := m.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "a", token)
+	}
+	assert.Equal(t, int32(1), issuer.discoveries.Load(), "the token endpoint is discovered once")
+}
@@ -0,0 +1,110 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
+)
+
+// userInfoMaxEntries bounds the number of cached userinfo responses
+const userInfoMaxEntries = 10000
+
+// userInfoClient fetches the userinfo claims of a token, caching them by subject
+type userInfoClient struct {
+	url        string
+	httpClient *http.Client
+	clock      clock.Clock
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	entries map[string]userInfoEntry
+}
+
+type userInfoEntry struct {
+	claims    map[string]any
+	expiresAt time.Time
+}
+
+func newUserInfoClient(url string, ttl time.Duration, httpClient *http.Client, clk clock.Clock) *userInfoClient {
+	return &userInfoClient{url: url, ttl: ttl, httpClient: httpClient, clock: clk, entries: make(map[string]userInfoEntry)}
+}
+
+// claims returns the cached claims of the subject or calls the userinfo endpoint with the token
+func (c *userInfoClient) claims(ctx context.Context, subject, token string) (map[string]any, error) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[subject]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.claims, nil
+	}
+
+	claims, err := c.fetch(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if sub, _ := claims["sub"].(string); sub != subject {
+		return nil, fmt.Errorf("userinfo subject %q does not match token subject", sub)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= userInfoMaxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= userInfoMaxEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[subject] = userInfoEntry{claims: claims, expiresAt: now.Add(c.ttl)}
+	return claims, nil
+}
+
+func (c *userInfoClient) fetch(ctx context.Context, token string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create userinfo request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("userinfo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo endpoint returned status %d", resp.StatusCode)
+	}
+	var claims map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("cannot decode userinfo response: %w", err)
+	}
+	return claims, nil
+}
+
+// enrich adds the userinfo claims missing from the token to the identity attributes,
+// the claims mapped to identity fields are never taken from userinfo
+func enrich(identity *auth.Identity, claims map[string]any) {
+	extra := auth.ExtraClaims(claims, mappedClaims...)
+	if len(extra) == 0 {
+		return
+	}
+	if identity.Attributes == nil {
+		identity.Attributes = make(map[string]any, len(extra))
+	}
+	for k, v := range extra {
+		if _, ok := identity.Attributes[k]; !ok {
+			identity.Attributes[k] = v
+		}
+	}
+}
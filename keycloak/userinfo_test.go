@@ -0,0 +1,110 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userInfoHandler returns the claims for the bearer token, any token when empty
+func userInfoHandler(calls *atomic.Int32, token string, claims map[string]any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(claims)
+	}
+}
+
+func TestUserInfoClient_Claims(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(userInfoHandler(&calls, "token-alice", map[string]any{"sub": "alice", "email": "alice@example.com"}))
+	defer server.Close()
+	clk := clock.NewFake(time.Now())
+	c := newUserInfoClient(server.URL, time.Minute, http.DefaultClient, clk)
+
+	for range 2 {
+		claims, err := c.claims(context.Background(), "alice", "token-alice")
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com", claims["email"])
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	clk.Advance(time.Minute)
+	_, err := c.claims(context.Background(), "alice", "token-alice")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	_, err = c.claims(context.Background(), "bob", "token-alice")
+	assert.EqualError(t, err, `userinfo subject "alice" does not match token subject`)
+
+	_, err = c.claims(context.Background(), "bob", "token-bob")
+	assert.EqualError(t, err, "userinfo endpoint returned status 401")
+}
+
+func TestEnrich(t *testing.T) {
+	identity := &auth.Identity{Name: "alice", Role: auth.RoleAdmin, Attributes: map[string]any{"region": "eu-west"}}
+	enrich(identity, map[string]any{
+		"sub":    "alice",
+		"email":  "alice@example.com",
+		"locale": "it",
+		"region": "us-east",
+		"role":   "participant",
+	})
+
+	assert.Equal(t, map[string]any{"region": "eu-west", "email": "alice@example.com", "locale": "it"}, identity.Attributes)
+	assert.Equal(t, auth.RoleAdmin, identity.Role)
+
+	empty := &auth.Identity{Name: "bob", Role: auth.RoleAdmin}
+	enrich(empty, map[string]any{"sub": "bob"})
+	assert.Nil(t, empty.Attributes)
+}
+
+func TestAuthenticator_UserInfoEnrichment(t *testing.T) {
+	id := "3f1c2b9a-6d0e-4c1b-9a57-2f8e6f0b7c11"
+	var calls atomic.Int32
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, userInfoHandler(&calls, "", map[string]any{"sub": id, "email": "ops@example.com"}))
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: 60, UserInfoEnrichment: true})
+	require.NoError(t, err)
+	defer authenticator.Close()
+
+	token := signToken(t, signer, map[string]any{"sub": id, "exp": time.Now().Add(time.Minute).Unix(), "role": "admin"})
+	for range 2 {
+		identity, err := authenticator.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"email": "ops@example.com"}, identity.Attributes)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestAuthenticator_UserInfoBestEffort(t *testing.T) {
+	id := "3f1c2b9a-6d0e-4c1b-9a57-2f8e6f0b7c11"
+	var calls atomic.Int32
+	srv := &jwksServer{}
+	signer := srv.addKey(t, "kid-1")
+	server := newOIDCServer(t, srv, userInfoHandler(&calls, "other", map[string]any{"sub": id}))
+
+	authenticator, err := NewAuthenticator(context.Background(), &Config{KeycloakURL: server.URL, Realm: "test", JWKSCacheTTL: 60, UserInfoEnrichment: true})
+	require.NoError(t, err)
+	defer authenticator.Close()
+
+	token := signToken(t, signer, map[string]any{"sub": id, "exp": time.Now().Add(time.Minute).Unix(), "role": "admin"})
+	identity, err := authenticator.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Nil(t, identity.Attributes)
+	assert.Equal(t, int32(1), calls.Load())
+}
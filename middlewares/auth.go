@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"strings"
 
@@ -43,6 +44,23 @@ func Auth(authenticator auth.Authenticator) func(http.Handler) http.Handler {
 	}
 }
 
+// AuthOptional is like Auth but sets the anonymous identity on requests without an Authorization header,
+// requests with an invalid token are still rejected
+func AuthOptional(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	authenticate := Auth(authenticator)
+	return func(next http.Handler) http.Handler {
+		authenticated := authenticate(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				ctx := auth.WithIdentity(r.Context(), auth.Anonymous())
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
 // ObjectScopeExtractor defines a function type that extracts the auth target scope from a request
 type ObjectScopeExtractor func(r *http.Request) (auth.ObjectScope, error)
 
@@ -54,14 +72,74 @@ type ObjectScopeProvider interface {
 	ObjectScope() (auth.ObjectScope, error)
 }
 
+// AuthzMetrics receives the authorization middleware instrumentation
+type AuthzMetrics interface {
+	// ObserveDecision is called once per request, reason is empty when allowed
+	ObserveDecision(action auth.Action, object auth.ObjectType, allowed bool, reason string)
+}
+
+type noopAuthzMetrics struct{}
+
+func (noopAuthzMetrics) ObserveDecision(auth.Action, auth.ObjectType, bool, string) {}
+
+// ReasonScopeExtraction is the metric reason of requests failing before authorization because the scope cannot be extracted
+const ReasonScopeExtraction = "scope_extraction"
+
+// reasonError is the metric reason of authorizer errors without a structured reason
+const reasonError = "error"
+
+type authzConfig struct {
+	verbose bool
+	metrics AuthzMetrics
+}
+
+// AuthzOption configures the authorization middlewares
+type AuthzOption func(*authzConfig)
+
+// WithVerboseErrors includes the structured denial details (action, object type, missing role or scopes) in 403 responses
+func WithVerboseErrors(verbose bool) AuthzOption {
+	return func(c *authzConfig) {
+		c.verbose = verbose
+	}
+}
+
+// WithAuthzMetrics sets the receiver of the authorization decisions
+func WithAuthzMetrics(m AuthzMetrics) AuthzOption {
+	return func(c *authzConfig) {
+		c.metrics = m
+	}
+}
+
 // AuthzFromExtractor is the base authorization middleware that uses a scope extractor function
 // to get the authorization target scope from the request
+// It panics when the action or object type is missing from the populated auth catalogs to catch typos at startup
 func AuthzFromExtractor(
 	object auth.ObjectType,
 	action auth.Action,
 	authorizer auth.Authorizer,
 	extractor ObjectScopeExtractor,
+	opts ...AuthzOption,
 ) func(http.Handler) http.Handler {
+	if err := auth.CheckCatalog(action, object); err != nil {
+		panic(fmt.Sprintf("middlewares: %v", err))
+	}
+	cfg := authzConfig{metrics: noopAuthzMetrics{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	deny := func(w http.ResponseWriter, r *http.Request, err error, reason string) {
+		cfg.metrics.ObserveDecision(action, object, false, reason)
+		resp := response.ErrUnauthorized(err).(*response.ErrResponse)
+		if cfg.verbose {
+			resp.Details = map[string]string{"action": string(action), "objectType": string(object)}
+			var denied *auth.DeniedError
+			if errors.As(err, &denied) {
+				maps.Copy(resp.Details, denied.Details())
+			}
+		}
+		render.Render(w, r, resp)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -71,15 +149,21 @@ func AuthzFromExtractor(
 			// Extract scope using the provided extractor
 			scope, err := extractor(r)
 			if err != nil {
-				render.Render(w, r, response.ErrUnauthorized(err))
+				deny(w, r, err, ReasonScopeExtraction)
 				return
 			}
 
 			// Authorize action
-			if err := authorizer.Authorize(identity, action, object, scope); err != nil {
-				render.Render(w, r, response.ErrUnauthorized(err))
+			if err := auth.AuthorizeCtx(r.Context(), authorizer, identity, action, object, scope); err != nil {
+				reason := reasonError
+				var denied *auth.DeniedError
+				if errors.As(err, &denied) {
+					reason = string(denied.Reason)
+				}
+				deny(w, r, err, reason)
 				return
 			}
+			cfg.metrics.ObserveDecision(action, object, true, "")
 
 			// Continue with updated context
 			next.ServeHTTP(w, r)
@@ -109,12 +193,13 @@ func AuthzFromID(
 	action auth.Action,
 	authorizer auth.Authorizer,
 	loader ObjectScopeLoader,
+	opts ...AuthzOption,
 ) func(http.Handler) http.Handler {
 	// Create an extractor that gets scope from the resource ID
 	extractor := IDScopeExtractor(loader)
 
 	// Use the base AuthzFromExtractor with our specialized extractor
-	return AuthzFromExtractor(object, action, authorizer, extractor)
+	return AuthzFromExtractor(object, action, authorizer, extractor, opts...)
 }
 
 // SimpleScopeExtractor creates an extractor that always returns empty scope
@@ -130,12 +215,13 @@ func AuthzSimple(
 	object auth.ObjectType,
 	action auth.Action,
 	authorizer auth.Authorizer,
+	opts ...AuthzOption,
 ) func(http.Handler) http.Handler {
 	// Create an extractor that always returns empty scope
 	extractor := SimpleScopeExtractor()
 
 	// Use the base AuthzFromExtractor with our specialized extractor
-	return AuthzFromExtractor(object, action, authorizer, extractor)
+	return AuthzFromExtractor(object, action, authorizer, extractor, opts...)
 }
 
 // BodyScopeExtractor creates an extractor that gets scope from the request body
@@ -160,12 +246,13 @@ func AuthzFromBody[T ObjectScopeProvider](
 	object auth.ObjectType,
 	action auth.Action,
 	authorizer auth.Authorizer,
+	opts ...AuthzOption,
 ) func(http.Handler) http.Handler {
 	// Create an extractor that gets scope from the request body
 	extractor := BodyScopeExtractor[T]()
 
 	// Use the base AuthzFromExtractor with our specialized extractor
-	return AuthzFromExtractor(object, action, authorizer, extractor)
+	return AuthzFromExtractor(object, action, authorizer, extractor, opts...)
 }
 
 // MustHaveRoles creates a middleware that ensures the authenticated user has at least one of the required roles
@@ -185,7 +272,10 @@ func MustHaveRoles(roles ...auth.Role) func(http.Handler) http.Handler {
 			}
 
 			if !hasRequiredRole {
-				err := fmt.Errorf("access denied: user role '%s' is not authorized", identity.Role)
+				err := auth.NewDeniedError(auth.ReasonMissingRole, "", "", "user role '%s' is not authorized", identity.Role)
+				if len(roles) > 0 {
+					err.MissingRole = roles[0]
+				}
 				render.Render(w, r, response.ErrUnauthorized(err))
 				return
 			}
@@ -195,3 +285,21 @@ func MustHaveRoles(roles ...auth.Role) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// MustHaveScopes creates a middleware that ensures the authenticated token was granted all the OAuth scopes
+func MustHaveScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity := auth.MustGetIdentity(r.Context())
+
+			if missing := identity.MissingScopes(scopes...); len(missing) > 0 {
+				err := auth.NewDeniedError(auth.ReasonMissingScope, "", "", "missing scopes '%s'", strings.Join(missing, " "))
+				err.RequiredScopes = missing
+				render.Render(w, r, response.ErrUnauthorized(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -144,6 +144,69 @@ func TestAuth(t *testing.T) {
 	}
 }
 
+func TestAuthOptional(t *testing.T) {
+	admin := &auth.Identity{ID: properties.NewUUID(), Name: "admin", Role: auth.RoleAdmin}
+
+	tests := []struct {
+		name           string
+		header         string
+		authenticator  *mockAuthenticator
+		expectedStatus int
+		expectedRole   auth.Role
+		expectCalled   bool
+	}{
+		{
+			name:           "No header sets anonymous",
+			authenticator:  &mockAuthenticator{identity: admin},
+			expectedStatus: http.StatusOK,
+			expectedRole:   auth.RoleAnonymous,
+		},
+		{
+			name:           "Valid token",
+			header:         "Bearer token",
+			authenticator:  &mockAuthenticator{identity: admin},
+			expectedStatus: http.StatusOK,
+			expectedRole:   auth.RoleAdmin,
+			expectCalled:   true,
+		},
+		{
+			name:           "Invalid token is rejected",
+			header:         "Bearer token",
+			authenticator:  &mockAuthenticator{err: errors.New("expired")},
+			expectedStatus: http.StatusForbidden,
+			expectCalled:   true,
+		},
+		{
+			name:           "Malformed header is rejected",
+			header:         "Basic abc",
+			authenticator:  &mockAuthenticator{identity: admin},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var role auth.Role
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				role = auth.MustGetIdentity(r.Context()).Role
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := AuthOptional(tt.authenticator)(nextHandler)
+
+			req := httptest.NewRequest("GET", "/catalog", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedRole, role)
+			assert.Equal(t, tt.expectCalled, tt.authenticator.called)
+		})
+	}
+}
+
 func TestAuthzFromExtractor(t *testing.T) {
 	testUUID := properties.NewUUID()
 	testIdentity := &auth.Identity{
@@ -711,6 +774,62 @@ func TestMustHaveRoles(t *testing.T) {
 	}
 }
 
+func TestMustHaveRoles_DeniedReason(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := MustHaveRoles(auth.RoleAdmin)(nextHandler)
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), &auth.Identity{Role: auth.RoleAgent}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"error":"access denied: user role 'agent' is not authorized","status":"Forbidden","reason":"missing_role"}`, rr.Body.String())
+}
+
+func TestMustHaveScopes(t *testing.T) {
+	tests := []struct {
+		name           string
+		scopes         []string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "All scopes granted",
+			scopes:         []string{"service:read"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing scope",
+			scopes:         []string{"service:read", "service:write"},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"access denied: missing scopes 'service:write'","status":"Forbidden","reason":"missing_scope"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := MustHaveScopes(tt.scopes...)(nextHandler)
+
+			identity := &auth.Identity{Role: auth.RoleAdmin, OAuthScopes: []string{"openid", "service:read"}}
+			req := httptest.NewRequest("GET", "/test", nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestMustHaveRoles_PanicOnMissingIdentity(t *testing.T) {
 	// Test that middleware panics when identity is not in context
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -763,3 +882,127 @@ type mockObjectScopeProvider struct {
 func (m *mockObjectScopeProvider) ObjectScope() (auth.ObjectScope, error) {
 	return m.scope, m.err
 }
+
+type mockContextAuthorizer struct {
+	ctx context.Context
+}
+
+func (m *mockContextAuthorizer) Authorize(identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	return errors.New("Authorize must not be used")
+}
+
+func (m *mockContextAuthorizer) AuthorizeCtx(ctx context.Context, identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	m.ctx = ctx
+	return nil
+}
+
+func TestAuthzFromExtractor_ContextAuthorizer(t *testing.T) {
+	type ctxKey struct{}
+	authorizer := &mockContextAuthorizer{}
+
+	handler := AuthzSimple("service", "read", authorizer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := auth.WithIdentity(req.Context(), &auth.Identity{Role: auth.RoleAdmin})
+	ctx = context.WithValue(ctx, ctxKey{}, "request")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, authorizer.ctx)
+	assert.Equal(t, "request", authorizer.ctx.Value(ctxKey{}))
+}
+
+type decision struct {
+	action  auth.Action
+	object  auth.ObjectType
+	allowed bool
+	reason  string
+}
+
+type recordingAuthzMetrics struct {
+	decisions []decision
+}
+
+func (m *recordingAuthzMetrics) ObserveDecision(action auth.Action, object auth.ObjectType, allowed bool, reason string) {
+	m.decisions = append(m.decisions, decision{action, object, allowed, reason})
+}
+
+func TestAuthzFromExtractor_VerboseErrorsAndMetrics(t *testing.T) {
+	denied := auth.NewDeniedError(auth.ReasonMissingRole, "delete", "service", "no matching authorization rule found for action 'delete' on object 'service'")
+	denied.MissingRole = auth.RoleAdmin
+	extractorErr := errors.New("cannot load resource: not found")
+
+	tests := []struct {
+		name             string
+		authorizerErr    error
+		extractorErr     error
+		verbose          bool
+		expectedStatus   int
+		expectedBody     string
+		expectedDecision decision
+	}{
+		{
+			name:             "Allowed",
+			expectedStatus:   http.StatusOK,
+			expectedDecision: decision{"delete", "service", true, ""},
+		},
+		{
+			name:             "Denied compact",
+			authorizerErr:    denied,
+			expectedStatus:   http.StatusForbidden,
+			expectedBody:     `{"error":"access denied: no matching authorization rule found for action 'delete' on object 'service'","status":"Forbidden","reason":"missing_role"}`,
+			expectedDecision: decision{"delete", "service", false, "missing_role"},
+		},
+		{
+			name:             "Denied verbose",
+			authorizerErr:    denied,
+			verbose:          true,
+			expectedStatus:   http.StatusForbidden,
+			expectedBody:     `{"error":"access denied: no matching authorization rule found for action 'delete' on object 'service'","status":"Forbidden","reason":"missing_role","details":{"action":"delete","objectType":"service","missingRole":"admin"}}`,
+			expectedDecision: decision{"delete", "service", false, "missing_role"},
+		},
+		{
+			name:             "Unstructured error verbose",
+			authorizerErr:    errors.New("opa unavailable"),
+			verbose:          true,
+			expectedStatus:   http.StatusForbidden,
+			expectedBody:     `{"error":"opa unavailable","status":"Forbidden","details":{"action":"delete","objectType":"service"}}`,
+			expectedDecision: decision{"delete", "service", false, "error"},
+		},
+		{
+			name:             "Extractor error",
+			extractorErr:     extractorErr,
+			expectedStatus:   http.StatusForbidden,
+			expectedBody:     `{"error":"cannot load resource: not found","status":"Forbidden"}`,
+			expectedDecision: decision{"delete", "service", false, ReasonScopeExtraction},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &recordingAuthzMetrics{}
+			extractor := func(r *http.Request) (auth.ObjectScope, error) {
+				return nil, tt.extractorErr
+			}
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := AuthzFromExtractor("service", "delete", &mockAuthorizer{err: tt.authorizerErr}, extractor,
+				WithVerboseErrors(tt.verbose), WithAuthzMetrics(metrics))(nextHandler)
+
+			req := httptest.NewRequest("DELETE", "/services/1", nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), &auth.Identity{Role: auth.RoleParticipant}))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			assert.Equal(t, []decision{tt.expectedDecision}, metrics.decisions)
+		})
+	}
+}
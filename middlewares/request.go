@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/fulcrumproject/commons/errs"
 	"github.com/fulcrumproject/commons/properties"
 	"github.com/fulcrumproject/commons/response"
 	"github.com/go-chi/chi/v5"
@@ -20,21 +21,30 @@ const (
 
 // ID extracts and validates the UUID from URL paths with /{id} format
 func ID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		idParam := chi.URLParam(r, "id")
-		if idParam != "" {
-			id, err := properties.ParseUUID(idParam)
-			if err != nil {
-				render.Render(w, r, response.ErrInvalidRequest(err))
+	return IDFromParam("id")(next)
+}
+
+// IDFromParam extracts and validates the UUID of the named chi URL parameter, e.g. /{jobId},
+// and stores it in the request context for MustGetID and AuthzFromID
+// Requests without the parameter are passed through, invalid UUIDs are rejected with ErrInvalidRequest
+func IDFromParam(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idParam := chi.URLParam(r, name)
+			if idParam != "" {
+				id, err := properties.ParseUUID(idParam)
+				if err != nil {
+					render.Render(w, r, response.ErrInvalidRequest(err))
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), uuidContextKey, id)
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
-
-			ctx := context.WithValue(r.Context(), uuidContextKey, id)
-			next.ServeHTTP(w, r.WithContext(ctx))
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // MustGetID retrieves the UUID from the request context
@@ -46,8 +56,14 @@ func MustGetID(ctx context.Context) properties.UUID {
 	return id
 }
 
-// DecodeBody is middleware that decodes the request body into a struct
+// Validator is implemented by request bodies validating themselves once decoded
+type Validator interface {
+	Validate() error
+}
+
+// DecodeBody is middleware that decodes the request body into a struct, validates it when it implements Validator
 // and stores it in the request context for later middlewares and handlers
+// Validation failures are rendered as MultiErrInvalidRequest with the errs field errors
 func DecodeBody[T any]() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -60,6 +76,14 @@ func DecodeBody[T any]() func(http.Handler) http.Handler {
 				return
 			}
 
+			// Validate the decoded body
+			if validator, ok := any(v).(Validator); ok {
+				if err := validator.Validate(); err != nil {
+					render.Render(w, r, response.MultiErrInvalidRequest(validationErrors(err)))
+					return
+				}
+			}
+
 			// Store the decoded body in the context
 			ctx := context.WithValue(r.Context(), decodedBodyContextKey, v)
 
@@ -69,6 +93,26 @@ func DecodeBody[T any]() func(http.Handler) http.Handler {
 	}
 }
 
+// validationErrors flattens the joined validation errors, the errors without field errors are reported without path
+func validationErrors(err error) []response.ValidationError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var result []response.ValidationError
+		for _, e := range joined.Unwrap() {
+			result = append(result, validationErrors(e)...)
+		}
+		return result
+	}
+	fields := errs.FieldsOf(err)
+	if len(fields) == 0 {
+		return []response.ValidationError{{Message: err.Error()}}
+	}
+	result := make([]response.ValidationError, 0, len(fields))
+	for _, f := range fields {
+		result = append(result, response.ValidationError{Path: f.Path, Message: f.Message})
+	}
+	return result
+}
+
 // MustGetBody retrieves and casts the decoded body to a specific type
 func MustGetBody[T any](ctx context.Context) T {
 	var zero T
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/fulcrumproject/commons/errs"
 	"github.com/fulcrumproject/commons/properties"
+	"github.com/fulcrumproject/commons/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -91,6 +94,47 @@ func TestID(t *testing.T) {
 	}
 }
 
+func TestIDFromParam(t *testing.T) {
+	id := properties.NewUUID()
+
+	tests := []struct {
+		name           string
+		params         map[string]string
+		expectedStatus int
+		expectedID     *properties.UUID
+	}{
+		{name: "Named parameter", params: map[string]string{"jobId": id.String()}, expectedStatus: http.StatusOK, expectedID: &id},
+		{name: "Other parameter ignored", params: map[string]string{"id": id.String()}, expectedStatus: http.StatusOK},
+		{name: "Invalid UUID", params: map[string]string{"jobId": "invalid-uuid"}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured *properties.UUID
+			handler := IDFromParam("jobId")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.expectedID != nil {
+					got := MustGetID(r.Context())
+					captured = &got
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/jobs", nil)
+			rctx := chi.NewRouteContext()
+			for k, v := range tt.params {
+				rctx.URLParams.Add(k, v)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedID, captured)
+		})
+	}
+}
+
 func TestMustGetID(t *testing.T) {
 	testUUID := properties.NewUUID()
 
@@ -245,6 +289,65 @@ func TestDecodeBody(t *testing.T) {
 	}
 }
 
+type validatedBody struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+func (b *validatedBody) Validate() error {
+	var err error
+	if b.Name == "" {
+		err = errors.Join(err, errs.Invalid("invalid body").WithField("name", "is required"))
+	}
+	if b.Value < 0 {
+		err = errors.Join(err, errs.Invalid("invalid body").WithField("value", "must be positive"))
+	}
+	if b.Name == "forbidden" {
+		err = errors.New("name is reserved")
+	}
+	return err
+}
+
+func TestDecodeBody_Validate(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedFailures []response.ValidationError
+	}{
+		{name: "Valid body", body: `{"name": "test", "value": 1}`, expectedStatus: http.StatusOK},
+		{name: "Field errors", body: `{"value": -1}`, expectedStatus: http.StatusBadRequest, expectedFailures: []response.ValidationError{
+			{Path: "name", Message: "is required"},
+			{Path: "value", Message: "must be positive"},
+		}},
+		{name: "Plain error", body: `{"name": "forbidden"}`, expectedStatus: http.StatusBadRequest, expectedFailures: []response.ValidationError{
+			{Message: "name is reserved"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := DecodeBody[validatedBody]()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.NotEmpty(t, MustGetBody[validatedBody](r.Context()).Name)
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedFailures != nil {
+				var resp response.ErrResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, response.ErrInvalidFields.Error(), resp.ErrorText)
+				assert.Equal(t, tt.expectedFailures, resp.ValidationErrors)
+			}
+		})
+	}
+}
+
 func TestMustGetBody(t *testing.T) {
 	type TestStruct struct {
 		Name  string `json:"name"`
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// Message is a notification to deliver through a Channel
+type Message struct {
+	To       []string          `json:"to"`
+	Subject  string            `json:"subject"`
+	Text     string            `json:"text,omitempty"`
+	HTML     string            `json:"html,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Channel delivers notifications
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ChannelFunc adapts a function to the Channel interface
+type ChannelFunc func(ctx context.Context, msg Message) error
+
+// Send calls the underlying function
+func (f ChannelFunc) Send(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Template renders messages from text/template subject and body and an optional html/template body
+type Template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// NewTemplate parses the templates, html can be empty for text only messages
+func NewTemplate(subject, text, html string) (*Template, error) {
+	t := &Template{}
+	var err error
+	if t.subject, err = texttemplate.New("subject").Option("missingkey=error").Parse(subject); err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	if t.text, err = texttemplate.New("text").Option("missingkey=error").Parse(text); err != nil {
+		return nil, fmt.Errorf("invalid text template: %w", err)
+	}
+	if html != "" {
+		if t.html, err = htmltemplate.New("html").Option("missingkey=error").Parse(html); err != nil {
+			return nil, fmt.Errorf("invalid html template: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// MustTemplate is like NewTemplate but panics on invalid templates
+func MustTemplate(subject, text, html string) *Template {
+	t, err := NewTemplate(subject, text, html)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render executes the templates with data
+func (t *Template) Render(to []string, data any) (Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("cannot render subject: %w", err)
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("cannot render text: %w", err)
+	}
+	if t.html != nil {
+		if err := t.html.Execute(&html, data); err != nil {
+			return Message{}, fmt.Errorf("cannot render html: %w", err)
+		}
+	}
+	return Message{
+		To:      to,
+		Subject: subject.String(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	tests := []struct {
+		name         string
+		html         string
+		data         any
+		expectErr    bool
+		expectedHTML string
+	}{
+		{
+			name:         "Text and HTML",
+			html:         "<p>Hello {{.Name}}</p>",
+			data:         map[string]string{"Name": "<b>Ops</b>", "Service": "db"},
+			expectedHTML: "<p>Hello &lt;b&gt;Ops&lt;/b&gt;</p>",
+		},
+		{
+			name: "Text only",
+			data: map[string]string{"Name": "Ops", "Service": "db"},
+		},
+		{
+			name:      "Missing key",
+			data:      map[string]string{"Name": "Ops"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewTemplate("{{.Service}} is down", "Hello {{.Name}}", tt.html)
+			require.NoError(t, err)
+
+			msg, err := tmpl.Render([]string{"ops@example.com"}, tt.data)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"ops@example.com"}, msg.To)
+			assert.Equal(t, "db is down", msg.Subject)
+			assert.Contains(t, msg.Text, "Hello ")
+			assert.Equal(t, tt.expectedHTML, msg.HTML)
+		})
+	}
+}
+
+func TestNewTemplate_Invalid(t *testing.T) {
+	_, err := NewTemplate("{{.Broken", "", "")
+	assert.Error(t, err)
+
+	assert.Panics(t, func() { MustTemplate("", "", "{{end}}") })
+}
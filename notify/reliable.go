@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/retry"
+)
+
+// WithRetry wraps a channel retrying failed sends according to policy
+func WithRetry(ch Channel, policy retry.Policy) Channel {
+	return ChannelFunc(func(ctx context.Context, msg Message) error {
+		return retry.Do(ctx, policy, func(ctx context.Context) error {
+			return ch.Send(ctx, msg)
+		})
+	})
+}
+
+// WithRateLimit wraps a channel waiting on limiter before each send
+func WithRateLimit(ch Channel, limiter *Limiter) Channel {
+	return ChannelFunc(func(ctx context.Context, msg Message) error {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		return ch.Send(ctx, msg)
+	})
+}
+
+// Limiter is a token bucket allowing rate sends per second with bursts up to burst
+type Limiter struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// LimiterOption configures a Limiter
+type LimiterOption func(*Limiter)
+
+// WithLimiterClock sets the clock used to refill the bucket and wait, defaults to the real clock
+func WithLimiterClock(clk clock.Clock) LimiterOption {
+	return func(l *Limiter) {
+		l.clock = clk
+	}
+}
+
+// NewLimiter creates a full token bucket limiter
+func NewLimiter(rate float64, burst int, opts ...LimiterOption) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &Limiter{
+		clock:  clock.New(),
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.last = l.clock.Now()
+	return l
+}
+
+// Allow takes a token if one is available
+func (l *Limiter) Allow() bool {
+	return l.reserve() == 0
+}
+
+// Wait blocks until a token is available or the context is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		wait := l.reserve()
+		if wait == 0 {
+			return nil
+		}
+		timer := l.clock.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// reserve takes a token returning zero, or returns how long until one is available
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	if l.rate <= 0 {
+		return time.Hour
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/fulcrumproject/commons/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetry(t *testing.T) {
+	attempts := 0
+	ch := ChannelFunc(func(ctx context.Context, msg Message) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+
+	policy := retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}
+	err := WithRetry(ch, policy).Send(context.Background(), Message{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(2, 2, WithLimiterClock(clk))
+
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	clk.Advance(time.Hour)
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+}
+
+func TestWithRateLimit(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	sent := make(chan struct{}, 2)
+	ch := WithRateLimit(ChannelFunc(func(ctx context.Context, msg Message) error {
+		sent <- struct{}{}
+		return nil
+	}), NewLimiter(1, 1, WithLimiterClock(clk)))
+
+	require.NoError(t, ch.Send(context.Background(), Message{}))
+
+	done := make(chan error, 1)
+	go func() { done <- ch.Send(context.Background(), Message{}) }()
+	clk.BlockUntil(1)
+	assert.Len(t, sent, 1)
+
+	clk.Advance(time.Second)
+	require.NoError(t, <-done)
+	assert.Len(t, sent, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- ch.Send(ctx, Message{}) }()
+	clk.BlockUntil(1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
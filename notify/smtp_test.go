@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPChannel_Send(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         Message
+		sendErr     error
+		expectErr   bool
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "Plain text",
+			msg:         Message{To: []string{"a@example.com", "b@example.com"}, Subject: "Alert", Text: "Disk full"},
+			contains:    []string{"To: a@example.com, b@example.com", "Subject: Alert", "text/plain", "Disk full"},
+			notContains: []string{"multipart"},
+		},
+		{
+			name:     "Multipart",
+			msg:      Message{To: []string{"a@example.com"}, Subject: "Alert", Text: "Disk full", HTML: "<p>Disk full</p>"},
+			contains: []string{"multipart/alternative", "text/plain", "text/html", "<p>Disk full</p>"},
+		},
+		{
+			name:      "No recipients",
+			msg:       Message{Subject: "Alert"},
+			expectErr: true,
+		},
+		{
+			name:     "Encoded subject",
+			msg:      Message{To: []string{"a@example.com"}, Subject: "Disque plein à 95%", Text: "Disk full"},
+			contains: []string{"Subject: =?UTF-8?q?Disque_plein_=C3=A0_95%?="},
+		},
+		{
+			name:      "Subject header injection",
+			msg:       Message{To: []string{"a@example.com"}, Subject: "Alert\r\nBcc: victim@example.com"},
+			expectErr: true,
+		},
+		{
+			name:      "Recipient header injection",
+			msg:       Message{To: []string{"a@example.com\nBcc: victim@example.com"}, Subject: "Alert"},
+			expectErr: true,
+		},
+		{
+			name:      "Send failure",
+			msg:       Message{To: []string{"a@example.com"}, Subject: "Alert"},
+			sendErr:   errors.New("connection refused"),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := NewSMTPChannel(SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "user", Password: "pass", From: "noreply@example.com"})
+			var gotAddr string
+			var gotAuth smtp.Auth
+			var gotBody string
+			ch.sendMail = func(_ context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				gotAddr, gotAuth, gotBody = addr, a, string(msg)
+				return tt.sendErr
+			}
+
+			err := ch.Send(context.Background(), tt.msg)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "smtp.example.com:587", gotAddr)
+			assert.NotNil(t, gotAuth)
+			assert.Contains(t, gotBody, "From: noreply@example.com")
+			for _, s := range tt.contains {
+				assert.Contains(t, gotBody, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, gotBody, s)
+			}
+		})
+	}
+}
+
+func TestSMTPChannel_FromHeaderInjection(t *testing.T) {
+	ch := NewSMTPChannel(SMTPConfig{Host: "smtp.example.com", Port: 587, From: "noreply@example.com\r\nBcc: victim@example.com"})
+	ch.sendMail = func(context.Context, string, smtp.Auth, string, []string, []byte) error {
+		t.Fatal("the email must not be sent")
+		return nil
+	}
+
+	err := ch.Send(context.Background(), Message{To: []string{"a@example.com"}, Subject: "Alert"})
+	assert.ErrorContains(t, err, "invalid From header")
+}
+
+func TestSMTPChannel_SendHonorsContext(t *testing.T) {
+	// The server accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	ch := NewSMTPChannel(SMTPConfig{Host: addr.IP.String(), Port: addr.Port, From: "noreply@example.com"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = ch.Send(ctx, Message{To: []string{"a@example.com"}, Subject: "Alert", Text: "Disk full"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
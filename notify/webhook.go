@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/fulcrumproject/commons/events"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/fulcrumproject/commons/retry"
+)
+
+// WebhookChannel posts notifications as JSON to an URL
+type WebhookChannel struct {
+	url     string
+	client  *http.Client
+	headers http.Header
+}
+
+// NewWebhookChannel creates a webhook channel, a nil client uses http.DefaultClient
+func NewWebhookChannel(url string, client *http.Client, headers http.Header) *WebhookChannel {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookChannel{url: url, client: client, headers: headers}
+}
+
+// Send posts the message, non 2xx responses are returned as retry.StatusError
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("cannot encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create webhook request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &retry.StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// BusChannel publishes notifications on a bus topic for asynchronous delivery
+type BusChannel struct {
+	broker events.Broker
+	topic  string
+}
+
+// NewBusChannel creates a bus channel publishing on topic
+func NewBusChannel(broker events.Broker, topic string) *BusChannel {
+	return &BusChannel{broker: broker, topic: topic}
+}
+
+// Send publishes the message
+func (c *BusChannel) Send(ctx context.Context, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("cannot encode notification: %w", err)
+	}
+	var payload properties.JSON
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("cannot encode notification: %w", err)
+	}
+	return c.broker.Publish(ctx, events.Message{
+		ID:      properties.NewUUID(),
+		Topic:   c.topic,
+		Payload: payload,
+	})
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fulcrumproject/commons/events"
+	"github.com/fulcrumproject/commons/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookChannel_Send(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		expectCode int
+	}{
+		{
+			name:   "Accepted",
+			status: http.StatusAccepted,
+		},
+		{
+			name:       "Server error",
+			status:     http.StatusServiceUnavailable,
+			expectCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Message
+			var gotToken string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotToken = r.Header.Get("X-Token")
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			ch := NewWebhookChannel(srv.URL, nil, http.Header{"X-Token": {"secret"}})
+			msg := Message{To: []string{"ops"}, Subject: "Alert", Text: "Disk full"}
+			err := ch.Send(context.Background(), msg)
+
+			assert.Equal(t, "secret", gotToken)
+			assert.Equal(t, msg, got)
+			if tt.expectCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var statusErr *retry.StatusError
+			require.ErrorAs(t, err, &statusErr)
+			assert.Equal(t, tt.expectCode, statusErr.StatusCode)
+		})
+	}
+}
+
+func TestBusChannel_Send(t *testing.T) {
+	var published []events.Message
+	broker := events.BrokerFunc(func(ctx context.Context, msg events.Message) error {
+		published = append(published, msg)
+		return nil
+	})
+
+	ch := NewBusChannel(broker, "notifications")
+	err := ch.Send(context.Background(), Message{To: []string{"ops"}, Subject: "Alert"})
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+	assert.Equal(t, "notifications", published[0].Topic)
+	assert.Equal(t, "Alert", published[0].Payload["subject"])
+
+	failing := NewBusChannel(events.BrokerFunc(func(ctx context.Context, msg events.Message) error {
+		return errors.New("bus down")
+	}), "notifications")
+	assert.Error(t, failing.Send(context.Background(), Message{}))
+}
@@ -0,0 +1,123 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/commons/properties"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// KeyType is the type of a sort key value, used to decode cursors
+type KeyType int
+
+const (
+	KeyString KeyType = iota
+	KeyInt
+	KeyFloat
+	KeyTime
+	KeyUUID
+)
+
+// SortKey is a column of the keyset ordering, the last key must be unique (e.g. the primary key)
+type SortKey struct {
+	Column string
+	Type   KeyType
+	Desc   bool
+}
+
+// Codec encodes and decodes opaque cursors signed with HMAC-SHA256 and bound to the sort keys
+type Codec struct {
+	secret []byte
+	keys   []SortKey
+}
+
+// NewCodec creates a cursor codec for the sort keys
+func NewCodec(secret []byte, keys ...SortKey) *Codec {
+	return &Codec{secret: secret, keys: keys}
+}
+
+// Encode builds a cursor from the sort key values of the last returned row
+func (c *Codec) Encode(values ...any) (string, error) {
+	if len(values) != len(c.keys) {
+		return "", fmt.Errorf("expected %d cursor values, got %d", len(c.keys), len(values))
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode cursor: %w", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode verifies the cursor signature and returns the typed sort key values
+func (c *Codec) Decode(cursor string) ([]any, error) {
+	payload, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	rawSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(rawSig, c.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw) != len(c.keys) {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]any, len(raw))
+	for i, key := range c.keys {
+		if values[i], err = decodeValue(key.Type, raw[i]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+// sign computes the signature over the payload and the sort keys, so cursors can't be reused with another ordering
+func (c *Codec) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	for _, key := range c.keys {
+		fmt.Fprintf(mac, "%s:%d:%t;", key.Column, key.Type, key.Desc)
+	}
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func decodeValue(t KeyType, raw json.RawMessage) (any, error) {
+	switch t {
+	case KeyInt:
+		var v int64
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case KeyFloat:
+		var v float64
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case KeyTime:
+		var v time.Time
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case KeyUUID:
+		var v properties.UUID
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	default:
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
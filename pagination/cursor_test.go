@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := NewCodec([]byte("secret"),
+		SortKey{Column: "name", Type: KeyString},
+		SortKey{Column: "size", Type: KeyInt},
+		SortKey{Column: "score", Type: KeyFloat},
+		SortKey{Column: "created_at", Type: KeyTime, Desc: true},
+		SortKey{Column: "id", Type: KeyUUID},
+	)
+	id := properties.NewUUID()
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+
+	cursor, err := codec.Encode("disk", 42, 1.5, createdAt, id)
+	require.NoError(t, err)
+
+	values, err := codec.Decode(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"disk", int64(42), 1.5, createdAt, id}, values)
+}
+
+func TestCodec_Decode_Invalid(t *testing.T) {
+	keys := []SortKey{{Column: "id", Type: KeyInt}}
+	codec := NewCodec([]byte("secret"), keys...)
+	valid, err := codec.Encode(1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		codec  *Codec
+		cursor string
+	}{
+		{name: "Garbage", codec: codec, cursor: "not-a-cursor"},
+		{name: "Tampered payload", codec: codec, cursor: "Wzld" + valid[4:]},
+		{name: "Other secret", codec: NewCodec([]byte("other"), keys...), cursor: valid},
+		{name: "Other ordering", codec: NewCodec([]byte("secret"), SortKey{Column: "id", Type: KeyInt, Desc: true}), cursor: valid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.codec.Decode(tt.cursor)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
+
+func TestCodec_Encode_WrongArity(t *testing.T) {
+	codec := NewCodec([]byte("secret"), SortKey{Column: "id", Type: KeyInt})
+	_, err := codec.Encode(1, 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package pagination
+
+import (
+	"strings"
+
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/fulcrumproject/commons/response"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Request is the requested page
+type Request struct {
+	Cursor string
+	Limit  int
+}
+
+// Paginator builds keyset pagination queries and pages over a set of sort keys
+type Paginator struct {
+	codec        *Codec
+	keys         []SortKey
+	defaultLimit int
+	maxLimit     int
+}
+
+// Option configures a Paginator
+type Option func(*Paginator)
+
+// WithLimits sets the default and the maximum page size
+func WithLimits(defaultLimit, maxLimit int) Option {
+	return func(p *Paginator) {
+		p.defaultLimit = defaultLimit
+		p.maxLimit = maxLimit
+	}
+}
+
+// New creates a paginator signing cursors with secret
+func New(secret []byte, keys []SortKey, opts ...Option) *Paginator {
+	p := &Paginator{
+		codec:        NewCodec(secret, keys...),
+		keys:         keys,
+		defaultLimit: DefaultLimit,
+		maxLimit:     MaxLimit,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Codec returns the cursor codec
+func (p *Paginator) Codec() *Codec {
+	return p.codec
+}
+
+// Limit returns the effective page size for the request
+func (p *Paginator) Limit(req Request) int {
+	switch {
+	case req.Limit <= 0:
+		return p.defaultLimit
+	case req.Limit > p.maxLimit:
+		return p.maxLimit
+	default:
+		return req.Limit
+	}
+}
+
+// Scope returns a GORM scope applying the keyset condition, the ordering and a limit one above the page size,
+// the extra row is used by NewPage to detect if there are more results
+func (p *Paginator) Scope(req Request) (func(*gorm.DB) *gorm.DB, error) {
+	var values []any
+	if req.Cursor != "" {
+		var err error
+		if values, err = p.codec.Decode(req.Cursor); err != nil {
+			return nil, errs.Invalid("invalid cursor").WithCause(err)
+		}
+	}
+	limit := p.Limit(req)
+
+	return func(db *gorm.DB) *gorm.DB {
+		if values != nil {
+			sql, args := p.keysetCondition(values)
+			db = db.Where(sql, args...)
+		}
+		for _, key := range p.keys {
+			if key.Desc {
+				db = db.Order(key.Column + " DESC")
+			} else {
+				db = db.Order(key.Column)
+			}
+		}
+		return db.Limit(limit + 1)
+	}, nil
+}
+
+// keysetCondition builds (a > ?) OR (a = ? AND b > ?) ... supporting mixed directions
+func (p *Paginator) keysetCondition(values []any) (string, []any) {
+	var (
+		ors  []string
+		args []any
+	)
+	for i, key := range p.keys {
+		var ands []string
+		for j := range i {
+			ands = append(ands, p.keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if key.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, key.Column+op)
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// NewPage builds the page envelope from the rows fetched with Scope, keys returns the sort key values of an item
+func NewPage[T any](p *Paginator, req Request, rows []T, keys func(T) []any) (*response.Page[T], error) {
+	limit := p.Limit(req)
+	page := &response.Page[T]{Items: rows}
+	if len(rows) <= limit {
+		return page, nil
+	}
+
+	page.Items = rows[:limit]
+	page.HasMore = true
+	cursor, err := p.codec.Encode(keys(page.Items[limit-1])...)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = cursor
+	return page, nil
+}
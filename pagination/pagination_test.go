@@ -0,0 +1,88 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type testItem struct {
+	ID    int64 `gorm:"primaryKey"`
+	Group string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&testItem{}))
+	return db
+}
+
+func itemKeys(i testItem) []any { return []any{i.Group, i.ID} }
+
+func fetchPage(t *testing.T, db *gorm.DB, p *Paginator, req Request) []testItem {
+	scope, err := p.Scope(req)
+	require.NoError(t, err)
+	var rows []testItem
+	require.NoError(t, db.Scopes(scope).Find(&rows).Error)
+	return rows
+}
+
+func TestPaginator_Iterate(t *testing.T) {
+	db := newTestDB(t)
+	for i, g := range []string{"b", "a", "b", "a", "c", "b", "a"} {
+		require.NoError(t, db.Create(&testItem{ID: int64(i + 1), Group: g}).Error)
+	}
+
+	p := New([]byte("secret"), []SortKey{
+		{Column: "`group`", Type: KeyString, Desc: true},
+		{Column: "id", Type: KeyInt},
+	})
+
+	var got []int64
+	req := Request{Limit: 3}
+	pages := 0
+	for {
+		rows := fetchPage(t, db, p, req)
+		page, err := NewPage(p, req, rows, itemKeys)
+		require.NoError(t, err)
+		pages++
+		for _, item := range page.Items {
+			got = append(got, item.ID)
+		}
+		if !page.HasMore {
+			assert.Empty(t, page.NextCursor)
+			break
+		}
+		if pages == 1 {
+			// rows inserted before the cursor position must not shift the next pages
+			require.NoError(t, db.Create(&testItem{ID: 100, Group: "d"}).Error)
+		}
+		req.Cursor = page.NextCursor
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []int64{5, 1, 3, 6, 2, 4, 7}, got)
+}
+
+func TestPaginator_Limit(t *testing.T) {
+	p := New([]byte("secret"), []SortKey{{Column: "id", Type: KeyInt}}, WithLimits(10, 50))
+
+	assert.Equal(t, 10, p.Limit(Request{}))
+	assert.Equal(t, 5, p.Limit(Request{Limit: 5}))
+	assert.Equal(t, 50, p.Limit(Request{Limit: 500}))
+}
+
+func TestPaginator_Scope_InvalidCursor(t *testing.T) {
+	p := New([]byte("secret"), []SortKey{{Column: "id", Type: KeyInt}})
+
+	_, err := p.Scope(Request{Cursor: "bogus"})
+	require.Error(t, err)
+	assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,97 @@
+package queryfilter
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Op is a comparison operator
+type Op string
+
+const (
+	OpEq     Op = "eq"
+	OpNe     Op = "ne"
+	OpGt     Op = "gt"
+	OpGte    Op = "gte"
+	OpLt     Op = "lt"
+	OpLte    Op = "lte"
+	OpIn     Op = "in"
+	OpLike   Op = "like"
+	OpIsNull Op = "isnull"
+)
+
+// Validate checks if the operator is known
+func (o Op) Validate() error {
+	switch o {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpLike, OpIsNull:
+		return nil
+	default:
+		return fmt.Errorf("invalid operator: %s", o)
+	}
+}
+
+// Expr is a node of the filter AST
+type Expr interface {
+	isExpr()
+}
+
+// Condition compares a field with a value, In expects a slice and IsNull a bool
+type Condition struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+// And matches when all the expressions match
+type And []Expr
+
+// Or matches when any of the expressions match
+type Or []Expr
+
+// Not negates an expression
+type Not struct {
+	Expr Expr
+}
+
+func (Condition) isExpr() {}
+func (And) isExpr()       {}
+func (Or) isExpr()        {}
+func (Not) isExpr()       {}
+
+// Parse builds an And of conditions from query values in the form field=value or field[op]=value,
+// in values are comma separated and keys listed in ignore (e.g. pagination params) are skipped
+func Parse(values url.Values, ignore ...string) (Expr, error) {
+	skip := make(map[string]bool, len(ignore))
+	for _, k := range ignore {
+		skip[k] = true
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if !skip[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var and And
+	for _, key := range keys {
+		field, op := key, OpEq
+		if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+			field, op = key[:i], Op(key[i+1:len(key)-1])
+		}
+		if err := op.Validate(); err != nil {
+			return nil, err
+		}
+		for _, raw := range values[key] {
+			var value any = raw
+			if op == OpIn {
+				value = strings.Split(raw, ",")
+			}
+			and = append(and, Condition{Field: field, Op: op, Value: value})
+		}
+	}
+	return and, nil
+}
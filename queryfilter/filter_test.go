@@ -0,0 +1,59 @@
+package queryfilter
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		expected  Expr
+		expectErr bool
+	}{
+		{
+			name:     "Empty",
+			query:    "",
+			expected: And(nil),
+		},
+		{
+			name:  "Equality and operators",
+			query: "status=active&size[gte]=10&page=2",
+			expected: And{
+				Condition{Field: "size", Op: OpGte, Value: "10"},
+				Condition{Field: "status", Op: OpEq, Value: "active"},
+			},
+		},
+		{
+			name:  "In list",
+			query: "status[in]=active,failed",
+			expected: And{
+				Condition{Field: "status", Op: OpIn, Value: []string{"active", "failed"}},
+			},
+		},
+		{
+			name:      "Unknown operator",
+			query:     "status[regex]=.*",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			expr, err := Parse(values, "page")
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr)
+		})
+	}
+}
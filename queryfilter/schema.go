@@ -0,0 +1,245 @@
+package queryfilter
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/fulcrumproject/commons/properties"
+)
+
+// Type is the type of a filterable field used to coerce values
+type Type int
+
+const (
+	TypeString Type = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	TypeTime
+	TypeUUID
+)
+
+// Field describes a filterable field
+type Field struct {
+	// Column is the SQL column, defaults to the field name
+	Column string
+	Type   Type
+	// Ops are the allowed operators, defaults to the operators that make sense for the type
+	Ops []Op
+	// Indexed marks the column as backed by an index
+	Indexed bool
+}
+
+// Schema is the allowlist of filterable fields of an entity
+type Schema struct {
+	Fields map[string]Field
+	// RequireIndexed rejects non empty filters without a top level condition on an indexed field
+	RequireIndexed bool
+	// MaxConditions limits the number of conditions, zero means no limit
+	MaxConditions int
+}
+
+func defaultOps(t Type) []Op {
+	switch t {
+	case TypeString:
+		return []Op{OpEq, OpNe, OpIn, OpLike, OpIsNull}
+	case TypeBool:
+		return []Op{OpEq, OpNe, OpIsNull}
+	case TypeUUID:
+		return []Op{OpEq, OpNe, OpIn, OpIsNull}
+	default:
+		return []Op{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpIsNull}
+	}
+}
+
+// Validate checks the expression against the schema returning a copy with coerced values
+func (s Schema) Validate(expr Expr) (Expr, error) {
+	count := 0
+	out, err := s.validate(expr, &count)
+	if err != nil {
+		return nil, err
+	}
+	if s.MaxConditions > 0 && count > s.MaxConditions {
+		return nil, errs.Invalid("too many filter conditions: %d (max %d)", count, s.MaxConditions)
+	}
+	if s.RequireIndexed && count > 0 && !s.hasIndexedCondition(out) {
+		return nil, errs.Invalid("filter must include a condition on an indexed field")
+	}
+	return out, nil
+}
+
+func (s Schema) validate(expr Expr, count *int) (Expr, error) {
+	switch e := expr.(type) {
+	case nil:
+		return nil, nil
+	case Condition:
+		*count++
+		return s.validateCondition(e)
+	case And:
+		out := make(And, 0, len(e))
+		for _, child := range e {
+			c, err := s.validate(child, count)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, c)
+		}
+		return out, nil
+	case Or:
+		out := make(Or, 0, len(e))
+		for _, child := range e {
+			c, err := s.validate(child, count)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, c)
+		}
+		return out, nil
+	case Not:
+		c, err := s.validate(e.Expr, count)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: c}, nil
+	default:
+		return nil, fmt.Errorf("unsupported filter expression: %T", expr)
+	}
+}
+
+func (s Schema) validateCondition(c Condition) (Condition, error) {
+	field, ok := s.Fields[c.Field]
+	if !ok {
+		return c, errs.Invalid("invalid filter").WithField(c.Field, "field is not filterable")
+	}
+	ops := field.Ops
+	if len(ops) == 0 {
+		ops = defaultOps(field.Type)
+	}
+	if !slices.Contains(ops, c.Op) {
+		return c, errs.Invalid("invalid filter").WithField(c.Field, fmt.Sprintf("operator %s is not allowed", c.Op))
+	}
+
+	var err error
+	switch c.Op {
+	case OpIsNull:
+		c.Value, err = coerce(TypeBool, c.Value)
+	case OpIn:
+		c.Value, err = coerceList(field.Type, c.Value)
+	case OpLike:
+		c.Value, err = coerce(TypeString, c.Value)
+	default:
+		c.Value, err = coerce(field.Type, c.Value)
+	}
+	if err != nil {
+		return c, errs.Invalid("invalid filter").WithField(c.Field, err.Error())
+	}
+	return c, nil
+}
+
+func (s Schema) hasIndexedCondition(expr Expr) bool {
+	switch e := expr.(type) {
+	case Condition:
+		return s.Fields[e.Field].Indexed && e.Op != OpNe && e.Op != OpLike
+	case And:
+		for _, child := range e {
+			if s.hasIndexedCondition(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Schema) column(field string) string {
+	if col := s.Fields[field].Column; col != "" {
+		return col
+	}
+	return field
+}
+
+func coerceList(t Type, value any) ([]any, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		v, err := coerce(t, value)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	}
+	if rv.Len() == 0 {
+		return nil, fmt.Errorf("empty list")
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		v, err := coerce(t, rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func coerce(t Type, value any) (any, error) {
+	s, isString := value.(string)
+	switch t {
+	case TypeString:
+		if isString {
+			return s, nil
+		}
+	case TypeInt:
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	case TypeFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, nil
+			}
+		}
+	case TypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+	case TypeTime:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			if tm, err := time.Parse(time.RFC3339, v); err == nil {
+				return tm, nil
+			}
+		}
+	case TypeUUID:
+		switch v := value.(type) {
+		case properties.UUID:
+			return v, nil
+		case string:
+			if id, err := properties.ParseUUID(v); err == nil {
+				return id, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid value: %v", value)
+}
@@ -0,0 +1,107 @@
+package queryfilter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSchema = Schema{
+	Fields: map[string]Field{
+		"id":        {Type: TypeUUID, Indexed: true},
+		"name":      {Type: TypeString},
+		"size":      {Column: "size_gb", Type: TypeInt},
+		"enabled":   {Type: TypeBool},
+		"createdAt": {Column: "created_at", Type: TypeTime, Indexed: true},
+		"status":    {Type: TypeString, Ops: []Op{OpEq, OpIn}, Indexed: true},
+	},
+}
+
+func TestSchema_Validate(t *testing.T) {
+	id := properties.NewUUID()
+
+	tests := []struct {
+		name      string
+		schema    Schema
+		expr      Expr
+		expected  Expr
+		expectErr bool
+	}{
+		{
+			name: "Coerces values",
+			expr: And{
+				Condition{Field: "id", Op: OpEq, Value: id.String()},
+				Condition{Field: "size", Op: OpGt, Value: "10"},
+				Condition{Field: "enabled", Op: OpEq, Value: "true"},
+				Condition{Field: "createdAt", Op: OpLt, Value: "2024-01-02T03:04:05Z"},
+				Condition{Field: "name", Op: OpIsNull, Value: "false"},
+			},
+			expected: And{
+				Condition{Field: "id", Op: OpEq, Value: id},
+				Condition{Field: "size", Op: OpGt, Value: int64(10)},
+				Condition{Field: "enabled", Op: OpEq, Value: true},
+				Condition{Field: "createdAt", Op: OpLt, Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+				Condition{Field: "name", Op: OpIsNull, Value: false},
+			},
+		},
+		{
+			name:     "Coerces lists",
+			expr:     Or{Condition{Field: "size", Op: OpIn, Value: []string{"1", "2"}}},
+			expected: Or{Condition{Field: "size", Op: OpIn, Value: []any{int64(1), int64(2)}}},
+		},
+		{
+			name:      "Unknown field",
+			expr:      Condition{Field: "password", Op: OpEq, Value: "x"},
+			expectErr: true,
+		},
+		{
+			name:      "Operator not allowed",
+			expr:      Condition{Field: "status", Op: OpLike, Value: "act"},
+			expectErr: true,
+		},
+		{
+			name:      "Invalid value",
+			expr:      Not{Expr: Condition{Field: "size", Op: OpEq, Value: "big"}},
+			expectErr: true,
+		},
+		{
+			name:      "Too many conditions",
+			schema:    Schema{Fields: testSchema.Fields, MaxConditions: 1},
+			expr:      And{Condition{Field: "name", Op: OpEq, Value: "a"}, Condition{Field: "name", Op: OpEq, Value: "b"}},
+			expectErr: true,
+		},
+		{
+			name:      "Requires indexed condition",
+			schema:    Schema{Fields: testSchema.Fields, RequireIndexed: true},
+			expr:      And{Condition{Field: "name", Op: OpEq, Value: "a"}, Or{Condition{Field: "status", Op: OpEq, Value: "a"}}},
+			expectErr: true,
+		},
+		{
+			name:     "Indexed condition present",
+			schema:   Schema{Fields: testSchema.Fields, RequireIndexed: true},
+			expr:     And{Condition{Field: "name", Op: OpEq, Value: "a"}, Condition{Field: "status", Op: OpEq, Value: "a"}},
+			expected: And{Condition{Field: "name", Op: OpEq, Value: "a"}, Condition{Field: "status", Op: OpEq, Value: "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := tt.schema
+			if schema.Fields == nil {
+				schema = testSchema
+			}
+			expr, err := schema.Validate(tt.expr)
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package queryfilter
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var sqlOps = map[Op]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+// likeEscape is the LIKE escape character, backslash is not portable as MySQL treats it as an escape in string literals
+const likeEscape = "!"
+
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, `%`, likeEscape+`%`, `_`, likeEscape+`_`)
+
+// SQL validates the expression and translates it to a parameterized SQL condition,
+// an empty expression returns an empty string
+func (s Schema) SQL(expr Expr) (string, []any, error) {
+	valid, err := s.Validate(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	var args []any
+	sql, err := s.translate(valid, &args)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args, nil
+}
+
+// Scope validates the expression and translates it to a GORM scope
+func (s Schema) Scope(expr Expr) (func(*gorm.DB) *gorm.DB, error) {
+	sql, args, err := s.SQL(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		if sql == "" {
+			return db
+		}
+		return db.Where(sql, args...)
+	}, nil
+}
+
+func (s Schema) translate(expr Expr, args *[]any) (string, error) {
+	switch e := expr.(type) {
+	case Condition:
+		return s.translateCondition(e, args)
+	case And:
+		return s.join(e, " AND ", args)
+	case Or:
+		return s.join(e, " OR ", args)
+	case Not:
+		inner, err := s.translate(e.Expr, args)
+		if err != nil || inner == "" {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	default:
+		return "", nil
+	}
+}
+
+func (s Schema) join(exprs []Expr, sep string, args *[]any) (string, error) {
+	parts := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		part, err := s.translate(e, args)
+		if err != nil {
+			return "", err
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return "", nil
+	case 1:
+		return parts[0], nil
+	default:
+		return "(" + strings.Join(parts, sep) + ")", nil
+	}
+}
+
+func (s Schema) translateCondition(c Condition, args *[]any) (string, error) {
+	col := s.column(c.Field)
+	switch c.Op {
+	case OpIsNull:
+		isNull, ok := c.Value.(bool)
+		if !ok {
+			return "", fmt.Errorf("filter %s %s expects a bool, got %T", c.Field, c.Op, c.Value)
+		}
+		if isNull {
+			return col + " IS NULL", nil
+		}
+		return col + " IS NOT NULL", nil
+	case OpIn:
+		*args = append(*args, c.Value)
+		return col + " IN ?", nil
+	case OpLike:
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("filter %s %s expects a string, got %T", c.Field, c.Op, c.Value)
+		}
+		*args = append(*args, "%"+likeEscaper.Replace(pattern)+"%")
+		return col + " LIKE ? ESCAPE '" + likeEscape + "'", nil
+	default:
+		*args = append(*args, c.Value)
+		return fmt.Sprintf("%s %s ?", col, sqlOps[c.Op]), nil
+	}
+}
@@ -0,0 +1,150 @@
+package queryfilter
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSchema_SQL(t *testing.T) {
+	tests := []struct {
+		name         string
+		expr         Expr
+		expectedSQL  string
+		expectedArgs []any
+	}{
+		{
+			name:        "Empty",
+			expr:        And{},
+			expectedSQL: "",
+		},
+		{
+			name:         "Like escapes the escape character",
+			expr:         Condition{Field: "name", Op: OpLike, Value: `hi!\`},
+			expectedSQL:  `name LIKE ? ESCAPE '!'`,
+			expectedArgs: []any{`%hi!!\%`},
+		},
+		{
+			name:         "Single condition uses column",
+			expr:         Condition{Field: "size", Op: OpGte, Value: "5"},
+			expectedSQL:  "size_gb >= ?",
+			expectedArgs: []any{int64(5)},
+		},
+		{
+			name: "Nested groups",
+			expr: And{
+				Condition{Field: "status", Op: OpIn, Value: []string{"a", "b"}},
+				Or{
+					Condition{Field: "name", Op: OpLike, Value: "50%_off"},
+					Not{Expr: Condition{Field: "enabled", Op: OpIsNull, Value: true}},
+				},
+			},
+			expectedSQL:  `(status IN ? AND (name LIKE ? ESCAPE '!' OR NOT (enabled IS NULL)))`,
+			expectedArgs: []any{[]any{"a", "b"}, `%50!%!_off%`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := testSchema.SQL(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSQL, sql)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+type testDisk struct {
+	ID      uint `gorm:"primaryKey"`
+	Name    string
+	SizeGB  int64 `gorm:"column:size_gb"`
+	Status  string
+	Enabled bool
+}
+
+func TestSchema_Scope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&testDisk{}))
+	require.NoError(t, db.Create([]testDisk{
+		{Name: "small", SizeGB: 10, Status: "active", Enabled: true},
+		{Name: "large", SizeGB: 500, Status: "active"},
+		{Name: "50%_off", SizeGB: 50, Status: "failed", Enabled: true},
+		{Name: "500 off", SizeGB: 50, Status: "failed", Enabled: true},
+		{Name: `hi!\`, SizeGB: 1, Status: "failed"},
+	}).Error)
+
+	tests := []struct {
+		name     string
+		expr     Expr
+		expected []string
+	}{
+		{
+			name:     "No filter",
+			expr:     nil,
+			expected: []string{"small", "large", "50%_off", "500 off", `hi!\`},
+		},
+		{
+			name:     "Range and equality",
+			expr:     And{Condition{Field: "size", Op: OpGt, Value: "20"}, Condition{Field: "status", Op: OpEq, Value: "active"}},
+			expected: []string{"large"},
+		},
+		{
+			name:     "Like wildcards are literal",
+			expr:     Condition{Field: "name", Op: OpLike, Value: "%_"},
+			expected: []string{"50%_off"},
+		},
+		{
+			name:     "Like escape and backslash are literal",
+			expr:     Condition{Field: "name", Op: OpLike, Value: `!\`},
+			expected: []string{`hi!\`},
+		},
+		{
+			name:     "Injection attempt is a value",
+			expr:     Condition{Field: "name", Op: OpEq, Value: "x' OR '1'='1"},
+			expected: []string{},
+		},
+		{
+			name:     "Or and in",
+			expr:     Or{Condition{Field: "status", Op: OpIn, Value: []string{"failed"}}, Condition{Field: "enabled", Op: OpEq, Value: false}},
+			expected: []string{"large", "50%_off", "500 off", `hi!\`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := testSchema.Scope(tt.expr)
+			require.NoError(t, err)
+
+			var names []string
+			require.NoError(t, db.Model(&testDisk{}).Scopes(scope).Order("id").Pluck("name", &names).Error)
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	_, err = testSchema.Scope(Condition{Field: "secret", Op: OpEq, Value: "x"})
+	assert.Error(t, err)
+}
+
+func TestSchema_TranslateInvalidValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   Expr
+		errMsg string
+	}{
+		{name: "Is null without bool", expr: Condition{Field: "enabled", Op: OpIsNull, Value: "yes"}, errMsg: "expects a bool"},
+		{name: "Like without string", expr: Not{Expr: Condition{Field: "name", Op: OpLike, Value: 5}}, errMsg: "expects a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []any
+			_, err := testSchema.translate(And{tt.expr}, &args)
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
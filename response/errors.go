@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/fulcrumproject/commons/errs"
 	"github.com/go-chi/render"
 )
 
@@ -20,6 +21,13 @@ type ErrResponse struct {
 	StatusText     string `json:"status"` // user-level status message
 
 	ValidationErrors []ValidationError `json:"validationErrors,omitempty"` // validation errors if any
+	Reason           string            `json:"reason,omitempty"`           // machine readable denial reason if any
+	Details          map[string]string `json:"details,omitempty"`          // structured error details if any
+}
+
+// deniedReasoner is implemented by authorization errors carrying a machine readable reason
+type deniedReasoner interface {
+	DeniedReason() string
 }
 
 type ValidationError struct {
@@ -78,11 +86,58 @@ func ErrUnauthenticated(err error) render.Renderer {
 	}
 }
 
+// ErrUnauthorized renders a 403 response, the reason is filled from errors implementing DeniedReason
 func ErrUnauthorized(err error) render.Renderer {
-	return &ErrResponse{
+	resp := &ErrResponse{
 		Err:            err,
 		ErrorText:      err.Error(),
 		HTTPStatusCode: http.StatusForbidden,
 		StatusText:     "Forbidden",
 	}
+	var denied deniedReasoner
+	if errors.As(err, &denied) {
+		resp.Reason = denied.DeniedReason()
+	}
+	return resp
+}
+
+func ErrConflict(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		ErrorText:      err.Error(),
+		HTTPStatusCode: http.StatusConflict,
+		StatusText:     "Conflict",
+	}
+}
+
+func ErrUnavailable(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		ErrorText:      err.Error(),
+		HTTPStatusCode: http.StatusServiceUnavailable,
+		StatusText:     "Service unavailable",
+	}
+}
+
+// ErrFromError maps an error to the response matching its errs.Kind
+// Errors without a kind are rendered as internal errors
+func ErrFromError(err error) render.Renderer {
+	switch errs.KindOf(err) {
+	case errs.KindNotFound:
+		return ErrNotFound(err)
+	case errs.KindConflict:
+		return ErrConflict(err)
+	case errs.KindInvalid:
+		resp := ErrInvalidRequest(err).(*ErrResponse)
+		for _, f := range errs.FieldsOf(err) {
+			resp.ValidationErrors = append(resp.ValidationErrors, ValidationError{Path: f.Path, Message: f.Message})
+		}
+		return resp
+	case errs.KindUnauthorized:
+		return ErrUnauthorized(err)
+	case errs.KindUnavailable:
+		return ErrUnavailable(err)
+	default:
+		return ErrInternal(err)
+	}
 }
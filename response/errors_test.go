@@ -2,10 +2,13 @@ package response
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/fulcrumproject/commons/errs"
+	"github.com/go-chi/render"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -134,4 +137,104 @@ func TestErrUnauthorized(t *testing.T) {
 	assert.Equal(t, testErr.Error(), errResp.ErrorText, "ErrorText should match error message")
 	assert.Equal(t, http.StatusForbidden, errResp.HTTPStatusCode, "HTTPStatusCode should be Forbidden")
 	assert.Equal(t, "Forbidden", errResp.StatusText, "StatusText should be 'Forbidden'")
+	assert.Empty(t, errResp.Reason, "Reason should be empty for plain errors")
+}
+
+type reasonErr struct{ reason string }
+
+func (e *reasonErr) Error() string        { return "access denied" }
+func (e *reasonErr) DeniedReason() string { return e.reason }
+
+func TestErrUnauthorized_Reason(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &reasonErr{reason: "missing_role"})
+
+	errResp := ErrUnauthorized(err).(*ErrResponse)
+	assert.Equal(t, "missing_role", errResp.Reason)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, render.Render(w, r, errResp))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"wrapped: access denied","status":"Forbidden","reason":"missing_role"}`, w.Body.String())
+}
+
+func TestErrConflict(t *testing.T) {
+	testErr := errors.New("already exists")
+
+	renderer := ErrConflict(testErr)
+	errResp, ok := renderer.(*ErrResponse)
+	require.True(t, ok, "Expected *ErrResponse type")
+
+	assert.Equal(t, testErr, errResp.Err, "Err should match the input error")
+	assert.Equal(t, testErr.Error(), errResp.ErrorText, "ErrorText should match error message")
+	assert.Equal(t, http.StatusConflict, errResp.HTTPStatusCode, "HTTPStatusCode should be Conflict")
+	assert.Equal(t, "Conflict", errResp.StatusText, "StatusText should be 'Conflict'")
+}
+
+func TestErrUnavailable(t *testing.T) {
+	testErr := errors.New("database unreachable")
+
+	renderer := ErrUnavailable(testErr)
+	errResp, ok := renderer.(*ErrResponse)
+	require.True(t, ok, "Expected *ErrResponse type")
+
+	assert.Equal(t, testErr, errResp.Err, "Err should match the input error")
+	assert.Equal(t, testErr.Error(), errResp.ErrorText, "ErrorText should match error message")
+	assert.Equal(t, http.StatusServiceUnavailable, errResp.HTTPStatusCode, "HTTPStatusCode should be Service Unavailable")
+	assert.Equal(t, "Service unavailable", errResp.StatusText, "StatusText should be 'Service unavailable'")
+}
+
+func TestErrFromError(t *testing.T) {
+	tests := []struct {
+		name                     string
+		err                      error
+		expectedStatus           int
+		expectedValidationErrors []ValidationError
+	}{
+		{
+			name:           "Not found",
+			err:            errs.NotFound("service", "123"),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Wrapped conflict",
+			err:            fmt.Errorf("cannot create: %w", errs.Conflict("name already used")),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Invalid with fields",
+			err:            errs.Invalid("invalid service").WithField("name", "is required"),
+			expectedStatus: http.StatusBadRequest,
+			expectedValidationErrors: []ValidationError{
+				{Path: "name", Message: "is required"},
+			},
+		},
+		{
+			name:           "Unauthorized",
+			err:            errs.Unauthorized("not your service"),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Unavailable",
+			err:            errs.Wrap(errs.KindUnavailable, errors.New("timeout")),
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Plain error",
+			err:            errors.New("boom"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errResp, ok := ErrFromError(tt.err).(*ErrResponse)
+			require.True(t, ok, "Expected *ErrResponse type")
+
+			assert.Equal(t, tt.err, errResp.Err, "Err should match the input error")
+			assert.Equal(t, tt.err.Error(), errResp.ErrorText, "ErrorText should match error message")
+			assert.Equal(t, tt.expectedStatus, errResp.HTTPStatusCode)
+			assert.Equal(t, tt.expectedValidationErrors, errResp.ValidationErrors)
+		})
+	}
 }
@@ -0,0 +1,17 @@
+package response
+
+import "net/http"
+
+// Page is the envelope of a page of items in cursor paginated listings
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+func (p *Page[T]) Render(w http.ResponseWriter, r *http.Request) error {
+	if p.Items == nil {
+		p.Items = []T{}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPage_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     *Page[string]
+		expected string
+	}{
+		{
+			name:     "Empty page",
+			page:     &Page[string]{},
+			expected: `{"items":[],"hasMore":false}`,
+		},
+		{
+			name:     "Page with cursor",
+			page:     &Page[string]{Items: []string{"a"}, NextCursor: "abc", HasMore: true},
+			expected: `{"items":["a"],"nextCursor":"abc","hasMore":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, render.Render(w, r, tt.page))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.expected, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package retry
+
+import "sync"
+
+// Budget limits the share of retries across all the calls using it, protecting
+// a struggling dependency from retry storms
+// Every retry withdraws one token and every success deposits ratio tokens;
+// retries are allowed while more than half of the tokens are available
+type Budget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+// NewBudget creates a full budget
+func NewBudget(maxTokens int, ratio float64) *Budget {
+	return &Budget{
+		tokens:    float64(maxTokens),
+		maxTokens: float64(maxTokens),
+		ratio:     ratio,
+	}
+}
+
+// Available returns the current number of tokens
+func (b *Budget) Available() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens <= b.maxTokens/2 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *Budget) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.maxTokens)
+}
@@ -0,0 +1,80 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+)
+
+// StatusError carries the HTTP status code of a failed call
+type StatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("http status %d: %v", e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("http status %d", e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// OnErrors retries errors matching any of the targets with errors.Is
+func OnErrors(targets ...error) Predicate {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OnStatus retries StatusErrors with any of the given codes
+func OnStatus(codes ...int) Predicate {
+	return func(err error) bool {
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			return false
+		}
+		return slices.Contains(codes, statusErr.StatusCode)
+	}
+}
+
+// OnTransientStatus retries the HTTP statuses that usually resolve on their own
+func OnTransientStatus() Predicate {
+	return OnStatus(
+		http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	)
+}
+
+// OnNetworkErrors retries network errors such as timeouts and refused connections
+func OnNetworkErrors() Predicate {
+	return func(err error) bool {
+		var netErr net.Error
+		return errors.As(err, &netErr)
+	}
+}
+
+// Any retries when at least one of the predicates matches
+func Any(predicates ...Predicate) Predicate {
+	return func(err error) bool {
+		for _, p := range predicates {
+			if p(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
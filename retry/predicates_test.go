@@ -0,0 +1,90 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		predicate Predicate
+		err       error
+		expected  bool
+	}{
+		{
+			name:      "OnErrors matches wrapped error",
+			predicate: OnErrors(errTransient),
+			err:       fmt.Errorf("call failed: %w", errTransient),
+			expected:  true,
+		},
+		{
+			name:      "OnErrors does not match other error",
+			predicate: OnErrors(errTransient),
+			err:       errors.New("other"),
+			expected:  false,
+		},
+		{
+			name:      "OnStatus matches status",
+			predicate: OnStatus(http.StatusServiceUnavailable),
+			err:       &StatusError{StatusCode: http.StatusServiceUnavailable},
+			expected:  true,
+		},
+		{
+			name:      "OnStatus does not match other status",
+			predicate: OnStatus(http.StatusServiceUnavailable),
+			err:       &StatusError{StatusCode: http.StatusBadRequest},
+			expected:  false,
+		},
+		{
+			name:      "OnStatus does not match non status error",
+			predicate: OnStatus(http.StatusServiceUnavailable),
+			err:       errTransient,
+			expected:  false,
+		},
+		{
+			name:      "OnTransientStatus matches too many requests",
+			predicate: OnTransientStatus(),
+			err:       fmt.Errorf("wrapped: %w", &StatusError{StatusCode: http.StatusTooManyRequests}),
+			expected:  true,
+		},
+		{
+			name:      "OnNetworkErrors matches net error",
+			predicate: OnNetworkErrors(),
+			err:       &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			expected:  true,
+		},
+		{
+			name:      "Any matches one predicate",
+			predicate: Any(OnErrors(context.DeadlineExceeded), OnErrors(errTransient)),
+			err:       errTransient,
+			expected:  true,
+		},
+		{
+			name:      "Any matches none",
+			predicate: Any(OnErrors(context.DeadlineExceeded)),
+			err:       errTransient,
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.predicate(tt.err))
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	assert.Equal(t, "http status 503", (&StatusError{StatusCode: 503}).Error())
+
+	err := &StatusError{StatusCode: 502, Err: errTransient}
+	assert.Equal(t, "http status 502: transient", err.Error())
+	assert.ErrorIs(t, err, errTransient)
+}
@@ -0,0 +1,201 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+)
+
+// Predicate decides whether an error is worth another attempt
+type Predicate func(err error) bool
+
+// Metrics receives the retry instrumentation
+type Metrics interface {
+	// ObserveAttempts is called once per Do with the number of attempts made and the final error
+	ObserveAttempts(attempts int, err error)
+	// ObserveBudgetExhausted is called when a retry is skipped because the budget is empty
+	ObserveBudgetExhausted()
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveAttempts(int, error) {}
+func (noopMetrics) ObserveBudgetExhausted()    {}
+
+// Policy defines how an operation is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts including the first one, zero means unlimited
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between attempts
+	MaxDelay time.Duration
+	// Multiplier grows the delay after each attempt
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction (0 to 1)
+	Jitter float64
+	// RetryIf selects retryable errors, nil retries every error not marked as Permanent
+	RetryIf Predicate
+	// Budget optionally limits retries across calls sharing it
+	Budget *Budget
+	// Metrics optionally receives attempt and budget metrics
+	Metrics Metrics
+	// Clock is used to wait between attempts (default real clock)
+	Clock clock.Clock
+}
+
+// DefaultPolicy returns a policy with 5 attempts and jittered exponential backoff from 100ms to 10s
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:  5,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+	}
+}
+
+// Delay returns the backoff before the given retry (starting at 1)
+// The jitter is applied before the MaxDelay cap so capped delays never exceed it
+func (p Policy) Delay(retry int) time.Duration {
+	limit := float64(math.MaxInt64)
+	if p.MaxDelay > 0 {
+		limit = float64(p.MaxDelay)
+	}
+	d := float64(p.InitialDelay)
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	for i := 1; i < retry && d < limit; i++ {
+		d *= multiplier
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	// Clamp to avoid overflowing the duration when MaxDelay is unset
+	if d >= limit {
+		if p.MaxDelay > 0 {
+			return p.MaxDelay
+		}
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, attempts are exhausted or ctx is done
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, policy, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoValue is like Do for operations returning a value
+func DoValue[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	metrics := policy.Metrics
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	clk := policy.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	var zero T
+	for attempt := 1; ; attempt++ {
+		v, err := fn(ctx)
+		if err == nil {
+			if policy.Budget != nil {
+				policy.Budget.success()
+			}
+			metrics.ObserveAttempts(attempt, nil)
+			return v, nil
+		}
+
+		if !policy.retryable(err) || (policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts) {
+			metrics.ObserveAttempts(attempt, err)
+			return zero, unwrapPermanent(err)
+		}
+		if policy.Budget != nil && !policy.Budget.withdraw() {
+			metrics.ObserveBudgetExhausted()
+			metrics.ObserveAttempts(attempt, err)
+			return zero, err
+		}
+
+		timer := clk.NewTimer(policy.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			metrics.ObserveAttempts(attempt, err)
+			return zero, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C():
+		}
+	}
+}
+
+func (p Policy) retryable(err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if p.RetryIf == nil {
+		return true
+	}
+	return p.RetryIf(err)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks an error as non-retryable, also when wrapped by fn before being returned
+// Do returns the error without the mark
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func unwrapPermanent(err error) error {
+	var perm *permanentError
+	if !errors.As(err, &perm) {
+		return err
+	}
+	if err == error(perm) {
+		return perm.err
+	}
+	// The mark is nested, keep the message of the wrapping errors
+	return &unmarkedError{msg: err.Error(), err: perm.err}
+}
+
+// unmarkedError is a wrapped permanent error once the mark is removed
+type unmarkedError struct {
+	msg string
+	err error
+}
+
+func (e *unmarkedError) Error() string {
+	return e.msg
+}
+
+func (e *unmarkedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,250 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = errors.New("transient")
+
+func fastPolicy(maxAttempts int) Policy {
+	return Policy{
+		MaxAttempts:  maxAttempts,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     5 * time.Millisecond,
+		Multiplier:   2,
+	}
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	p := Policy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, p.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, p.Delay(2))
+	assert.Equal(t, 400*time.Millisecond, p.Delay(3))
+	assert.Equal(t, time.Second, p.Delay(5))
+	assert.Equal(t, time.Second, p.Delay(50))
+
+	// Jitter stays within the configured fraction
+	p.Jitter = 0.5
+	for range 100 {
+		d := p.Delay(1)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.LessOrEqual(t, d, 150*time.Millisecond)
+	}
+
+	// Jitter never pushes a capped delay beyond MaxDelay
+	for range 100 {
+		d := p.Delay(50)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+	}
+
+	// Without MaxDelay large retries saturate instead of overflowing
+	p = Policy{InitialDelay: time.Second, Multiplier: 2, Jitter: 0.2}
+	for _, retry := range []int{64, 100, 10000} {
+		assert.Equal(t, time.Duration(math.MaxInt64), p.Delay(retry))
+	}
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name             string
+		policy           Policy
+		failures         int
+		err              error
+		expectedAttempts int
+		expectedErr      error
+	}{
+		{
+			name:             "Success on first attempt",
+			policy:           fastPolicy(3),
+			failures:         0,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Success after retries",
+			policy:           fastPolicy(3),
+			failures:         2,
+			err:              errTransient,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Attempts exhausted",
+			policy:           fastPolicy(3),
+			failures:         10,
+			err:              errTransient,
+			expectedAttempts: 3,
+			expectedErr:      errTransient,
+		},
+		{
+			name:             "Permanent error",
+			policy:           fastPolicy(3),
+			failures:         10,
+			err:              Permanent(errTransient),
+			expectedAttempts: 1,
+			expectedErr:      errTransient,
+		},
+		{
+			name:             "Wrapped permanent error",
+			policy:           fastPolicy(3),
+			failures:         10,
+			err:              fmt.Errorf("cannot call backend: %w", Permanent(errTransient)),
+			expectedAttempts: 1,
+			expectedErr:      errTransient,
+		},
+		{
+			name: "Predicate rejects error",
+			policy: func() Policy {
+				p := fastPolicy(3)
+				p.RetryIf = OnErrors(context.DeadlineExceeded)
+				return p
+			}(),
+			failures:         10,
+			err:              errTransient,
+			expectedAttempts: 1,
+			expectedErr:      errTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(context.Background(), tt.policy, func(ctx context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+
+			assert.Equal(t, tt.expectedAttempts, attempts)
+			var perm *permanentError
+			assert.False(t, errors.As(err, &perm), "the permanent mark is removed")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Equal(t, tt.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDo_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := fastPolicy(0)
+	policy.InitialDelay = time.Hour
+	policy.MaxDelay = time.Hour
+
+	attempts := 0
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := Do(ctx, policy, func(ctx context.Context) error {
+		attempts++
+		return errTransient
+	})
+
+	assert.Equal(t, 1, attempts)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "transient")
+}
+
+func TestDoValue(t *testing.T) {
+	attempts := 0
+	v, err := DoValue(context.Background(), fastPolicy(3), func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts < 2 {
+			return "", errTransient
+		}
+		return "done", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "done", v)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestDo_Metrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	policy := fastPolicy(4)
+	policy.Metrics = metrics
+
+	_ = Do(context.Background(), policy, func(ctx context.Context) error { return errTransient })
+
+	assert.Equal(t, []int{4}, metrics.attempts)
+	assert.Equal(t, []error{errTransient}, metrics.errs)
+}
+
+func TestDo_Budget(t *testing.T) {
+	metrics := &recordingMetrics{}
+	policy := fastPolicy(0)
+	policy.Budget = NewBudget(4, 1)
+	policy.Metrics = metrics
+
+	// Only the tokens above half of the budget can be spent on retries
+	attempts := 0
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		attempts++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 1, metrics.budgetExhausted)
+	assert.Equal(t, float64(2), policy.Budget.Available())
+
+	// Successes refill the budget
+	require.NoError(t, Do(context.Background(), policy, func(ctx context.Context) error { return nil }))
+	assert.Equal(t, float64(3), policy.Budget.Available())
+}
+
+type recordingMetrics struct {
+	attempts        []int
+	errs            []error
+	budgetExhausted int
+}
+
+func (m *recordingMetrics) ObserveAttempts(attempts int, err error) {
+	m.attempts = append(m.attempts, attempts)
+	m.errs = append(m.errs, err)
+}
+
+func (m *recordingMetrics) ObserveBudgetExhausted() {
+	m.budgetExhausted++
+}
+
+func TestDo_Clock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	policy := Policy{MaxAttempts: 3, InitialDelay: time.Minute, Multiplier: 2, Clock: fake}
+
+	attempts := make(chan int, 3)
+	done := make(chan error, 1)
+	go func() {
+		n := 0
+		done <- Do(context.Background(), policy, func(ctx context.Context) error {
+			n++
+			attempts <- n
+			return errTransient
+		})
+	}()
+
+	assert.Equal(t, 1, <-attempts)
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	assert.Equal(t, 2, <-attempts)
+	fake.BlockUntil(1)
+	fake.Advance(2 * time.Minute)
+	assert.Equal(t, 3, <-attempts)
+	assert.ErrorIs(t, <-done, errTransient)
+}
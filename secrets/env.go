@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvProvider reads secrets from environment variables, the name is upper cased,
+// non alphanumeric characters are replaced by underscores and the prefix is prepended
+type EnvProvider struct {
+	prefix  string
+	watcher watcher
+}
+
+// NewEnvProvider creates an environment variables provider
+func NewEnvProvider(prefix string, opts ...Option) *EnvProvider {
+	return &EnvProvider{prefix: prefix, watcher: newWatcher(opts)}
+}
+
+// Get returns the value of the variable mapped from name
+func (p *EnvProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	key := p.Key(name)
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return []byte(value), nil
+}
+
+// Watch polls the variable for changes
+func (p *EnvProvider) Watch(ctx context.Context, name string, fn func([]byte)) error {
+	return p.watcher.watch(ctx, p, name, fn)
+}
+
+// Key returns the environment variable used for name
+func (p *EnvProvider) Key(name string) string {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	return p.prefix + key
+}
@@ -0,0 +1,23 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvProvider_Get(t *testing.T) {
+	t.Setenv("APP_DB_PASSWORD", "s3cret")
+	p := NewEnvProvider("APP_")
+
+	assert.Equal(t, "APP_DB_PASSWORD", p.Key("db.password"))
+
+	value, err := p.Get(context.Background(), "db-password")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("s3cret"), value)
+
+	_, err = p.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,43 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider reads secrets from files in a directory, e.g. Kubernetes or Docker mounted secrets
+type FileProvider struct {
+	dir     string
+	watcher watcher
+}
+
+// NewFileProvider creates a provider reading files from dir
+func NewFileProvider(dir string, opts ...Option) *FileProvider {
+	return &FileProvider{dir: dir, watcher: newWatcher(opts)}
+}
+
+// Get returns the content of the file without the trailing newline
+func (p *FileProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid secret name: %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secret %s: %w", name, err)
+	}
+	return bytes.TrimRight(data, "\r\n"), nil
+}
+
+// Watch polls the file for changes
+func (p *FileProvider) Watch(ctx context.Context, name string, fn func([]byte)) error {
+	return p.watcher.watch(ctx, p, name, fn)
+}
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider_Get(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api-key"), []byte("abc\n"), 0o600))
+	p := NewFileProvider(dir)
+
+	tests := []struct {
+		name      string
+		secret    string
+		expected  []byte
+		expectErr error
+	}{
+		{name: "Trims newline", secret: "api-key", expected: []byte("abc")},
+		{name: "Missing", secret: "other", expectErr: ErrNotFound},
+		{name: "Traversal", secret: "../api-key"},
+		{name: "Empty name", secret: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := p.Get(context.Background(), tt.secret)
+			if tt.expected == nil {
+				require.Error(t, err)
+				if tt.expectErr != nil {
+					assert.ErrorIs(t, err, tt.expectErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestFileProvider_Watch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "signing-key")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0o600))
+
+	clk := clock.NewFake(time.Now())
+	p := NewFileProvider(dir, WithClock(clk), WithPollInterval(time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rotated := make(chan string, 1)
+	require.NoError(t, p.Watch(ctx, "signing-key", func(value []byte) { rotated <- string(value) }))
+	clk.BlockUntil(1)
+
+	clk.Advance(time.Second)
+	select {
+	case v := <-rotated:
+		t.Fatalf("unexpected rotation to %s", v)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("v2"), 0o600))
+	clk.Advance(time.Second)
+	select {
+	case v := <-rotated:
+		assert.Equal(t, "v2", v)
+	case <-time.After(time.Second):
+		t.Fatal("rotation not notified")
+	}
+
+	assert.ErrorIs(t, p.Watch(ctx, "missing", func([]byte) {}), ErrNotFound)
+}
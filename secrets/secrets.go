@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+)
+
+var (
+	ErrNotFound = errors.New("secret not found")
+)
+
+// Provider gives access to secrets by name
+type Provider interface {
+	// Get returns the current value of the secret
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Watch reads the secret and calls fn every time its value is rotated until ctx is done,
+	// it fails if the secret can't be read initially
+	Watch(ctx context.Context, name string, fn func(value []byte)) error
+}
+
+// Option configures the watch behaviour of a provider
+type Option func(*watcher)
+
+// WithPollInterval sets how often watched secrets are checked for rotation
+func WithPollInterval(d time.Duration) Option {
+	return func(w *watcher) {
+		w.interval = d
+	}
+}
+
+// WithClock sets the clock used to schedule polls
+func WithClock(c clock.Clock) Option {
+	return func(w *watcher) {
+		w.clock = c
+	}
+}
+
+// WithLogger sets the logger used to report poll failures
+func WithLogger(l *slog.Logger) Option {
+	return func(w *watcher) {
+		w.logger = l
+	}
+}
+
+// watcher implements Watch polling Get for changes
+type watcher struct {
+	interval time.Duration
+	clock    clock.Clock
+	logger   *slog.Logger
+}
+
+func newWatcher(opts []Option) watcher {
+	w := watcher{
+		interval: time.Minute,
+		clock:    clock.New(),
+		logger:   slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(&w)
+	}
+	return w
+}
+
+func (w watcher) watch(ctx context.Context, p Provider, name string, fn func([]byte)) error {
+	last, err := p.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	ticker := w.clock.NewTicker(w.interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+			}
+			value, err := p.Get(ctx, name)
+			if err != nil {
+				if ctx.Err() == nil {
+					w.logger.Warn("Cannot refresh secret", "name", name, "error", err)
+				}
+				continue
+			}
+			if !bytes.Equal(value, last) {
+				last = value
+				fn(value)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// VaultConfig configures the Vault KV v2 provider
+type VaultConfig struct {
+	Addr      string `json:"addr" env:"VAULT_ADDR"`
+	Token     string `json:"token" env:"VAULT_TOKEN"`
+	Namespace string `json:"namespace" env:"VAULT_NAMESPACE"`
+	Mount     string `json:"mount" env:"VAULT_MOUNT"`
+}
+
+// VaultProvider reads secrets from a Vault KV v2 engine using the HTTP API,
+// names are in the form path#key and the key defaults to "value"
+type VaultProvider struct {
+	config  VaultConfig
+	client  *http.Client
+	watcher watcher
+}
+
+// NewVaultProvider creates a Vault provider, a nil client uses http.DefaultClient and the mount defaults to "secret"
+func NewVaultProvider(cfg VaultConfig, client *http.Client, opts ...Option) *VaultProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "secret"
+	}
+	return &VaultProvider{config: cfg, client: client, watcher: newWatcher(opts)}
+}
+
+// Get reads the key of the latest version of the secret
+func (p *VaultProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	path, key, ok := strings.Cut(name, "#")
+	if !ok {
+		key = "value"
+	}
+
+	u, err := url.JoinPath(p.config.Addr, "v1", p.config.Mount, "data", path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault secret path: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.config.Token)
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("cannot decode vault response: %w", err)
+	}
+	value, ok := payload.Data.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(value)
+}
+
+// Watch polls the secret for new versions
+func (p *VaultProvider) Watch(ctx context.Context, name string, fn func([]byte)) error {
+	return p.watcher.watch(ctx, p, name, fn)
+}
@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider_Get(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/kv/data/app/db":
+			w.Write([]byte(`{"data":{"data":{"value":"pw","port":5432},"metadata":{"version":3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		token     string
+		secret    string
+		expected  string
+		expectErr error
+	}{
+		{name: "Default key", token: "root", secret: "app/db", expected: "pw"},
+		{name: "Explicit non string key", token: "root", secret: "app/db#port", expected: "5432"},
+		{name: "Missing key", token: "root", secret: "app/db#user", expectErr: ErrNotFound},
+		{name: "Missing path", token: "root", secret: "app/other", expectErr: ErrNotFound},
+		{name: "Forbidden", token: "bad", secret: "app/db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: tt.token, Namespace: "team", Mount: "kv"}, nil)
+			value, err := p.Get(context.Background(), tt.secret)
+			if tt.expected == "" {
+				require.Error(t, err)
+				if tt.expectErr != nil {
+					assert.ErrorIs(t, err, tt.expectErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(value))
+		})
+	}
+}
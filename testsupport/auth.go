@@ -0,0 +1,193 @@
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fulcrumproject/commons/auth"
+)
+
+var (
+	ErrUnknownToken = errors.New("unknown token")
+	ErrDenied       = errors.New("access denied")
+)
+
+type authResult struct {
+	identity *auth.Identity
+	err      error
+}
+
+// FakeAuthenticator is a scriptable auth.Authenticator mapping tokens to results
+// Unknown tokens fail with ErrUnknownToken
+type FakeAuthenticator struct {
+	mu      sync.Mutex
+	results map[string]authResult
+	calls   []string
+}
+
+// NewFakeAuthenticator creates an authenticator that knows no token
+func NewFakeAuthenticator() *FakeAuthenticator {
+	return &FakeAuthenticator{
+		results: make(map[string]authResult),
+	}
+}
+
+// WithToken makes the token authenticate as the identity
+func (f *FakeAuthenticator) WithToken(token string, identity *auth.Identity) *FakeAuthenticator {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[token] = authResult{identity: identity}
+	return f
+}
+
+// WithTokenError makes the token fail with err
+func (f *FakeAuthenticator) WithTokenError(token string, err error) *FakeAuthenticator {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[token] = authResult{err: err}
+	return f
+}
+
+// Authenticate returns the scripted result for the token
+func (f *FakeAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, token)
+	res, ok := f.results[token]
+	if !ok {
+		return nil, ErrUnknownToken
+	}
+	return res.identity, res.err
+}
+
+// Calls returns the tokens received so far
+func (f *FakeAuthenticator) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// AuthorizeCall records the arguments of an Authorize call
+type AuthorizeCall struct {
+	Identity *auth.Identity
+	Action   auth.Action
+	Object   auth.ObjectType
+	Scope    auth.ObjectScope
+}
+
+type permission struct {
+	action auth.Action
+	object auth.ObjectType
+}
+
+// FakeAuthorizer is a scriptable auth.Authorizer denying everything not explicitly allowed
+type FakeAuthorizer struct {
+	mu         sync.Mutex
+	allowAll   bool
+	allowed    map[permission]bool
+	err        error
+	checkScope bool
+	calls      []AuthorizeCall
+}
+
+// NewFakeAuthorizer creates an authorizer denying every action
+func NewFakeAuthorizer() *FakeAuthorizer {
+	return &FakeAuthorizer{
+		allowed: make(map[permission]bool),
+		err:     ErrDenied,
+	}
+}
+
+// Allow permits the action on the object type
+func (f *FakeAuthorizer) Allow(action auth.Action, object auth.ObjectType) *FakeAuthorizer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.allowed[permission{action, object}] = true
+	return f
+}
+
+// AllowAll permits every action
+func (f *FakeAuthorizer) AllowAll() *FakeAuthorizer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.allowAll = true
+	return f
+}
+
+// DenyWith sets the error returned for denied actions
+func (f *FakeAuthorizer) DenyWith(err error) *FakeAuthorizer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+	return f
+}
+
+// CheckScope makes allowed actions also require the object scope to match the identity
+func (f *FakeAuthorizer) CheckScope() *FakeAuthorizer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checkScope = true
+	return f
+}
+
+// Authorize applies the scripted permissions
+func (f *FakeAuthorizer) Authorize(identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, AuthorizeCall{
+		Identity: identity,
+		Action:   action,
+		Object:   object,
+		Scope:    objectScope,
+	})
+
+	if !f.allowAll && !f.allowed[permission{action, object}] {
+		return fmt.Errorf("%w: %s on %s", f.err, action, object)
+	}
+	if f.checkScope && objectScope != nil && !objectScope.Matches(identity) {
+		return fmt.Errorf("%w: scope mismatch", f.err)
+	}
+	return nil
+}
+
+// Calls returns the Authorize calls received so far
+func (f *FakeAuthorizer) Calls() []AuthorizeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]AuthorizeCall(nil), f.calls...)
+}
+
+type staticAuthenticator struct {
+	identity *auth.Identity
+}
+
+// StaticAuthenticator returns an auth.Authenticator authenticating every token as the identity
+func StaticAuthenticator(identity *auth.Identity) auth.Authenticator {
+	return staticAuthenticator{identity: identity}
+}
+
+// Authenticate returns a copy of the identity
+func (s staticAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	return s.identity.Clone(), nil
+}
+
+type allowAllAuthorizer struct{}
+
+func (allowAllAuthorizer) Authorize(*auth.Identity, auth.Action, auth.ObjectType, auth.ObjectScope) error {
+	return nil
+}
+
+type denyAllAuthorizer struct{}
+
+func (denyAllAuthorizer) Authorize(identity *auth.Identity, action auth.Action, object auth.ObjectType, objectScope auth.ObjectScope) error {
+	return auth.NewDeniedError(auth.ReasonPolicy, action, object, "denied by test authorizer")
+}
+
+var (
+	// AllowAll is an auth.Authorizer permitting every action
+	AllowAll auth.Authorizer = allowAllAuthorizer{}
+	// DenyAll is an auth.Authorizer denying every action with an auth.DeniedError
+	DenyAll auth.Authorizer = denyAllAuthorizer{}
+)
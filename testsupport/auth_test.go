@@ -0,0 +1,139 @@
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeAuthenticator(t *testing.T) {
+	identity := AdminIdentity()
+	tokenErr := errors.New("expired")
+	fake := NewFakeAuthenticator().
+		WithToken("good", identity).
+		WithTokenError("expired", tokenErr)
+
+	id, err := fake.Authenticate(context.Background(), "good")
+	require.NoError(t, err)
+	assert.Same(t, identity, id)
+
+	_, err = fake.Authenticate(context.Background(), "expired")
+	assert.ErrorIs(t, err, tokenErr)
+
+	_, err = fake.Authenticate(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrUnknownToken)
+
+	assert.Equal(t, []string{"good", "expired", "unknown"}, fake.Calls())
+}
+
+func TestFakeAuthorizer(t *testing.T) {
+	identity := ParticipantIdentity()
+	otherParticipant := properties.NewUUID()
+	ownScope := &auth.DefaultObjectScope{ParticipantID: identity.Scope.ParticipantID}
+	otherScope := &auth.DefaultObjectScope{ParticipantID: &otherParticipant}
+	customErr := errors.New("custom")
+
+	tests := []struct {
+		name        string
+		setup       func() *FakeAuthorizer
+		action      auth.Action
+		object      auth.ObjectType
+		scope       auth.ObjectScope
+		expectedErr error
+	}{
+		{
+			name:        "Denies by default",
+			setup:       NewFakeAuthorizer,
+			action:      "read",
+			object:      "service",
+			expectedErr: ErrDenied,
+		},
+		{
+			name:   "Allows scripted permission",
+			setup:  func() *FakeAuthorizer { return NewFakeAuthorizer().Allow("read", "service") },
+			action: "read",
+			object: "service",
+		},
+		{
+			name:        "Denies other permission",
+			setup:       func() *FakeAuthorizer { return NewFakeAuthorizer().Allow("read", "service") },
+			action:      "delete",
+			object:      "service",
+			expectedErr: ErrDenied,
+		},
+		{
+			name:   "Allow all",
+			setup:  func() *FakeAuthorizer { return NewFakeAuthorizer().AllowAll() },
+			action: "delete",
+			object: "agent",
+			scope:  otherScope,
+		},
+		{
+			name:   "Scope check matches",
+			setup:  func() *FakeAuthorizer { return NewFakeAuthorizer().AllowAll().CheckScope() },
+			action: "read",
+			object: "service",
+			scope:  ownScope,
+		},
+		{
+			name:        "Scope check mismatch",
+			setup:       func() *FakeAuthorizer { return NewFakeAuthorizer().AllowAll().CheckScope() },
+			action:      "read",
+			object:      "service",
+			scope:       otherScope,
+			expectedErr: ErrDenied,
+		},
+		{
+			name:        "Custom deny error",
+			setup:       func() *FakeAuthorizer { return NewFakeAuthorizer().DenyWith(customErr) },
+			action:      "read",
+			object:      "service",
+			expectedErr: customErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := tt.setup()
+			err := fake.Authorize(identity, tt.action, tt.object, tt.scope)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			calls := fake.Calls()
+			require.Len(t, calls, 1)
+			assert.Equal(t, AuthorizeCall{Identity: identity, Action: tt.action, Object: tt.object, Scope: tt.scope}, calls[0])
+		})
+	}
+}
+
+func TestStaticAuthenticator(t *testing.T) {
+	identity := ParticipantIdentity()
+	authenticator := StaticAuthenticator(identity)
+
+	for _, token := range []string{"any", "other"} {
+		id, err := authenticator.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, identity, id)
+		assert.NotSame(t, identity, id)
+	}
+}
+
+func TestAllowAllAndDenyAll(t *testing.T) {
+	identity := AgentIdentity()
+
+	assert.NoError(t, AllowAll.Authorize(identity, "delete", "participant", nil))
+
+	err := DenyAll.Authorize(identity, "read", "job", nil)
+	var denied *auth.DeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Equal(t, auth.ReasonPolicy, denied.Reason)
+	assert.Equal(t, auth.Action("read"), denied.Action)
+}
@@ -0,0 +1,83 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/middlewares"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/go-chi/chi/v5"
+)
+
+// Harness is a chi router pre-wired with the commons Auth middleware backed by fakes
+type Harness struct {
+	t             testing.TB
+	Router        chi.Router
+	Authenticator *FakeAuthenticator
+	Authorizer    *FakeAuthorizer
+}
+
+// NewHarness creates a harness whose routes are all behind the Auth middleware
+func NewHarness(t testing.TB) *Harness {
+	h := &Harness{
+		t:             t,
+		Authenticator: NewFakeAuthenticator(),
+		Authorizer:    NewFakeAuthorizer(),
+	}
+	r := chi.NewRouter()
+	r.Use(middlewares.Auth(h.Authenticator))
+	h.Router = r
+	return h
+}
+
+// Token registers a new bearer token authenticating as the identity
+func (h *Harness) Token(identity *auth.Identity) string {
+	token := properties.NewUUID().String()
+	h.Authenticator.WithToken(token, identity)
+	return token
+}
+
+// Do sends a request with an optional bearer token and JSON body and returns the recorded response
+func (h *Harness) Do(method, path, token string, body any) *httptest.ResponseRecorder {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("cannot encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return h.Serve(req)
+}
+
+// Serve records the response of the router to req
+func (h *Harness) Serve(req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.Router.ServeHTTP(w, req)
+	return w
+}
+
+// DecodeJSON decodes the recorded response body into T
+func DecodeJSON[T any](t testing.TB, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("cannot decode response body %q: %v", w.Body.String(), err)
+	}
+	return v
+}
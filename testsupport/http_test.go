@@ -0,0 +1,63 @@
+package testsupport
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/middlewares"
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+type echoResponse struct {
+	Message string `json:"message"`
+	User    string `json:"user"`
+}
+
+func TestHarness(t *testing.T) {
+	h := NewHarness(t)
+	h.Authorizer.Allow("echo", "message")
+	h.Router.With(
+		middlewares.AuthzSimple("message", "echo", h.Authorizer),
+		middlewares.DecodeBody[echoRequest](),
+	).Post("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body := middlewares.MustGetBody[echoRequest](r.Context())
+		render.JSON(w, r, echoResponse{
+			Message: body.Message,
+			User:    auth.MustGetIdentity(r.Context()).Name,
+		})
+	})
+	h.Router.With(
+		middlewares.AuthzSimple("message", "delete", h.Authorizer),
+	).Delete("/echo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	token := h.Token(NewIdentity().WithName("alice").Build())
+
+	t.Run("Authorized request", func(t *testing.T) {
+		w := h.Do(http.MethodPost, "/echo", token, echoRequest{Message: "hello"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, echoResponse{Message: "hello", User: "alice"}, DecodeJSON[echoResponse](t, w))
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		w := h.Do(http.MethodPost, "/echo", "", echoRequest{Message: "hello"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		w := h.Do(http.MethodPost, "/echo", "unknown", echoRequest{Message: "hello"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Denied action", func(t *testing.T) {
+		w := h.Do(http.MethodDelete, "/echo", token, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,113 @@
+package testsupport
+
+import (
+	"context"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+)
+
+// IdentityBuilder builds valid identities with sensible defaults
+type IdentityBuilder struct {
+	identity auth.Identity
+}
+
+// NewIdentity starts building an admin identity with a random ID
+func NewIdentity() *IdentityBuilder {
+	return &IdentityBuilder{
+		identity: auth.Identity{
+			ID:   properties.NewUUID(),
+			Name: "test-user",
+			Role: auth.RoleAdmin,
+		},
+	}
+}
+
+// AdminIdentity returns an admin identity
+func AdminIdentity() *auth.Identity {
+	return NewIdentity().Build()
+}
+
+// ParticipantIdentity returns a participant identity scoped to a random participant
+func ParticipantIdentity() *auth.Identity {
+	return NewIdentity().Participant(properties.NewUUID()).Build()
+}
+
+// AgentIdentity returns an agent identity scoped to a random participant and agent
+func AgentIdentity() *auth.Identity {
+	return NewIdentity().Agent(properties.NewUUID(), properties.NewUUID()).Build()
+}
+
+// WithID sets the identity ID
+func (b *IdentityBuilder) WithID(id properties.UUID) *IdentityBuilder {
+	b.identity.ID = id
+	return b
+}
+
+// WithName sets the identity name
+func (b *IdentityBuilder) WithName(name string) *IdentityBuilder {
+	b.identity.Name = name
+	return b
+}
+
+// Admin sets the admin role and clears the scope
+func (b *IdentityBuilder) Admin() *IdentityBuilder {
+	b.identity.Role = auth.RoleAdmin
+	b.identity.Scope = auth.IdentityScope{}
+	return b
+}
+
+// Participant sets the participant role scoped to the participant
+func (b *IdentityBuilder) Participant(participantID properties.UUID) *IdentityBuilder {
+	b.identity.Role = auth.RoleParticipant
+	b.identity.Scope = auth.IdentityScope{ParticipantID: &participantID}
+	return b
+}
+
+// Agent sets the agent role scoped to the participant and agent
+func (b *IdentityBuilder) Agent(participantID, agentID properties.UUID) *IdentityBuilder {
+	b.identity.Role = auth.RoleAgent
+	b.identity.Scope = auth.IdentityScope{ParticipantID: &participantID, AgentID: &agentID}
+	return b
+}
+
+// Organization scopes the identity to the organization
+func (b *IdentityBuilder) Organization(organizationID properties.UUID) *IdentityBuilder {
+	b.identity.Scope.OrganizationID = &organizationID
+	return b
+}
+
+// ServiceAccount marks the identity as a service account
+func (b *IdentityBuilder) ServiceAccount() *IdentityBuilder {
+	b.identity.Kind = auth.KindServiceAccount
+	return b
+}
+
+// WithOAuthScopes sets the OAuth scopes granted to the token
+func (b *IdentityBuilder) WithOAuthScopes(scopes ...string) *IdentityBuilder {
+	b.identity.OAuthScopes = scopes
+	return b
+}
+
+// WithAttribute sets an identity attribute
+func (b *IdentityBuilder) WithAttribute(key string, value any) *IdentityBuilder {
+	if b.identity.Attributes == nil {
+		b.identity.Attributes = map[string]any{}
+	}
+	b.identity.Attributes[key] = value
+	return b
+}
+
+// Build returns a copy of the identity, panicking if it is invalid
+func (b *IdentityBuilder) Build() *auth.Identity {
+	id := b.identity.Clone()
+	if err := id.Validate(); err != nil {
+		panic("invalid test identity: " + err.Error())
+	}
+	return id
+}
+
+// Context returns a copy of ctx carrying the identity
+func (b *IdentityBuilder) Context(ctx context.Context) context.Context {
+	return auth.WithIdentity(ctx, b.Build())
+}
@@ -0,0 +1,99 @@
+package testsupport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulcrumproject/commons/auth"
+	"github.com/fulcrumproject/commons/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityBuilder(t *testing.T) {
+	participantID := properties.NewUUID()
+	agentID := properties.NewUUID()
+	id := properties.NewUUID()
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		expected auth.Identity
+	}{
+		{
+			name:     "Admin",
+			identity: NewIdentity().WithID(id).Build(),
+			expected: auth.Identity{ID: id, Name: "test-user", Role: auth.RoleAdmin},
+		},
+		{
+			name:     "Participant",
+			identity: NewIdentity().WithID(id).WithName("p").Participant(participantID).Build(),
+			expected: auth.Identity{
+				ID:    id,
+				Name:  "p",
+				Role:  auth.RoleParticipant,
+				Scope: auth.IdentityScope{ParticipantID: &participantID},
+			},
+		},
+		{
+			name:     "Agent",
+			identity: NewIdentity().WithID(id).Agent(participantID, agentID).Build(),
+			expected: auth.Identity{
+				ID:    id,
+				Name:  "test-user",
+				Role:  auth.RoleAgent,
+				Scope: auth.IdentityScope{ParticipantID: &participantID, AgentID: &agentID},
+			},
+		},
+		{
+			name:     "Back to admin clears scope",
+			identity: NewIdentity().WithID(id).Participant(participantID).Admin().Build(),
+			expected: auth.Identity{ID: id, Name: "test-user", Role: auth.RoleAdmin},
+		},
+		{
+			name: "Service account with scopes and attributes",
+			identity: NewIdentity().WithID(id).Participant(participantID).Organization(agentID).ServiceAccount().
+				WithOAuthScopes("job:read").WithAttribute("region", "eu").Build(),
+			expected: auth.Identity{
+				ID:          id,
+				Name:        "test-user",
+				Role:        auth.RoleParticipant,
+				Kind:        auth.KindServiceAccount,
+				Scope:       auth.IdentityScope{ParticipantID: &participantID, OrganizationID: &agentID},
+				OAuthScopes: []string{"job:read"},
+				Attributes:  map[string]any{"region": "eu"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, *tt.identity)
+		})
+	}
+}
+
+func TestIdentityShortcuts(t *testing.T) {
+	assert.NoError(t, AdminIdentity().Validate())
+	assert.Equal(t, auth.RoleParticipant, ParticipantIdentity().Role)
+	assert.NoError(t, ParticipantIdentity().Validate())
+	assert.Equal(t, auth.RoleAgent, AgentIdentity().Role)
+	assert.NoError(t, AgentIdentity().Validate())
+}
+
+func TestIdentityBuilder_Context(t *testing.T) {
+	participantID := properties.NewUUID()
+	ctx := NewIdentity().Participant(participantID).Context(context.Background())
+
+	id := auth.MustGetIdentity(ctx)
+	require.NotNil(t, id.Scope.ParticipantID)
+	assert.Equal(t, participantID, *id.Scope.ParticipantID)
+}
+
+func TestIdentityBuilder_BuildCopies(t *testing.T) {
+	builder := NewIdentity().WithAttribute("region", "eu")
+	first := builder.Build()
+	first.Attributes["region"] = "us"
+
+	assert.Equal(t, "eu", builder.Build().Attributes["region"])
+}
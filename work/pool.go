@@ -0,0 +1,305 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/commons/clock"
+)
+
+var (
+	ErrQueueFull  = errors.New("work queue is full")
+	ErrPoolClosed = errors.New("work pool is closed")
+)
+
+// Priority represents the lane a task is queued in, the zero value is PriorityNormal
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// Validate ensures the Priority is one of the predefined values
+func (p Priority) Validate() error {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh:
+		return nil
+	default:
+		return fmt.Errorf("invalid task priority: %d", p)
+	}
+}
+
+// Task is a unit of work processed by the pool
+type Task struct {
+	Name     string
+	Priority Priority
+	// Timeout bounds each attempt, zero means no timeout
+	Timeout time.Duration
+	// MaxAttempts overrides the pool default when greater than zero
+	MaxAttempts int
+	Run         func(ctx context.Context) error
+}
+
+// BackoffFunc returns the delay before the given retry attempt (starting at 1)
+type BackoffFunc func(attempt int) time.Duration
+
+// ExponentialBackoff returns a BackoffFunc doubling the delay at each attempt up to max
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+// Metrics receives the pool instrumentation
+type Metrics interface {
+	ObserveQueued(name string, priority Priority)
+	ObserveRetry(name string, attempt int, err error)
+	ObserveDone(name string, duration time.Duration, err error)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveQueued(string, Priority)           {}
+func (noopMetrics) ObserveRetry(string, int, error)          {}
+func (noopMetrics) ObserveDone(string, time.Duration, error) {}
+
+// Option configures a Pool
+type Option func(*Pool)
+
+// WithWorkers sets the number of concurrent workers, values below 1 keep the default of 4
+func WithWorkers(n int) Option {
+	return func(p *Pool) {
+		if n > 0 {
+			p.workers = n
+		}
+	}
+}
+
+// WithQueueSize sets the capacity of each priority lane, negative values keep the default of 100
+func WithQueueSize(n int) Option {
+	return func(p *Pool) {
+		if n >= 0 {
+			p.queueSize = n
+		}
+	}
+}
+
+// WithRetry sets the default attempts per task and the backoff between them
+func WithRetry(maxAttempts int, backoff BackoffFunc) Option {
+	return func(p *Pool) {
+		p.maxAttempts = maxAttempts
+		p.backoff = backoff
+	}
+}
+
+// WithMetrics sets the metrics receiver
+func WithMetrics(m Metrics) Option {
+	return func(p *Pool) {
+		p.metrics = m
+	}
+}
+
+// WithClock sets the clock used for backoff and durations
+func WithClock(c clock.Clock) Option {
+	return func(p *Pool) {
+		p.clock = c
+	}
+}
+
+// Pool is a bounded worker pool with priority lanes
+// Higher priority lanes are always drained before lower ones
+type Pool struct {
+	workers     int
+	queueSize   int
+	maxAttempts int
+	backoff     BackoffFunc
+	metrics     Metrics
+	clock       clock.Clock
+
+	lanes   []chan Task // indexed from highest to lowest priority
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closing chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewPool creates a pool and starts its workers
+func NewPool(opts ...Option) *Pool {
+	p := &Pool{
+		workers:     4,
+		queueSize:   100,
+		maxAttempts: 1,
+		backoff:     ExponentialBackoff(100*time.Millisecond, 10*time.Second),
+		metrics:     noopMetrics{},
+		clock:       clock.New(),
+		closing:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.lanes = []chan Task{
+		make(chan Task, p.queueSize),
+		make(chan Task, p.queueSize),
+		make(chan Task, p.queueSize),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	p.wg.Add(p.workers)
+	for range p.workers {
+		go p.worker()
+	}
+	return p
+}
+
+// Submit queues a task without blocking
+func (p *Pool) Submit(task Task) error {
+	if err := task.Priority.Validate(); err != nil {
+		return err
+	}
+	if task.Run == nil {
+		return errors.New("task has no run function")
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case p.lane(task.Priority) <- task:
+		p.metrics.ObserveQueued(task.Name, task.Priority)
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting tasks and waits for the queued ones to complete
+// If ctx expires first, running tasks are cancelled, the queued ones are dropped without running
+// and the context error is returned
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.closing)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) lane(priority Priority) chan Task {
+	return p.lanes[PriorityHigh-priority]
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+	for {
+		task, ok := p.next()
+		if !ok {
+			return
+		}
+		// The shutdown deadline expired, the remaining tasks are dropped
+		if err := p.ctx.Err(); err != nil {
+			p.metrics.ObserveDone(task.Name, 0, err)
+			continue
+		}
+		p.process(task)
+	}
+}
+
+// next returns the highest priority queued task, blocking until one is available
+// After shutdown it keeps draining and returns false once all lanes are empty
+func (p *Pool) next() (Task, bool) {
+	for _, lane := range p.lanes {
+		select {
+		case t := <-lane:
+			return t, true
+		default:
+		}
+	}
+
+	select {
+	case t := <-p.lanes[0]:
+		return t, true
+	case t := <-p.lanes[1]:
+		return t, true
+	case t := <-p.lanes[2]:
+		return t, true
+	case <-p.closing:
+		for _, lane := range p.lanes {
+			select {
+			case t := <-lane:
+				return t, true
+			default:
+			}
+		}
+		return Task{}, false
+	}
+}
+
+func (p *Pool) process(task Task) {
+	maxAttempts := p.maxAttempts
+	if task.MaxAttempts > 0 {
+		maxAttempts = task.MaxAttempts
+	}
+
+	start := p.clock.Now()
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = p.attempt(task)
+		if err == nil || attempt >= maxAttempts || p.ctx.Err() != nil {
+			break
+		}
+		p.metrics.ObserveRetry(task.Name, attempt, err)
+
+		select {
+		case <-p.ctx.Done():
+		case <-p.clock.After(p.backoff(attempt)):
+		}
+	}
+	p.metrics.ObserveDone(task.Name, p.clock.Since(start), err)
+}
+
+func (p *Pool) attempt(task Task) (err error) {
+	ctx := p.ctx
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, task.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task %s panicked: %v", task.Name, r)
+		}
+	}()
+	return task.Run(ctx)
+}
@@ -0,0 +1,241 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+
+	assert.Equal(t, 10*time.Millisecond, backoff(1))
+	assert.Equal(t, 20*time.Millisecond, backoff(2))
+	assert.Equal(t, 40*time.Millisecond, backoff(3))
+	assert.Equal(t, 50*time.Millisecond, backoff(4))
+	assert.Equal(t, 50*time.Millisecond, backoff(10))
+}
+
+func TestPool_Submit(t *testing.T) {
+	tests := []struct {
+		name        string
+		task        Task
+		expectedErr string
+	}{
+		{
+			name:        "Invalid priority",
+			task:        Task{Priority: Priority(42), Run: func(context.Context) error { return nil }},
+			expectedErr: "invalid task priority: 42",
+		},
+		{
+			name:        "Missing run function",
+			task:        Task{Priority: PriorityNormal},
+			expectedErr: "task has no run function",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewPool(WithWorkers(1))
+			defer pool.Shutdown(context.Background())
+
+			err := pool.Submit(tt.task)
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestPool_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool := NewPool(WithWorkers(1), WithQueueSize(1))
+
+	var once sync.Once
+	blocking := Task{Run: func(context.Context) error {
+		once.Do(func() { close(started) })
+		<-release
+		return nil
+	}}
+	require.NoError(t, pool.Submit(blocking))
+	// Wait for the worker to pick the first task so the lane is empty again
+	<-started
+	require.NoError(t, pool.Submit(blocking))
+	assert.ErrorIs(t, pool.Submit(blocking), ErrQueueFull)
+
+	close(release)
+	require.NoError(t, pool.Shutdown(context.Background()))
+	assert.ErrorIs(t, pool.Submit(blocking), ErrPoolClosed)
+}
+
+func TestPool_Priority(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(WithWorkers(1))
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	// Keep the single worker busy while tasks are queued
+	started := make(chan struct{})
+	require.NoError(t, pool.Submit(Task{Run: func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}}))
+	<-started
+	require.NoError(t, pool.Submit(Task{Priority: PriorityLow, Run: record("low")}))
+	require.NoError(t, pool.Submit(Task{Priority: PriorityNormal, Run: record("normal")}))
+	require.NoError(t, pool.Submit(Task{Priority: PriorityHigh, Run: record("high")}))
+	require.NoError(t, pool.Submit(Task{Run: record("unset")}))
+
+	close(release)
+	require.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, []string{"high", "normal", "unset", "low"}, order, "tasks without priority are normal")
+}
+
+func TestPool_Retry(t *testing.T) {
+	metrics := &recordingMetrics{}
+	pool := NewPool(
+		WithWorkers(1),
+		WithRetry(3, func(int) time.Duration { return time.Millisecond }),
+		WithMetrics(metrics),
+	)
+
+	var calls atomic.Int32
+	require.NoError(t, pool.Submit(Task{
+		Name: "flaky",
+		Run: func(context.Context) error {
+			if calls.Add(1) < 3 {
+				return errors.New("transient")
+			}
+			return nil
+		},
+	}))
+	require.NoError(t, pool.Submit(Task{
+		Name:        "once",
+		MaxAttempts: 1,
+		Run:         func(context.Context) error { return errors.New("fatal") },
+	}))
+	require.NoError(t, pool.Submit(Task{
+		Name: "panics",
+		Run:  func(context.Context) error { panic("boom") },
+	}))
+
+	require.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 2+2, metrics.retries)
+	assert.NoError(t, metrics.done["flaky"])
+	assert.EqualError(t, metrics.done["once"], "fatal")
+	assert.EqualError(t, metrics.done["panics"], "task panics panicked: boom")
+}
+
+func TestPool_Timeout(t *testing.T) {
+	pool := NewPool(WithWorkers(1))
+
+	result := make(chan error, 1)
+	require.NoError(t, pool.Submit(Task{
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			result <- ctx.Err()
+			return ctx.Err()
+		},
+	}))
+
+	require.NoError(t, pool.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-result, context.DeadlineExceeded)
+}
+
+func TestPool_ShutdownDrains(t *testing.T) {
+	pool := NewPool(WithWorkers(2))
+
+	var done atomic.Int32
+	for range 10 {
+		require.NoError(t, pool.Submit(Task{Run: func(context.Context) error {
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+			return nil
+		}}))
+	}
+
+	require.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, int32(10), done.Load())
+}
+
+func TestPool_ShutdownDeadline(t *testing.T) {
+	pool := NewPool(WithWorkers(1))
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	require.NoError(t, pool.Submit(Task{Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}}))
+	<-started
+
+	var ran atomic.Int32
+	for range 3 {
+		require.NoError(t, pool.Submit(Task{Run: func(context.Context) error {
+			ran.Add(1)
+			return nil
+		}}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+	<-cancelled
+	assert.Zero(t, ran.Load(), "queued tasks are dropped once the deadline expired")
+}
+
+func TestPool_InvalidOptions(t *testing.T) {
+	pool := NewPool(WithWorkers(-1), WithQueueSize(-1))
+	assert.Equal(t, 4, pool.workers)
+	assert.Equal(t, 100, pool.queueSize)
+
+	done := make(chan struct{})
+	require.NoError(t, pool.Submit(Task{Run: func(context.Context) error {
+		close(done)
+		return nil
+	}}))
+	<-done
+	require.NoError(t, pool.Shutdown(context.Background()))
+}
+
+type recordingMetrics struct {
+	mu      sync.Mutex
+	retries int
+	done    map[string]error
+}
+
+func (m *recordingMetrics) ObserveQueued(string, Priority) {}
+
+func (m *recordingMetrics) ObserveRetry(string, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+func (m *recordingMetrics) ObserveDone(name string, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done == nil {
+		m.done = map[string]error{}
+	}
+	m.done[name] = err
+}